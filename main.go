@@ -1,446 +1,1287 @@
-package main
-
-import (
-	"archive/zip"
-	"bufio"
-	"bytes"
-	"errors"
-	"flag"
-	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
-	"io"
-	"log"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
-	"sync"
-	"time"
-)
-
-// 版本号
-const Version = "v0.2.0"
-
-// 支持的文件类型枚举（按处理方式分类）
-var (
-	// Office OpenXML：docx/xlsx/pptx 通过删除 zip 内的 docProps/* 实现属性清除
-	openXMLSet = map[string]bool{
-		".docx": true, ".xlsx": true, ".pptx": true,
-	}
-	// OpenDocument：odt/ods/odp 通过删除 zip 内的 meta.xml 实现属性清除
-	openDocSet = map[string]bool{
-		".odt": true, ".ods": true, ".odp": true,
-	}
-	// 图片：jpeg/jpg、png 通过解码再无元数据重编码
-	imageSet = map[string]bool{
-		".jpg": true, ".jpeg": true, ".png": true,
-	}
-	// 其他：pdf 需要可选依赖（pdfcpu），见 --with-pdf 标志
-)
-
-// 命令行参数
-var (
-	inputPath  string
-	backup     bool
-	dryRun     bool
-	workers    int
-	withPDF    bool
-	includeExt string
-	excludeExt string
-	verbose    bool
-)
-
-func init() {
-	flag.StringVar(&inputPath, "path", "", "待处理文件或目录路径（支持文件或目录）")
-	flag.BoolVar(&backup, "backup", true, "是否保留 .bak 备份（默认保留）")
-	flag.BoolVar(&dryRun, "dry-run", false, "仅演示将要处理的文件，不做任何修改")
-	flag.IntVar(&workers, "workers", max(2, runtime.NumCPU()), "并发处理的工作协程数")
-	flag.BoolVar(&withPDF, "with-pdf", false, "启用 PDF 脱敏（需要额外依赖 pdfcpu，见源码注释）")
-	flag.StringVar(&includeExt, "include", "", "仅处理这些扩展名（逗号分隔，例如: docx,xlsx,pptx,pdf,jpg,png）")
-	flag.StringVar(&excludeExt, "exclude", "", "排除这些扩展名（逗号分隔）")
-	flag.BoolVar(&verbose, "v", false, "输出更多日志")
-}
-
-func main() {
-	flag.Parse()
-	if inputPath == "" {
-		fmt.Printf("goscrub %s\n用法: goscrub --path <文件或目录> [--with-pdf] [--backup] [--workers N] [--dry-run] [--include ext1,ext2] [--exclude ext1,ext2]\n", Version)
-		os.Exit(2)
-	}
-
-	// 规范化 include/exclude 列表
-	inc := toSet(includeExt)
-	exc := toSet(excludeExt)
-
-	// 收集待处理文件
-	var files []string
-	info, err := os.Stat(inputPath)
-	if err != nil {
-		log.Fatalf("路径无法访问: %v", err)
-	}
-	if info.IsDir() {
-		err = filepath.WalkDir(inputPath, func(p string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if d.IsDir() {
-				return nil
-			}
-			ext := strings.ToLower(filepath.Ext(p))
-			if len(inc) > 0 && !inc[trimDot(ext)] {
-				return nil
-			}
-			if exc[trimDot(ext)] {
-				return nil
-			}
-			if isSupportedExt(ext) {
-				files = append(files, p)
-			}
-			return nil
-		})
-		if err != nil {
-			log.Fatalf("遍历目录失败: %v", err)
-		}
-	} else {
-		ext := strings.ToLower(filepath.Ext(inputPath))
-		if len(inc) > 0 && !inc[trimDot(ext)] {
-			log.Fatalf("不在 include 列表: %s", inputPath)
-		}
-		if exc[trimDot(ext)] {
-			log.Fatalf("在 exclude 列表中: %s", inputPath)
-		}
-		if !isSupportedExt(ext) {
-			log.Fatalf("暂不支持的文件类型: %s", ext)
-		}
-		files = []string{inputPath}
-	}
-
-	if len(files) == 0 {
-		fmt.Println("没有匹配到可处理的文件。")
-		return
-	}
-
-	fmt.Printf("发现 %d 个待处理文件。\n", len(files))
-	if dryRun {
-		for _, f := range files {
-			fmt.Println("- ", f)
-		}
-		return
-	}
-
-	// 并发处理
-	jobs := make(chan string, len(files))
-	wg := sync.WaitGroup{}
-	okCount := int64(0)
-	failCount := int64(0)
-
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for f := range jobs {
-				if err := scrubFile(f); err != nil {
-					log.Printf("[FAIL] %s: %v", f, err)
-					add(&failCount, 1)
-				} else {
-					if verbose {
-						log.Printf("[OK] %s", f)
-					}
-					add(&okCount, 1)
-				}
-			}
-		}()
-	}
-	for _, f := range files {
-		jobs <- f
-	}
-	close(jobs)
-	wg.Wait()
-
-	fmt.Printf("处理完成：成功 %d，失败 %d。\n", okCount, failCount)
-}
-
-func scrubFile(p string) error {
-	ext := strings.ToLower(filepath.Ext(p))
-	// 为避免 “文件被占用” 问题：以只读打开探测，随后复制到临时文件再原子替换
-	// Windows 上如果目标被占用会报错，建议关闭占用应用或加重试
-
-	switch {
-	case openXMLSet[ext]:
-		return scrubOpenXML(p)
-	case openDocSet[ext]:
-		return scrubOpenDocument(p)
-	case imageSet[ext]:
-		return scrubImage(p, ext)
-	case ext == ".pdf":
-		if !withPDF {
-			return errors.New("检测到 PDF，请使用 --with-pdf 以启用 PDF 脱敏（需要 pdfcpu 依赖）")
-		}
-		return scrubPDF(p)
-	default:
-		return fmt.Errorf("不支持的扩展名: %s", ext)
-	}
-}
-
-// —— Office OpenXML: 过滤 zip 中的 docProps/* ——
-func scrubOpenXML(path string) error {
-	return rewriteZip(path, func(name string) bool {
-		// 返回 true 表示保留该条目
-		lower := strings.ToLower(name)
-		if strings.HasPrefix(lower, "docprops/") {
-			return false // 丢弃所有属性文件: core.xml, app.xml, custom.xml
-		}
-		return true
-	})
-}
-
-// —— OpenDocument: 删除根目录 meta.xml ——
-func scrubOpenDocument(path string) error {
-	return rewriteZip(path, func(name string) bool {
-		lower := strings.ToLower(name)
-		if lower == "meta.xml" {
-			return false
-		}
-		return true
-	})
-}
-
-// —— 图片：解码->无元数据重编码 ——
-func scrubImage(path, ext string) error {
-	in, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	img, format, err := image.Decode(bufio.NewReader(in))
-	if err != nil {
-		return fmt.Errorf("图片解码失败: %w", err)
-	}
-	_ = format // 仅供调试
-
-	// 写入到临时文件
-	tmp := path + ".tmp"
-	out, err := os.Create(tmp)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	switch ext {
-	case ".jpg", ".jpeg":
-		// 重新编码会丢弃 EXIF/XMP
-		if err := jpeg.Encode(out, img, &jpeg.Options{Quality: 95}); err != nil {
-			return err
-		}
-	case ".png":
-		enc := png.Encoder{CompressionLevel: png.BestCompression}
-		if err := enc.Encode(out, img); err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("未知图片类型: %s", ext)
-	}
-
-	return replaceOriginal(path, tmp)
-}
-
-// —— PDF：使用 pdfcpu 清除元数据（需要 go get github.com/pdfcpu/pdfcpu@latest）——
-// 说明：
-// 1) 请在构建前执行： go get github.com/pdfcpu/pdfcpu@latest
-// 2) pdfcpu 的 Clean/Optimize 会去除冗余对象，SetMetadata 可清空 XMP；同时可清空 Info Dict。
-// 3) 某些加密/权限受限的 PDF 可能需要密码，本文未处理。
-func scrubPDF(path string) error {
-	// 为避免在未安装依赖时无法构建，代码在此处做延迟加载（接口解耦）。
-	return scrubPDFWithPDFCPU(path)
-}
-
-// —— ZIP 重写通用函数 ——
-func rewriteZip(path string, keep func(name string) bool) error {
-	// 读取原始二进制到内存，尽量减少占用冲突
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
-	if err != nil {
-		return fmt.Errorf("打开 zip 失败: %w", err)
-	}
-
-	// 写入到临时 zip
-	tmp := path + ".tmp"
-	f, err := os.Create(tmp)
-	if err != nil {
-		return err
-	}
-	zw := zip.NewWriter(f)
-
-	for _, zf := range zr.File {
-		if !keep(zf.Name) {
-			continue
-		}
-
-		// 打开源条目
-		r, err := zf.Open()
-		if err != nil {
-			zw.Close()
-			f.Close()
-			os.Remove(tmp)
-			return fmt.Errorf("读取条目失败 %s: %w", zf.Name, err)
-		}
-		// 创建目标条目，尽量保留压缩方式
-		h := &zip.FileHeader{Name: zf.Name, Method: zf.Method}
-		h.SetMode(zf.Mode())
-		h.Modified = zf.Modified
-		w, err := zw.CreateHeader(h)
-		if err != nil {
-			r.Close()
-			zw.Close()
-			f.Close()
-			os.Remove(tmp)
-			return err
-		}
-		if _, err := io.Copy(w, r); err != nil {
-			r.Close()
-			zw.Close()
-			f.Close()
-			os.Remove(tmp)
-			return err
-		}
-		r.Close()
-	}
-
-	if err := zw.Close(); err != nil {
-		f.Close()
-		os.Remove(tmp)
-		return err
-	}
-	if err := f.Close(); err != nil {
-		os.Remove(tmp)
-		return err
-	}
-
-	return replaceOriginal(path, tmp)
-}
-
-// —— 原子替换并保留备份 ——
-func replaceOriginal(orig, tmp string) error {
-	if backup {
-		bak := orig + ".bak"
-		if _, err := os.Stat(bak); err == nil {
-			bak = fmt.Sprintf("%s.%d.bak", orig, time.Now().Unix())
-		}
-		if err := copyFile(orig, bak); err != nil {
-			return fmt.Errorf("创建备份失败: %w", err)
-		}
-	}
-
-	// 原子替换失败时，尝试直接覆盖写入
-	for i := 0; i < 2; i++ {
-		if err := os.Rename(tmp, orig); err != nil {
-			if i == 0 {
-				time.Sleep(300 * time.Millisecond)
-				continue
-			}
-			// fallback: 用 copy 覆盖
-			if err := copyFile(tmp, orig); err != nil {
-				return fmt.Errorf("替换原文件失败（可能被占用）: %w", err)
-			}
-			os.Remove(tmp)
-			return nil
-		}
-		return nil
-	}
-	return nil
-}
-
-func copyFile(src, dst string) error {
-	s, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer s.Close()
-	d, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer d.Close()
-	_, err = io.Copy(d, s)
-	return err
-}
-
-// —— 小工具函数 ——
-func isSupportedExt(ext string) bool {
-	if openXMLSet[ext] || openDocSet[ext] || imageSet[ext] {
-		return true
-	}
-	if ext == ".pdf" {
-		return true
-	}
-	return false
-}
-
-func toSet(csv string) map[string]bool {
-	res := map[string]bool{}
-	if strings.TrimSpace(csv) == "" {
-		return res
-	}
-	for _, v := range strings.Split(csv, ",") {
-		v = strings.TrimSpace(strings.ToLower(v))
-		v = trimDot(v)
-		if v != "" {
-			res[v] = true
-		}
-	}
-	return res
-}
-
-func trimDot(ext string) string {
-	return strings.TrimPrefix(ext, ".")
-}
-
-func add(ptr *int64, delta int64) {
-	// 无需原子性，这里非关键统计，若要原子请使用 atomic.AddInt64
-	*ptr += delta
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-// ========== 可选：pdfcpu 清理实现 ==========
-// 将此部分单独放置，避免未安装依赖时报编译错误。
-// 若要启用：
-//   go get github.com/pdfcpu/pdfcpu@latest
-// 然后正常 go build / run，并加 --with-pdf
-
-// 为了在未引入依赖的情况下也能编译，这里采用 build tags 的技巧：
-// 你可以创建一个同目录文件 pdf_stub.go 存根（见下备注），或者直接使用下方的反射式延迟导入方案。
-
-// 简化处理：我们在此给出一个占位实现，提示未启用 PDF 支持。
-// 如需真正生效，请将本函数替换为使用 pdfcpu 的实现（示例见下方注释）。
-
-func scrubPDFWithPDFCPU(path string) error {
-	// ===== 如需启用真正的 PDF 清理，请参考： =====
-	// import (
-	//   pdfapi "github.com/pdfcpu/pdfcpu/pkg/api"
-	//   "github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
-	// )
-	// conf := pdfcpu.NewDefaultConfiguration()
-	// // 1) 清空 XMP 元数据
-	// if err := pdfapi.SetMetadataFile(path, path+".tmp", nil, conf); err != nil { return err }
-	// // 2) 清空 Info 字典
-	// infos := map[string]string{"Title":"","Author":"","Subject":"","Keywords":"","Creator":"","Producer":""}
-	// if err := pdfapi.SetInfoMapFile(path, path+".tmp2", infos, conf); err != nil { return err }
-	// // 3) 进一步优化/清理
-	// if err := pdfapi.OptimizeFile(path+".tmp2", path, conf); err != nil { return err }
-	// os.Remove(path+".tmp")
-	// os.Remove(path+".tmp2")
-	return errors.New("未编译 PDF 支持：请执行 `go get github.com/pdfcpu/pdfcpu@latest` 并使用 --with-pdf 重新运行；同时将 scrubPDFWithPDFCPU 实现替换为 pdfcpu 版本（见源码注释）")
-}
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kkive/Word_DataMasking/scrub"
+)
+
+// 版本号
+const Version = "v0.2.0"
+
+// 退出码
+const (
+	exitFailed      = 1   // 有文件处理失败（--fail-on-error）或运行时错误
+	exitUsage       = 2   // 参数错误
+	exitInterrupted = 130 // 被 Ctrl-C / SIGTERM 中断
+)
+
+// 命令行参数
+var (
+	inputPath  string
+	backup     bool
+	backupDir  string
+	dryRun     bool
+	dryFormat  string
+	limitN     int
+	sample     bool
+	workers    int
+	workAuto   bool
+	walkN      int
+	withPDF    bool
+	withHEIC   bool
+	includeExt string
+	excludeExt string
+	excludeDir string
+	includeGlb string
+	excludeGlb string
+	verbose    bool
+	outputDir  string
+	suffix     string
+	stripMode  string
+	keepThumb  bool
+	gpsOnly    bool
+	jpegQ      int
+	keepExact  bool
+	reportPath string
+	zeroTimes  bool
+	determ     bool
+	deepOffice bool
+	deepXLSX   bool
+	deepODF    bool
+	minProps   bool
+	zipExtra   bool
+	normNames  bool
+	unsafeName bool
+	stripNotes bool
+	stripMacro bool
+	derefCT    bool
+	stripODF   bool
+	restore    bool
+	fromStdin  bool
+	pdfPass    string
+	pdfPWList  string
+	pdfDecrypt bool
+	withVideo  bool
+	keepMtime  bool
+	strictExt  bool
+	stripICC   bool
+	verifyOut  bool
+	verifyRB   bool
+	checkSum   bool
+	manifest   string
+	fileTO     time.Duration
+	stateFile  string
+	force      bool
+	failOnErr  bool
+	onlyMeta   bool
+	confirm    bool
+	extractMD  bool
+	confirmOne bool
+	assumeYes  bool
+	quarDir    string
+	quarMove   bool
+	recurseZip bool
+	maxMemMB   int64
+	quiet      bool
+	retries    int
+	retryDelay time.Duration
+	maxSize    string
+	since      string
+	followLink bool
+	extLinks   bool
+	logLevel   string
+	logFormat  string
+	listHandle bool
+	copyUnsup  bool
+	zipMethod  string
+	hashList   string
+	recompress bool
+	serveAddr  string
+	serveMax   string
+	serveConc  int
+	auditPath  string
+	stripMac   bool
+	numRetries int
+	fileDelay  time.Duration
+	pdfAttach  bool
+	pdfAnnots  bool
+)
+
+// lg 为全局日志，flag 解析后按 --v/--log-level/--log-format 创建
+var lg = scrub.NewLogger(os.Stderr, scrub.LevelWarn, false)
+
+func init() {
+	flag.StringVar(&inputPath, "path", "", "待处理文件或目录路径（支持文件、目录或通配符如 reports/**/*.docx；- 表示从标准输入读取文件列表）")
+	flag.BoolVar(&backup, "backup", true, "是否保留 .bak 备份（默认保留）")
+	flag.StringVar(&backupDir, "backup-dir", "", "将 .bak 备份按相对路径写入该目录，而不是原文件旁边（--restore 时同样指定）")
+	flag.BoolVar(&dryRun, "dry-run", false, "仅检查并列出每个文件中将被删除的元数据，不做任何修改")
+	flag.StringVar(&dryFormat, "dry-run-format", "list", "dry-run 的输出格式：list（逐个文件列出）、tree（按目录层级列出）或 json（含类型与计划操作，供脚本读取）")
+	flag.IntVar(&limitN, "limit", 0, "只处理过滤后的前 N 个文件，用于先在少量文件上试验选项；0 表示不限制")
+	flag.BoolVar(&sample, "sample", false, "配合 --limit：随机抽取 N 个文件，而不是取前 N 个")
+	flag.IntVar(&workers, "workers", max(2, runtime.NumCPU()), "并发处理的工作协程数")
+	flag.BoolVar(&workAuto, "workers-auto", false, "按类型分池并发：图片/PDF 按 CPU 核数（或 --workers），Office 等 zip 重写最多 4 个，适合机械硬盘上的混合目录")
+	flag.IntVar(&walkN, "walk-workers", 0, "并发遍历目录的协程数（大于 1 时启用），适合网络文件系统上条目很多的目录；0 表示顺序遍历")
+	flag.Int64Var(&maxMemMB, "max-memory", 1024, "所有 worker 同时占用的内存预算（MB），大文件会自动降低并发")
+	flag.BoolVar(&withPDF, "with-pdf", false, "启用 PDF 脱敏（需以 -tags withpdf 构建，依赖 pdfcpu）")
+	flag.StringVar(&pdfPass, "pdf-password", "", "加密 PDF 的密码（同时作为用户密码与所有者密码尝试）")
+	flag.StringVar(&pdfPWList, "pdf-password-list", "", "候选密码文件（每行一个）：加密 PDF 依次尝试其中的密码（在 --pdf-password 之后），直到能打开为止")
+	flag.BoolVar(&pdfAttach, "pdf-strip-attachments", false, "删除 PDF 中的附件（嵌入文件与附件注释）与文档级 JavaScript（需 --with-pdf）")
+	flag.BoolVar(&pdfAnnots, "pdf-strip-annotations", false, "删除 PDF 批注、便笺与高亮等注释（保留链接与表单控件），并清除表单字段的填写值（需 --with-pdf）")
+	flag.BoolVar(&pdfDecrypt, "pdf-decrypt", false, "输出时去除 PDF 加密（默认按原加密方式写回）")
+	flag.BoolVar(&withHEIC, "with-heic", false, "启用 HEIC/HEIF 脱敏（需以 -tags withheic 构建，输出转为 JPEG）")
+	flag.BoolVar(&withVideo, "with-video", false, "启用 MP4/MOV 脱敏：删除 moov 中的 udta/meta（GPS ©xyz、设备型号）与 XMP 盒子，不重新编码")
+	flag.StringVar(&includeExt, "include", "", "仅处理这些扩展名（逗号分隔，例如: docx,xlsx,pptx,pdf,jpg,png,tif）")
+	flag.StringVar(&excludeExt, "exclude", "", "排除这些扩展名（逗号分隔）")
+	flag.StringVar(&includeGlb, "include-glob", "", "仅处理相对路径匹配这些通配符的文件（逗号分隔，支持 **，例如: 2023-*/invoices/*.pdf）")
+	flag.StringVar(&excludeGlb, "exclude-glob", "", "排除相对路径匹配这些通配符的文件（逗号分隔，例如: **/templates/**）")
+	flag.StringVar(&excludeDir, "exclude-dir", "", "遍历时跳过的目录名或通配符（逗号分隔，例如: node_modules,.git,backup*）")
+	flag.BoolVar(&followLink, "follow-symlinks", false, "遍历目录时跟随符号链接（默认跳过；指向文件的链接会处理其目标，链接本身保持不变）")
+	flag.BoolVar(&extLinks, "allow-external-symlinks", false, "配合 --follow-symlinks，允许处理指向输入目录之外的链接目标")
+	flag.StringVar(&maxSize, "max-file-size", "", "跳过超过该大小的文件（如 100MB、2GB，不带单位为字节），默认不限制")
+	flag.StringVar(&since, "since", "", "只处理修改时间晚于该时刻的文件：RFC3339 时间、日期（2006-01-02，本地时区）或相对时长（如 24h、7d）")
+	flag.BoolVar(&verbose, "v", false, "输出更多日志（等同于 --log-level debug）")
+	flag.StringVar(&logLevel, "log-level", "", "日志级别：debug/info/warn/error，默认 warn（指定 --v 时为 debug）")
+	flag.StringVar(&logFormat, "log-format", "text", "日志格式：text 或 json（每行一个 JSON 对象，便于日志采集）")
+	flag.BoolVar(&quiet, "quiet", false, "不显示开始时的运行信息与进度行（进度行在 stderr 不是终端时也不显示）")
+	flag.StringVar(&outputDir, "output-dir", "", "输出目录：设置后按原目录结构写入该目录，不修改原文件")
+	flag.StringVar(&suffix, "suffix", "", "在原文件旁写出带后缀的副本（如 _clean：report.docx -> report_clean.docx），不修改原文件、不生成备份")
+	flag.StringVar(&stripMode, "strip-mode", "", "JPEG 脱敏方式：lossless（删除全部元数据段，不重编码）、full（解码后重编码，去除全部元数据）、selective（仅删除 GPS/拍摄时间/设备型号与序列号，不重编码）或 gps（仅删除位置信息，也适用于 TIFF）；默认 lossless，指定 --jpeg-quality 时为 full")
+	flag.BoolVar(&keepExact, "chroma-keep-exact", false, "JPEG 一律无损处理：只删除元数据段，扫描数据原样拷贝，质量与色度抽样不变；等同 --strip-mode lossless")
+	flag.BoolVar(&gpsOnly, "gps-only", false, "只删除 JPEG/TIFF 中的位置信息（EXIF GPS 与 XMP 中的 GPS 字段），其余元数据与像素不变；等同 --strip-mode gps")
+	flag.BoolVar(&keepThumb, "keep-thumbnail", false, "selective 模式下保留 EXIF 内嵌缩略图")
+	flag.IntVar(&jpegQ, "jpeg-quality", 0, "JPEG 重编码质量（1-100），0 表示根据源文件量化表自动估算")
+	flag.BoolVar(&stripICC, "strip-icc", false, "删除图片中的 ICC 色彩配置（默认从源文件取出并嵌回，配置中可能含设备/创建者信息）")
+	flag.IntVar(&retries, "replace-retries", 5, "替换文件遇到占用（杀毒/同步软件）时的重试次数，-1 表示不重试")
+	flag.DurationVar(&fileTO, "file-timeout", 0, "单个文件的处理时限（如 30s、2m），超时记为失败并继续，0 表示不限制")
+	flag.DurationVar(&retryDelay, "replace-delay", 200*time.Millisecond, "首次重试前的等待时间，之后每次翻倍")
+	flag.IntVar(&numRetries, "num-retries", 0, "文件因暂时性错误（仍在被写入、被占用、I/O 错误）处理失败时整体重试的次数，0 表示不重试")
+	flag.DurationVar(&fileDelay, "retry-delay", time.Second, "配合 --num-retries：首次重试前的等待时间，之后每次翻倍")
+	flag.StringVar(&zipMethod, "compression-method", "keep", "重写 Office/OpenDocument 等 zip 时的压缩方式：keep 沿用源条目，deflate 压缩文本部件（已压缩的图片等媒体不变），store 全部不压缩")
+	flag.BoolVar(&recompress, "recompress", false, "对以 Store 保存的 XML 等部件重新压缩，常能明显减小 docx/pptx；等同 --compression-method deflate")
+	flag.BoolVar(&zeroTimes, "zero-timestamps", false, "将 Office/OpenDocument 内部条目的修改时间统一置为 1980-01-01，消除时间指纹")
+	flag.BoolVar(&determ, "deterministic", false, "输出可逐字节复现：zip 条目按名称排序、修改时间统一置为 1980-01-01，PDF 的日期与 /ID 固定（隐含 --zero-timestamps）")
+	flag.BoolVar(&deepXLSX, "deep-xlsx", false, "深度清理 Excel：将批注作者与线程批注人员匿名化，删除 xl/calcChain.xml")
+	flag.BoolVar(&derefCT, "dereference-content-types", false, "删除 docProps 等部件时同步去掉 _rels/.rels 与 [Content_Types].xml 中的引用，避免严格的校验工具报告悬空关系")
+	flag.BoolVar(&stripMacro, "strip-macros", false, "删除 docm/xlsm/pptm/vsdm 中的 VBA 宏工程（vbaProject.bin）及其关系与内容类型声明")
+	flag.BoolVar(&stripNotes, "strip-notes", false, "删除 PowerPoint 演讲者备注（ppt/notesSlides/）")
+	flag.BoolVar(&stripODF, "strip-odf-extras", false, "删除 OpenDocument 的数字签名（META-INF/documentsignatures.xml 等）与缩略图（Thumbnails/）")
+	flag.BoolVar(&normNames, "normalize-filenames", false, "重写 zip 类文件时规范化条目名：反斜杠换成 /，去掉多余的 ./ 与重复分隔符")
+	flag.BoolVar(&unsafeName, "allow-unsafe-names", false, "照常处理条目名为绝对路径、含 .. 或 NUL 的 zip/tar（默认拒绝，防止 zip-slip），条目名原样保留")
+	flag.BoolVar(&zipExtra, "strip-extra", false, "重写 zip 类文件时条目不带任何扩展字段（含修改时间戳），只保留 DOS 时间；源条目的 UID/GID、NTFS 时间等扩展字段始终不复制")
+	flag.BoolVar(&minProps, "keep-minimal-props", false, "不删除 docProps/core.xml 与 app.xml，改为替换成不含任何属性的空部件（custom.xml 等照常删除），兼容要求这两个部件存在的系统")
+	flag.BoolVar(&deepODF, "deep-odf", false, "深度清理 OpenDocument：将修订记录与批注的作者匿名化，时间改为 1980-01-01，修订结构保持不变")
+	flag.BoolVar(&deepOffice, "deep-office", false, "深度清理 Office：删除 customXml/、docMetadata/，并将 Word 修订与批注作者匿名化、删除修订时间")
+	flag.BoolVar(&recurseZip, "recursive-zip", false, "递归脱敏文档中嵌入的 Office 文件与嵌套 zip（最多 3 层，总大小上限 256MB）")
+	flag.BoolVar(&restore, "restore", false, "从 .bak 备份恢复原文件并删除所用备份（存在多个备份时取最新的一个）")
+	flag.StringVar(&manifest, "manifest", "", "按 CSV/TSV 清单处理（列 path,strip-mode,output-path），逐文件覆盖全局选项")
+	flag.BoolVar(&fromStdin, "from-stdin", false, "从标准输入逐行读取待处理文件路径，等同于 --path -")
+	flag.BoolVar(&keepMtime, "preserve-mtime", false, "处理后保留原文件的修改时间，避免备份/同步工具误判")
+	flag.BoolVar(&strictExt, "strict-ext", false, "只按扩展名判断文件类型，不读取文件头识别真实格式")
+	flag.BoolVar(&verifyOut, "verify", false, "处理后重新读取输出，确认元数据已删除；未通过的文件记为失败")
+	flag.BoolVar(&verifyRB, "verify-rollback", false, "配合 --verify：校验未通过时用 .bak 备份恢复原文件")
+	flag.BoolVar(&checkSum, "checksum-content", false, "比较处理前后的图片像素与文档正文文本，不一致时输出警告")
+	flag.StringVar(&stateFile, "state-file", "", "增量处理的状态文件（如 "+scrub.DefaultStatePath()+"）：跳过上次以相同选项处理且此后未改动的文件，默认不记录")
+	flag.StringVar(&quarDir, "quarantine-dir", "", "将处理失败的文件按相对路径复制到该目录，便于集中复查")
+	flag.BoolVar(&quarMove, "quarantine-move", false, "配合 --quarantine-dir：移动失败的文件而不是复制")
+	flag.BoolVar(&failOnErr, "fail-on-error", true, "有文件处理失败时以退出码 1 结束；设为 false 则尽力处理、始终以 0 结束")
+	flag.BoolVar(&onlyMeta, "only-metadata-present", false, "处理前先检查，未发现可删除元数据的文件直接跳过（不重新编码、不改动修改时间）")
+	flag.BoolVar(&copyUnsup, "copy-unsupported", false, "配合 --output-dir：不支持的文件原样复制到输出目录，使输出成为输入的完整镜像（仍遵循排除规则）")
+	flag.BoolVar(&stripMac, "strip-mac-files", false, "删除遍历到的 macOS 附带文件（._* AppleDouble 与 .DS_Store），并在重写 zip 类文档时丢弃 __MACOSX/ 与 ._* 条目")
+	flag.BoolVar(&force, "force", false, "忽略状态文件中的记录，全部重新处理")
+	flag.BoolVar(&extractMD, "extract-metadata", false, "处理前把将被删除的元数据另存为输出文件旁的 <文件名>.metadata.json")
+	flag.BoolVar(&confirm, "confirm", false, "处理前列出待处理文件并询问是否继续（标准输入不是终端时需加 --yes）")
+	flag.BoolVar(&confirmOne, "confirm-each", false, "写出每个文件前逐个询问（标准输入不是终端时需加 --yes）")
+	flag.BoolVar(&listHandle, "list-handlers", false, "列出支持的扩展名、各自的处理方式与删除的元数据，以及需要的选项和构建标签，然后退出")
+	flag.BoolVar(&assumeYes, "yes", false, "对 --confirm / --confirm-each 的询问一律回答是，用于脚本等非交互环境")
+	flag.StringVar(&hashList, "hash-manifest", "", "将每个成功处理的输出文件的 SHA-256 按路径排序写入该文件（sha256sum 格式）；开启 --backup 时另以注释行记录原文件的哈希")
+	flag.StringVar(&serveAddr, "serve", "", "以 HTTP 服务方式运行，监听该地址（如 :8080）：POST /scrub 上传文件（multipart 字段 file），返回处理后的文件")
+	flag.StringVar(&serveMax, "serve-max-size", "100MB", "配合 --serve：单个上传请求的大小上限（如 100MB、1GB）")
+	flag.IntVar(&serveConc, "serve-concurrency", max(2, runtime.NumCPU()), "配合 --serve：同时处理的上传数，超出的请求排队等待")
+	flag.StringVar(&auditPath, "audit-log", "", "审计日志：以追加方式每个文件写一行 JSON（路径、类型、结果、字节数、备份路径），每次运行开头记录版本与所用选项")
+	flag.StringVar(&reportPath, "report", "", "处理结束后将逐文件结果写入该 JSON 文件（dry-run 时列出将要处理的文件）")
+}
+
+func main() {
+	flag.Parse()
+	if inputPath == "-" {
+		fromStdin = true
+	}
+	if listHandle {
+		printHandlers(scrub.Handlers())
+		return
+	}
+	if inputPath == "" && !fromStdin && manifest == "" && serveAddr == "" {
+		fmt.Printf("goscrub %s\n用法: goscrub --path <文件或目录> [--with-pdf] [--with-heic] [--with-video] [--backup] [--workers N] [--dry-run] [--include ext1,ext2] [--exclude ext1,ext2] [--output-dir 目录] [--suffix _clean] [--report report.json] [--restore]\n      goscrub --serve :8080 [--serve-max-size 100MB] [--serve-concurrency N]\n", Version)
+		os.Exit(exitUsage)
+	}
+
+	if err := setupLogger(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	if keepExact {
+		if stripMode != "" && stripMode != "lossless" {
+			usagef("--chroma-keep-exact 不能与 --strip-mode %s 同时使用", stripMode)
+		}
+		if jpegQ != 0 {
+			usagef("--chroma-keep-exact 不重新编码，不能与 --jpeg-quality 同时使用")
+		}
+		stripMode = "lossless"
+	}
+
+	switch dryFormat {
+	case "list", "tree", "json":
+	default:
+		usagef("未知的 --dry-run-format: %s（可选 list/tree/json）", dryFormat)
+	}
+	if dryFormat != "list" && !dryRun {
+		usagef("--dry-run-format 需配合 --dry-run 使用")
+	}
+
+	if limitN < 0 {
+		usagef("--limit 不能为负数: %d", limitN)
+	}
+	if sample && limitN == 0 {
+		usagef("--sample 需配合 --limit N 指定抽取的文件数")
+	}
+
+	if gpsOnly {
+		if stripMode != "" && stripMode != "gps" {
+			usagef("--gps-only 不能与 --strip-mode %s 同时使用", stripMode)
+		}
+		stripMode = "gps"
+	}
+
+	if recompress {
+		if zipMethod != "keep" && zipMethod != "deflate" {
+			usagef("--recompress 不能与 --compression-method %s 同时使用", zipMethod)
+		}
+		zipMethod = "deflate"
+	}
+
+	maxFileSize, err := parseSize(maxSize)
+	if err != nil {
+		usagef("%v", err)
+	}
+	sinceTime, err := parseSince(since, time.Now())
+	if err != nil {
+		usagef("%v", err)
+	}
+
+	var pdfPasswords []string
+	if pdfPWList != "" {
+		if pdfPasswords, err = readPasswordList(pdfPWList); err != nil {
+			usagef("读取 --pdf-password-list 失败: %v", err)
+		}
+	}
+
+	s := &scrub.Scrubber{
+		Backup:      backup,
+		BackupDir:   backupDir,
+		DryRun:      dryRun,
+		Workers:     workers,
+		WorkersAuto: workAuto,
+		WalkWorkers: walkN,
+		WithPDF:     withPDF,
+		WithHEIC:    withHEIC,
+		WithVideo:   withVideo,
+		PDFPassword: pdfPass,
+		PDFDecrypt:  pdfDecrypt,
+		Verbose:     verbose,
+		Logger:      lg,
+		Progress:    !quiet && !dryRun && !confirmOne && isTerminal(os.Stderr),
+		MaxMemory:   maxMemMB << 20,
+
+		ReplaceRetries: retries,
+		ReplaceDelay:   retryDelay,
+		FileRetries:    numRetries,
+		FileRetryDelay: fileDelay,
+
+		Include:     splitList(includeExt),
+		Exclude:     splitList(excludeExt),
+		ExcludeDirs: splitList(excludeDir),
+
+		IncludeGlobs: splitList(includeGlb),
+		ExcludeGlobs: splitList(excludeGlb),
+		MaxFileSize:  maxFileSize,
+		Since:        sinceTime,
+		StrictExt:    strictExt,
+
+		FollowSymlinks:     followLink,
+		AllowExternalLinks: extLinks,
+
+		OutputDir:     outputDir,
+		Suffix:        suffix,
+		StripMode:     stripMode,
+		KeepThumbnail: keepThumb,
+		JPEGQuality:   jpegQ,
+		StripICC:      stripICC,
+
+		ZeroTimestamps:      zeroTimes,
+		Deterministic:       determ,
+		CompressionMethod:   zipMethod,
+		StripODFExtras:      stripODF,
+		PDFStripAttachments: pdfAttach,
+		PDFStripAnnotations: pdfAnnots,
+		PDFPasswords:        pdfPasswords,
+		DeepOffice:          deepOffice,
+		DeepXLSX:            deepXLSX,
+		DeepODF:             deepODF,
+		KeepMinimalProps:    minProps,
+		StripZipExtra:       zipExtra,
+		NormalizeZipNames:   normNames,
+		AllowUnsafeNames:    unsafeName,
+		StripNotes:          stripNotes,
+		StripMacros:         stripMacro,
+		DerefContentTypes:   derefCT,
+		RecursiveZip:        recurseZip,
+		PreserveMtime:       keepMtime,
+		Verify:              verifyOut,
+		VerifyRollback:      verifyRB,
+		ChecksumContent:     checkSum,
+		FileTimeout:         fileTO,
+		Force:               force,
+		OnlyMetadataPresent: onlyMeta,
+		QuarantineDir:       quarDir,
+		QuarantineMove:      quarMove,
+		ExtractMetadata:     extractMD,
+		CopyUnsupported:     copyUnsup,
+		StripMacFiles:       stripMac,
+		HashOutputs:         hashList != "" && !dryRun,
+	}
+
+	if err := s.Validate(); err != nil {
+		usagef("%v", err)
+	}
+
+	if serveAddr != "" {
+		if restore || dryRun || inputPath != "" || fromStdin || manifest != "" {
+			usagef("--serve 不能与 --path、--manifest、--restore、--dry-run 同时使用")
+		}
+		runServe(s)
+		return
+	}
+
+	if restore {
+		if fromStdin || manifest != "" {
+			usagef("--restore 不支持从标准输入或清单读取路径")
+		}
+		runRestore(s)
+		return
+	}
+
+	run := s.RunInfo(Version)
+	if !quiet {
+		printBanner(run)
+	}
+
+	// 收集待处理文件
+	var files []string
+	var entries []scrub.ManifestEntry
+	root := ""
+	stream := false // 边遍历边处理，见 ScrubDirContext
+	if manifest != "" {
+		f, err := os.Open(manifest)
+		if err != nil {
+			fatalf("打开清单失败: %v", err)
+		}
+		entries, err = s.ReadManifest(f)
+		f.Close()
+		if err != nil {
+			fatalf("%v", err)
+		}
+		for _, e := range entries {
+			files = append(files, e.Path)
+		}
+	} else if fromStdin {
+		// 相对路径在输出目录下按原结构还原，绝对路径只保留文件名
+		root = "."
+		var err error
+		files, err = s.CollectList(os.Stdin)
+		if err != nil {
+			fatalf("%v", err)
+		}
+	} else if _, err := os.Stat(inputPath); err != nil && scrub.IsGlob(inputPath) {
+		// 同名文件不存在时才按通配符展开，避免文件名本身含有 [ ] 时被误解析
+		root, files, err = s.CollectGlob(inputPath)
+		if err != nil {
+			fatalf("%v", err)
+		}
+	} else {
+		info, err := os.Stat(inputPath)
+		if err != nil {
+			fatalf("路径无法访问: %v", err)
+		}
+		if info.IsDir() {
+			root = inputPath
+			// --confirm 与 --limit 需要事先拿到完整列表，其余情况不等遍历结束即开始处理
+			stream = !confirm && limitN == 0
+			if !stream {
+				if files, err = s.Collect(inputPath); err != nil {
+					fatalf("%v", err)
+				}
+			}
+		} else {
+			if err := s.Check(inputPath); err != nil {
+				fatalf("%v", err)
+			}
+			files = []string{inputPath}
+		}
+	}
+
+	if !stream && len(files) == 0 && len(s.Unsupported()) == 0 && len(s.MacFiles()) == 0 {
+		if dryFormat == "json" {
+			fmt.Println("[]")
+			return
+		}
+		fmt.Println("没有匹配到可处理的文件。")
+		if n := s.Skipped(); n > 0 {
+			fmt.Printf("另有 %d 个文件超过 --max-file-size 被跳过。\n", n)
+		}
+		if n := s.TooOld(); n > 0 {
+			fmt.Printf("另有 %d 个文件修改时间早于 --since 被跳过。\n", n)
+		}
+		return
+	}
+
+	found := len(files)
+	if limitN > 0 && found > limitN {
+		idx := pickIndexes(found, limitN, sample)
+		files = pick(files, idx)
+		if entries != nil {
+			entries = pick(entries, idx)
+		}
+	}
+
+	// JSON 格式的标准输出只有 JSON 本身，这些数量已体现在其中；边遍历边处理时在结束后输出
+	if dryFormat != "json" && !stream {
+		printPending(s, found, len(files))
+	}
+
+	if (confirm || confirmOne) && !dryRun && !assumeYes {
+		// 从标准输入读取路径时，标准输入已被路径列表占用
+		if fromStdin || !isTerminal(os.Stdin) {
+			usagef("标准输入不是终端，无法询问确认；确认无误后加 --yes 继续")
+		}
+		if confirm {
+			listPending(files, outputDir == "" && suffix == "")
+			if !askYes("是否继续？[y/N] ") {
+				fmt.Println("已取消，未修改任何文件。")
+				return
+			}
+		}
+		if confirmOne {
+			var mu sync.Mutex
+			s.Confirm = func(p, dst string) bool {
+				// 多个 worker 同时到达时逐个提问
+				mu.Lock()
+				defer mu.Unlock()
+				if dst == p {
+					return askYes(fmt.Sprintf("覆盖 %s？[y/N] ", p))
+				}
+				return askYes(fmt.Sprintf("处理 %s，写出到 %s？[y/N] ", p, dst))
+			}
+		}
+	}
+
+	if stateFile != "" {
+		st, err := scrub.LoadState(stateFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		s.State = st
+	}
+
+	if auditPath != "" {
+		al, err := scrub.OpenAuditLog(auditPath)
+		if err != nil {
+			fatalf("打开审计日志失败: %v", err)
+		}
+		defer al.Close()
+		if err := al.Start(Version, setFlags()); err != nil {
+			fatalf("写入审计日志失败: %v", err)
+		}
+		s.Audit = al
+	}
+
+	if stream && dryRun && dryFormat == "list" {
+		// 逐个输出检查结果，不等全部检查完
+		var mu sync.Mutex
+		s.OnResult = func(r scrub.FileResult) {
+			mu.Lock()
+			printFinding(r)
+			mu.Unlock()
+		}
+	}
+
+	ctx := interruptContext()
+	var rep scrub.Report
+	walkFailed := false
+	switch {
+	case entries != nil:
+		rep = s.ScrubManifestContext(ctx, entries)
+	case stream:
+		var err error
+		if rep, err = s.ScrubDirContext(ctx, root); err != nil {
+			lg.Errorf("%v（已发现的文件照常处理）", err)
+			walkFailed = true
+		}
+		files = rep.Files
+		found = len(files)
+		if found == 0 && len(s.Unsupported()) == 0 && len(s.MacFiles()) == 0 && dryFormat != "json" {
+			fmt.Println("没有匹配到可处理的文件。")
+			if n := s.Skipped(); n > 0 {
+				fmt.Printf("另有 %d 个文件超过 --max-file-size 被跳过。\n", n)
+			}
+			if n := s.TooOld(); n > 0 {
+				fmt.Printf("另有 %d 个文件修改时间早于 --since 被跳过。\n", n)
+			}
+			if walkFailed {
+				os.Exit(exitFailed)
+			}
+			return
+		}
+		if dryFormat != "json" {
+			printPending(s, found, found)
+		}
+	default:
+		rep = s.ScrubFilesContext(ctx, root, files)
+	}
+	if s.Audit != nil {
+		if err := s.Audit.End(rep); err != nil {
+			lg.Errorf("写入审计日志失败: %v", err)
+		}
+	}
+
+	if reportPath != "" {
+		rep.Run = &run
+		if err := rep.WriteJSON(reportPath); err != nil {
+			lg.Errorf("写入报告失败: %v", err)
+		}
+	}
+	if dryRun {
+		switch dryFormat {
+		case "json":
+			printDryRunJSON(s, rep.Results)
+		case "tree":
+			printFindingsTree(root, rep.Results)
+		default:
+			if s.OnResult == nil {
+				printFindings(rep.Results)
+			}
+		}
+		if walkFailed {
+			os.Exit(exitFailed)
+		}
+		return
+	}
+
+	if s.State != nil {
+		if err := s.State.Save(); err != nil {
+			lg.Errorf("写入状态文件失败: %v", err)
+		}
+	}
+
+	summary := fmt.Sprintf("处理完成：成功 %d，失败 %d", rep.OK, rep.Failed)
+	if rep.Skipped > 0 {
+		summary += fmt.Sprintf("，跳过 %d（超过大小上限）", rep.Skipped)
+	}
+	if rep.TooOld > 0 {
+		summary += fmt.Sprintf("，跳过 %d（早于 --since）", rep.TooOld)
+	}
+	if rep.Unchanged > 0 {
+		summary += fmt.Sprintf("，未改动 %d（上次已处理，--force 可重新处理）", rep.Unchanged)
+	}
+	if rep.NoMetadata > 0 {
+		summary += fmt.Sprintf("，无元数据 %d（已跳过）", rep.NoMetadata)
+	}
+	if rep.Declined > 0 {
+		summary += fmt.Sprintf("，未确认 %d（已跳过）", rep.Declined)
+	}
+	if rep.Copied > 0 {
+		summary += fmt.Sprintf("，原样复制 %d（不支持的类型）", rep.Copied)
+	}
+	if len(files) < found {
+		summary += fmt.Sprintf("，受 --limit 限制未处理 %d", found-len(files))
+	}
+	if rep.MacRemoved > 0 {
+		summary += fmt.Sprintf("，删除 macOS 附带文件 %d", rep.MacRemoved)
+	}
+	fmt.Println(summary + "。")
+	if rep.OK > 0 {
+		before, after := rep.SizeChange()
+		fmt.Printf("总大小变化: %s（%s → %s）\n", formatSize(after-before, true), formatSize(before, false), formatSize(after, false))
+	}
+	printExtStats(rep.ByExt())
+	if hashList != "" {
+		if n, err := rep.WriteHashManifest(hashList); err != nil {
+			lg.Errorf("写入哈希清单失败: %v", err)
+		} else {
+			fmt.Printf("已将 %d 个输出文件的 SHA-256 写入 %s。\n", n, hashList)
+		}
+	}
+	if quarDir != "" {
+		n := 0
+		for _, r := range rep.Results {
+			if r.Quarantined != "" {
+				n++
+			}
+		}
+		if n > 0 {
+			fmt.Printf("已将 %d 个处理失败的文件隔离到 %s。\n", n, quarDir)
+		}
+	}
+	if rep.Canceled > 0 {
+		fmt.Printf("已中断：%d 个文件未处理。\n", rep.Canceled)
+	}
+
+	// 单独列出因缺少密码或密码不对而失败的 PDF，便于补充密码后重跑
+	var locked, wrong []string
+	for _, r := range rep.Results {
+		switch {
+		case errors.Is(r.Err, scrub.ErrPDFPasswordRequired):
+			locked = append(locked, r.Path)
+		case errors.Is(r.Err, scrub.ErrPDFWrongPassword):
+			wrong = append(wrong, r.Path)
+		}
+	}
+	if len(locked) > 0 {
+		fmt.Printf("以下 %d 个 PDF 已加密，需要 --pdf-password 或 --pdf-password-list：\n", len(locked))
+		for _, f := range locked {
+			fmt.Println("- ", f)
+		}
+	}
+	if len(wrong) > 0 {
+		fmt.Printf("以下 %d 个 PDF 无法用给定的任何密码打开：\n", len(wrong))
+		for _, f := range wrong {
+			fmt.Println("- ", f)
+		}
+	}
+	// 超时被放弃的文件可能仍在后台运行，退出前删除它们留下的临时文件
+	scrub.CleanupTemps()
+	if rep.Canceled > 0 {
+		os.Exit(exitInterrupted)
+	}
+	if (rep.Failed > 0 || walkFailed) && failOnErr {
+		os.Exit(exitFailed)
+	}
+}
+
+// interruptContext 在收到 Ctrl-C 或 SIGTERM 时取消：不再开始新文件，等待进行中的文件完成后输出已处理部分的汇总。
+// 再次中断时不再等待，清理临时文件后立即退出
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		lg.Warnf("收到中断信号，等待进行中的文件完成（再次中断立即退出）")
+		cancel()
+		<-sigs
+		n := scrub.CleanupTemps()
+		lg.Errorf("强制退出，已删除 %d 个临时文件", n)
+		os.Exit(exitInterrupted)
+	}()
+	return ctx
+}
+
+// setupLogger 按命令行参数创建日志
+func setupLogger() error {
+	level := scrub.LevelWarn
+	if verbose {
+		level = scrub.LevelDebug
+	}
+	if logLevel != "" {
+		l, err := scrub.ParseLevel(logLevel)
+		if err != nil {
+			return err
+		}
+		level = l
+	}
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("未知的日志格式: %s（可选 text/json）", logFormat)
+	}
+	lg = scrub.NewLogger(os.Stderr, level, logFormat == "json")
+	return nil
+}
+
+// fatalf 记录错误日志后退出
+func fatalf(format string, args ...any) {
+	lg.Errorf(format, args...)
+	os.Exit(exitFailed)
+}
+
+// usagef 记录参数错误后以 exitUsage 退出
+func usagef(format string, args ...any) {
+	lg.Errorf(format, args...)
+	os.Exit(exitUsage)
+}
+
+// setFlags 返回命令行中显式给出的选项及其取值，记入审计日志；密码只记录是否提供
+func setFlags() map[string]string {
+	opts := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		opts[f.Name] = f.Value.String()
+	})
+	if _, ok := opts["pdf-password"]; ok {
+		opts["pdf-password"] = "***"
+	}
+	return opts
+}
+
+// runRestore 执行 --restore：按 .bak 备份回滚
+func runRestore(s *scrub.Scrubber) {
+	rep, err := s.Restore(inputPath)
+	if err != nil {
+		fatalf("恢复失败: %v", err)
+	}
+	if len(rep.Files) == 0 {
+		fmt.Println("没有找到可恢复的备份。")
+		return
+	}
+	if dryRun {
+		fmt.Printf("发现 %d 个可恢复的文件。\n", len(rep.Files))
+		for _, f := range rep.Files {
+			fmt.Println("- ", f)
+		}
+		return
+	}
+	fmt.Printf("恢复完成：成功 %d，失败 %d。\n", rep.OK, rep.Failed)
+	if rep.Failed > 0 && failOnErr {
+		os.Exit(exitFailed)
+	}
+}
+
+// —— HTTP 服务（--serve）——
+// POST /scrub 接收 multipart 上传（字段 file），按命令行给出的选项处理后直接返回处理后的文件；
+// 文件名的扩展名决定处理方式（没有扩展名时按内容识别）。处理在私有临时目录中进行，不保留任何副本。
+// 请求体超过 --serve-max-size 时返回 413，不支持的类型返回 415，处理失败返回 422。
+
+// runServe 执行 --serve，收到中断信号后等待进行中的请求完成再退出
+func runServe(s *scrub.Scrubber) {
+	limit, err := parseSize(serveMax)
+	if err != nil {
+		usagef("%v", err)
+	}
+	if limit <= 0 || serveConc <= 0 {
+		usagef("--serve-max-size 与 --serve-concurrency 必须大于 0")
+	}
+
+	sem := make(chan struct{}, serveConc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scrub", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-r.Context().Done():
+			return
+		}
+		serveScrub(s, limit, w, r)
+	})
+	srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 30 * time.Second}
+
+	ctx := interruptContext()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	fmt.Printf("goscrub %s 正在监听 %s（POST /scrub）\n", Version, serveAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("启动 HTTP 服务失败: %v", err)
+	}
+	scrub.CleanupTemps()
+}
+
+// serveScrub 处理一次上传：读到 file 字段后流式交给 ScrubStreamContext，成功时以附件形式返回结果
+func serveScrub(s *scrub.Scrubber, limit int64, w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "需要 multipart/form-data 上传", http.StatusBadRequest)
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = mr.NextPart()
+		if err != nil {
+			serveError(w, r, "", fmt.Errorf("没有找到 file 字段: %w", err))
+			return
+		}
+		if part.FormName() == "file" {
+			break
+		}
+		part.Close()
+	}
+	defer part.Close()
+
+	name := filepath.Base(filepath.FromSlash(part.FileName()))
+	ext := strings.ToLower(filepath.Ext(name))
+	out := strings.TrimSuffix(name, filepath.Ext(name)) + scrub.OutputExt(ext)
+	ctype := mime.TypeByExtension(scrub.OutputExt(ext))
+	if ctype == "" || ext == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": out}))
+
+	// ScrubStreamContext 失败时不会写出内容，此时响应头仍可改写为错误
+	if err := s.ScrubStreamContext(r.Context(), part, ext, w); err != nil {
+		w.Header().Del("Content-Disposition")
+		serveError(w, r, name, err)
+		return
+	}
+	lg.Infof("%s: 已处理上传 %s", r.RemoteAddr, name)
+}
+
+// serveError 按错误类型返回对应的状态码
+func serveError(w http.ResponseWriter, r *http.Request, name string, err error) {
+	code := http.StatusUnprocessableEntity
+	var tooBig *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooBig), errors.Is(err, scrub.ErrTooLarge):
+		code = http.StatusRequestEntityTooLarge
+	case errors.Is(err, scrub.ErrUnsupportedType):
+		code = http.StatusUnsupportedMediaType
+	case r.Context().Err() != nil:
+		return // 客户端已断开
+	case name == "":
+		code = http.StatusBadRequest
+	}
+	lg.Warnf("%s: 上传 %s 处理失败（%d）: %v", r.RemoteAddr, name, code, err)
+	http.Error(w, err.Error(), code)
+}
+
+// printPending 输出发现的文件数；n 小于 found 时说明受 --limit 限制
+func printPending(s *scrub.Scrubber, found, n int) {
+	fmt.Printf("发现 %d 个待处理文件。\n", found)
+	if n < found {
+		how := "只处理前"
+		if sample {
+			how = "随机抽取"
+		}
+		fmt.Printf("已按 --limit %s %d 个，其余 %d 个本次不处理。\n", how, n, found-n)
+	}
+	if n := len(s.Unsupported()); n > 0 {
+		fmt.Printf("另有 %d 个不支持的文件将原样复制到输出目录。\n", n)
+	}
+	if n := len(s.MacFiles()); n > 0 {
+		if outputDir == "" && suffix == "" {
+			fmt.Printf("另有 %d 个 macOS 附带文件（._* / .DS_Store）将被删除。\n", n)
+		} else {
+			fmt.Printf("另有 %d 个 macOS 附带文件（._* / .DS_Store）不会写出到输出。\n", n)
+		}
+	}
+}
+
+// printFindings 以树形列出 dry-run 检查到的、将被删除的元数据
+func printFindings(results []scrub.FileResult) {
+	for _, r := range results {
+		printFinding(r)
+	}
+}
+
+// printFinding 列出单个文件的检查结果
+func printFinding(r scrub.FileResult) {
+	fmt.Println("- ", r.Path)
+	switch {
+	case r.Status == scrub.StatusUnchanged:
+		fmt.Println("    └ （上次已处理且未改动，将跳过）")
+	case r.Error != "":
+		fmt.Printf("    └ 检查失败: %s\n", r.Error)
+	case len(r.Findings) == 0:
+		fmt.Println("    └ （未发现元数据）")
+	}
+	for i, f := range r.Findings {
+		branch := "├"
+		if i == len(r.Findings)-1 {
+			branch = "└"
+		}
+		if f.Value != "" {
+			fmt.Printf("    %s %s: %s\n", branch, f.Item, f.Value)
+		} else {
+			fmt.Printf("    %s %s\n", branch, f.Item)
+		}
+	}
+}
+
+// dryRunEntry 为 --dry-run-format=json 输出中的一项
+type dryRunEntry struct {
+	Path     string          `json:"path"`
+	Type     string          `json:"type,omitempty"`
+	Action   string          `json:"action"` // overwrite/write/skip，不支持的文件为 copy，macOS 附带文件为 delete/omit
+	Output   string          `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Findings []scrub.Finding `json:"findings"`
+}
+
+// printDryRunJSON 以 JSON 数组输出每个文件的类型、计划操作与检查结果
+func printDryRunJSON(s *scrub.Scrubber, results []scrub.FileResult) {
+	entries := make([]dryRunEntry, 0, len(results))
+	for _, r := range results {
+		e := dryRunEntry{Path: r.Path, Type: r.Type, Action: r.Action, Output: r.Output, Error: r.Error, Findings: r.Findings}
+		if e.Action == "" {
+			e.Action = r.Status // 被中断时为 canceled
+		}
+		if e.Findings == nil {
+			e.Findings = []scrub.Finding{}
+		}
+		entries = append(entries, e)
+	}
+	for _, p := range s.Unsupported() {
+		entries = append(entries, dryRunEntry{Path: p, Action: "copy", Findings: []scrub.Finding{}})
+	}
+	mac := "omit"
+	if outputDir == "" && suffix == "" {
+		mac = "delete"
+	}
+	for _, p := range s.MacFiles() {
+		entries = append(entries, dryRunEntry{Path: p, Action: mac, Findings: []scrub.Finding{}})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entries); err != nil {
+		lg.Errorf("输出 JSON 失败: %v", err)
+	}
+}
+
+// treeNode 为 --dry-run-format=tree 中的一个目录或文件
+type treeNode struct {
+	children map[string]*treeNode
+	result   *scrub.FileResult
+}
+
+// printFindingsTree 按目录层级列出 dry-run 的检查结果；root 为空（单个文件、清单）时以各文件的公共目录为根
+func printFindingsTree(root string, results []scrub.FileResult) {
+	if len(results) == 0 {
+		return
+	}
+	if root == "" {
+		root = filepath.Dir(results[0].Path)
+		for _, r := range results[1:] {
+			for !isUnder(r.Path, root) && filepath.Dir(root) != root {
+				root = filepath.Dir(root)
+			}
+		}
+	}
+	top := &treeNode{children: map[string]*treeNode{}}
+	for i := range results {
+		rel := results[i].Path
+		if isUnder(rel, root) {
+			rel, _ = filepath.Rel(root, rel)
+		}
+		n := top
+		for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+			if part == "" {
+				continue
+			}
+			c, ok := n.children[part]
+			if !ok {
+				c = &treeNode{children: map[string]*treeNode{}}
+				n.children[part] = c
+			}
+			n = c
+		}
+		n.result = &results[i]
+	}
+	fmt.Println(root)
+	printTreeNode(top, "")
+}
+
+func printTreeNode(n *treeNode, indent string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		c := n.children[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+		r := c.result
+		switch {
+		case r == nil:
+			fmt.Println(indent + branch + name + "/")
+			printTreeNode(c, indent+next)
+			continue
+		case r.Status == scrub.StatusUnchanged:
+			name += "（上次已处理且未改动，将跳过）"
+		case r.Error != "":
+			name += "（检查失败: " + r.Error + "）"
+		case len(r.Findings) == 0:
+			name += "（未发现元数据）"
+		case r.Action == scrub.ActionWrite:
+			name += " → " + r.Output
+		}
+		fmt.Println(indent + branch + name)
+		for j, f := range r.Findings {
+			leaf := "├ "
+			if j == len(r.Findings)-1 {
+				leaf = "└ "
+			}
+			if f.Value != "" {
+				fmt.Printf("%s%s%s: %s\n", indent+next, leaf, f.Item, f.Value)
+			} else {
+				fmt.Printf("%s%s%s\n", indent+next, leaf, f.Item)
+			}
+		}
+	}
+}
+
+// isUnder 判断 p 是否位于目录 dir 之下
+func isUnder(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// printExtStats 按扩展名输出成功/失败数量
+func printExtStats(stats []scrub.ExtStat) {
+	if len(stats) < 2 {
+		return // 只有一种类型时与总计相同
+	}
+	// 中文标题每字占两列，宽度相应减半以与下方数字对齐
+	fmt.Printf("%-8s %6s %6s\n", "类型", "成功", "失败")
+	for _, st := range stats {
+		ext := st.Ext
+		if ext == "" {
+			ext = "(无)"
+		}
+		fmt.Printf("%-10s %8d %8d\n", ext, st.OK, st.Failed)
+	}
+}
+
+// printBanner 在 stderr 输出版本、主要选项、可选格式的构建与开启情况以及本次处理的扩展名，
+// 便于附在问题报告中复现；写到 stderr，不影响 --dry-run-format json 的标准输出
+func printBanner(run scrub.RunInfo) {
+	w := os.Stderr
+	fmt.Fprintf(w, "goscrub %s（%s %s）\n", run.Version, run.GoVersion, run.Platform)
+	keys := make([]string, 0, len(run.Options))
+	for k := range run.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	opts := make([]string, len(keys))
+	for i, k := range keys {
+		opts[i] = k + "=" + run.Options[k]
+	}
+	fmt.Fprintf(w, "选项: %s\n", strings.Join(opts, " "))
+	var optional []string
+	for _, o := range run.Optional {
+		state := "未开启"
+		if o.Enabled {
+			state = "已开启"
+		}
+		if o.BuildTag != "" {
+			built := "未编译"
+			if o.Built {
+				built = "已编译"
+			}
+			state += fmt.Sprintf("，%s %s", o.BuildTag, built)
+		}
+		optional = append(optional, fmt.Sprintf("%s（%s，%s）", o.Kind, o.Flag, state))
+	}
+	fmt.Fprintf(w, "可选格式: %s\n", strings.Join(optional, "；"))
+	fmt.Fprintf(w, "处理的扩展名: %s\n", strings.Join(run.Exts, " "))
+}
+
+// printHandlers 按扩展名列出注册表中的格式：处理方式、删除的元数据、需要的选项与构建标签
+func printHandlers(entries []scrub.HandlerEntry) {
+	for _, e := range entries {
+		fmt.Printf("%-8s %-8s %s\n", e.Ext, e.Kind, e.Info.Method)
+		if e.Info.Removes != "" {
+			fmt.Printf("%18s删除: %s\n", "", e.Info.Removes)
+		}
+		var req []string
+		if e.Info.Flag != "" {
+			req = append(req, e.Info.Flag)
+		}
+		if e.Info.BuildTag != "" {
+			built := "当前构建未包含"
+			if e.Info.Built {
+				built = "当前构建已包含"
+			}
+			req = append(req, fmt.Sprintf("-tags %s（%s）", e.Info.BuildTag, built))
+		}
+		if len(req) > 0 {
+			fmt.Printf("%18s需要: %s\n", "", strings.Join(req, "，"))
+		}
+	}
+}
+
+// pickIndexes 返回从 n 个文件中选出的 k 个下标（升序）：sample 时随机抽取，否则为前 k 个
+func pickIndexes(n, k int, sample bool) []int {
+	if !sample {
+		idx := make([]int, k)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	idx := rand.Perm(n)[:k]
+	sort.Ints(idx) // 保持收集时的顺序
+	return idx
+}
+
+// pick 按下标取出 items 中的元素
+func pick[T any](items []T, idx []int) []T {
+	out := make([]T, len(idx))
+	for i, j := range idx {
+		out[i] = items[j]
+	}
+	return out
+}
+
+// readPasswordList 读取候选密码文件：每行一个密码，只去掉行尾的换行符（密码可能含空格），跳过空行
+func readPasswordList(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var pws []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			pws = append(pws, line)
+		}
+	}
+	if len(pws) == 0 {
+		return nil, errors.New("文件中没有密码")
+	}
+	return pws, nil
+}
+
+// maxListPending 为 --confirm 时列出的文件数上限
+const maxListPending = 50
+
+// listPending 列出将要处理的文件，inPlace 时提示原文件会被覆盖
+func listPending(files []string, inPlace bool) {
+	if inPlace {
+		fmt.Println("以下文件将被原地覆盖：")
+	} else {
+		fmt.Println("将处理以下文件：")
+	}
+	for i, f := range files {
+		if i == maxListPending {
+			fmt.Printf("  ……另有 %d 个\n", len(files)-i)
+			break
+		}
+		fmt.Println("  " + f)
+	}
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// askYes 输出提示并读取一行回答，只有 y/yes（不区分大小写）视为同意，读取失败视为拒绝
+func askYes(prompt string) bool {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// isTerminal 判断 f 是否为终端（重定向到文件或管道时不输出进度行）
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseSize 解析带 KB/MB/GB 单位（1024 进制，大小写不敏感，B 可省略）的大小，空串为 0
+func parseSize(raw string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(raw))
+	if v == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}} {
+		if t := strings.TrimSuffix(v, "B"); strings.HasSuffix(t, u.suffix) {
+			v, mult = strings.TrimSuffix(t, u.suffix), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(v, "B")), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无法解析的大小: %q（示例: 100MB）", raw)
+	}
+	return n * mult, nil
+}
+
+// parseSince 解析 --since：RFC3339 时间、本地时区的日期，或相对 now 的时长（支持 d 表示天），空串为零值
+func parseSince(raw string, now time.Time) (time.Time, error) {
+	v := strings.TrimSpace(raw)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v, time.Local); err == nil {
+		return t, nil
+	}
+	if n, ok := strings.CutSuffix(v, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	} else if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无法解析的 --since: %q（示例: 2024-05-01T00:00:00+08:00、2024-05-01、24h、7d）", raw)
+}
+
+// formatSize 以 1024 进制的 B/KB/MB/GB 显示字节数，signed 时正数带 + 号
+func formatSize(n int64, signed bool) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	} else if signed && n > 0 {
+		sign = "+"
+	}
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%s%.1f GB", sign, float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%s%.1f MB", sign, float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%s%.1f KB", sign, float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%s%d B", sign, n)
+}
+
+// splitList 拆分逗号分隔的命令行列表
+func splitList(csv string) []string {
+	if strings.TrimSpace(csv) == "" {
+		return nil
+	}
+	return strings.Split(csv, ",")
+}
@@ -0,0 +1,358 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// —— MP3/FLAC：删除标签，不触及音频帧 ——
+// MP3 的 ID3v2 位于文件开头（"ID3" + 10 字节头声明的长度），ID3v1 是末尾固定 128 字节的 "TAG" 块，
+// 两者之间的音频帧原样流式复制。FLAC 在 "fLaC" 之后是一串元数据块，删除 VORBIS_COMMENT（艺术家、
+// 编码软件、注释）与 PICTURE（封面），保留 STREAMINFO、SEEKTABLE 等播放所需的块；
+// SEEKTABLE 中的偏移相对第一个音频帧，不受元数据长度变化影响。
+
+var audioSet = map[string]bool{
+	".mp3": true, ".flac": true,
+}
+
+const (
+	id3v1Size = 128
+
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+var flacMagic = []byte("fLaC")
+
+func init() {
+	registerSet(audioSet, handlerFuncs{
+		kind:    "audio",
+		scrub:   (*Scrubber).scrubAudio,
+		inspect: func(_ *Scrubber, p, ext string) ([]Finding, error) { return inspectAudio(p, ext) },
+		verify:  func(_ *Scrubber, out, ext string) error { return verifyAudio(out, ext) },
+		describe: func(ext string) HandlerInfo {
+			if ext == ".flac" {
+				return HandlerInfo{Method: "删除元数据块，音频不变", Removes: "Vorbis 注释与封面图片"}
+			}
+			return HandlerInfo{Method: "删除标签，音频不变", Removes: "ID3v2、ID3v1 标签"}
+		},
+	})
+}
+
+// scrubAudio 按扩展名处理 MP3 与 FLAC
+func (s *Scrubber) scrubAudio(path, dst, ext string) error {
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		info, err := in.Stat()
+		if err != nil {
+			return err
+		}
+		if ext == ".flac" {
+			return writeFLAC(in, info.Size(), w)
+		}
+		return writeMP3(in, info.Size(), w)
+	})
+}
+
+// id3v2Size 返回 off 处 ID3v2 标签的总长度（含头与可选的尾部），不是 ID3v2 时返回 0
+func id3v2Size(r io.ReaderAt, off int64) int64 {
+	var h [10]byte
+	if _, err := r.ReadAt(h[:], off); err != nil || string(h[:3]) != "ID3" {
+		return 0
+	}
+	n := syncsafe(h[6:10])
+	if n < 0 {
+		return 0
+	}
+	n += 10
+	if h[5]&0x10 != 0 {
+		n += 10 // 带尾部（footer）
+	}
+	return n
+}
+
+// syncsafe 解码 ID3v2 的 28 位同步安全整数，某字节最高位为 1 时返回 -1
+func syncsafe(b []byte) int64 {
+	var n int64
+	for _, c := range b {
+		if c&0x80 != 0 {
+			return -1
+		}
+		n = n<<7 | int64(c)
+	}
+	return n
+}
+
+// mp3Audio 返回去掉首部 ID3v2（可能有多个相连）与末尾 ID3v1 后的音频数据范围
+func mp3Audio(r io.ReaderAt, size int64) (start, end int64) {
+	for start < size {
+		n := id3v2Size(r, start)
+		if n == 0 {
+			break
+		}
+		start += n
+	}
+	end = size
+	if end-start >= id3v1Size {
+		tag := make([]byte, 3)
+		if _, err := r.ReadAt(tag, end-id3v1Size); err == nil && string(tag) == "TAG" {
+			end -= id3v1Size
+		}
+	}
+	return min(start, size), end
+}
+
+func writeMP3(in io.ReaderAt, size int64, w io.Writer) error {
+	start, end := mp3Audio(in, size)
+	if start >= end {
+		return errors.New("去除标签后没有音频数据")
+	}
+	_, err := io.Copy(w, io.NewSectionReader(in, start, end-start))
+	return err
+}
+
+// flacBlock 是一个 FLAC 元数据块（off 为块头所在的文件偏移）
+type flacBlock struct {
+	typ  byte
+	off  int64
+	size int64 // 不含 4 字节块头
+}
+
+// flacBlocks 解析 FLAC 元数据块，返回块列表与第一个音频帧的偏移；开头的 ID3v2 会被跳过
+func flacBlocks(r io.ReaderAt, size int64) ([]flacBlock, int64, error) {
+	off := id3v2Size(r, 0)
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, off); err != nil || !bytes.Equal(magic, flacMagic) {
+		return nil, 0, errors.New("不是合法的 FLAC 文件")
+	}
+	off += 4
+	var blocks []flacBlock
+	for {
+		var h [4]byte
+		if _, err := r.ReadAt(h[:], off); err != nil {
+			return nil, 0, fmt.Errorf("读取元数据块失败: %w", err)
+		}
+		b := flacBlock{typ: h[0] & 0x7F, off: off, size: int64(h[1])<<16 | int64(h[2])<<8 | int64(h[3])}
+		if off+4+b.size > size {
+			return nil, 0, errors.New("元数据块长度非法")
+		}
+		blocks = append(blocks, b)
+		off += 4 + b.size
+		if h[0]&0x80 != 0 {
+			break // 最后一个元数据块
+		}
+	}
+	if len(blocks) == 0 || blocks[0].typ != flacStreamInfo {
+		return nil, 0, errors.New("缺少 STREAMINFO 块")
+	}
+	return blocks, off, nil
+}
+
+func flacDrop(typ byte) bool {
+	return typ == flacVorbisComment || typ == flacPicture
+}
+
+func writeFLAC(in io.ReaderAt, size int64, w io.Writer) error {
+	blocks, audio, err := flacBlocks(in, size)
+	if err != nil {
+		return err
+	}
+	var kept []flacBlock
+	for _, b := range blocks {
+		if !flacDrop(b.typ) {
+			kept = append(kept, b)
+		}
+	}
+	if _, err := w.Write(flacMagic); err != nil {
+		return err
+	}
+	for i, b := range kept {
+		h := make([]byte, 4)
+		if _, err := in.ReadAt(h, b.off); err != nil {
+			return err
+		}
+		// 删除块后重新标记最后一个元数据块
+		h[0] = b.typ
+		if i == len(kept)-1 {
+			h[0] |= 0x80
+		}
+		if _, err := w.Write(h); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(in, b.off+4, b.size)); err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(w, io.NewSectionReader(in, audio, size-audio))
+	return err
+}
+
+// —— dry-run 与校验 ——
+
+func inspectAudio(path, ext string) ([]Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".flac" {
+		return inspectFLAC(f, info.Size())
+	}
+
+	var fs []Finding
+	start, end := mp3Audio(f, info.Size())
+	for off := int64(0); off < start; {
+		n := id3v2Size(f, off)
+		tag := make([]byte, n)
+		if _, err := f.ReadAt(tag, off); err != nil {
+			return nil, err
+		}
+		fs = append(fs, id3Frames(tag)...)
+		off += n
+	}
+	if end < info.Size() {
+		tag := make([]byte, id3v1Size)
+		if _, err := f.ReadAt(tag, end); err != nil {
+			return nil, err
+		}
+		// ID3v1：标题、艺术家、专辑各 30 字节
+		fs = append(fs, Finding{Item: "ID3v1", Value: strings.Join(strings.Fields(strings.ReplaceAll(string(tag[3:93]), "\x00", " ")), " ")})
+	}
+	return fs, nil
+}
+
+// id3Frames 列出 ID3v2.3/2.4 标签中的帧，文本帧附带取值（仅 ISO-8859-1 与 UTF-8 编码）
+func id3Frames(tag []byte) []Finding {
+	ver := tag[3]
+	if ver < 3 || tag[5]&0x40 != 0 {
+		// v2.2 的帧头格式不同，带扩展头的标签也不再细分
+		return []Finding{{Item: fmt.Sprintf("ID3v2.%d", ver)}}
+	}
+	var fs []Finding
+	body := tag[10:min(len(tag), 10+int(syncsafe(tag[6:10])))]
+	for p := 0; p+10 <= len(body) && body[p] != 0; {
+		id := string(body[p : p+4])
+		var n int
+		if ver == 4 {
+			n = int(syncsafe(body[p+4 : p+8]))
+		} else {
+			n = int(binary.BigEndian.Uint32(body[p+4 : p+8]))
+		}
+		if n < 0 || p+10+n > len(body) {
+			break
+		}
+		data := body[p+10 : p+10+n]
+		fd := Finding{Item: "ID3v2 " + id}
+		if id[0] == 'T' && len(data) > 1 && (data[0] == 0 || data[0] == 3) {
+			fd.Value = strings.TrimRight(string(data[1:]), "\x00")
+		}
+		fs = append(fs, fd)
+		p += 10 + n
+	}
+	return fs
+}
+
+// inspectFLAC 列出 Vorbis 注释（KEY=value）与封面图片
+func inspectFLAC(r io.ReaderAt, size int64) ([]Finding, error) {
+	blocks, _, err := flacBlocks(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for _, b := range blocks {
+		switch b.typ {
+		case flacPicture:
+			fs = append(fs, Finding{Item: "FLAC PICTURE（封面图片）"})
+		case flacVorbisComment:
+			data := make([]byte, b.size)
+			if _, err := r.ReadAt(data, b.off+4); err != nil {
+				return nil, err
+			}
+			for _, c := range vorbisComments(data) {
+				k, v, _ := strings.Cut(c, "=")
+				fs = append(fs, Finding{Item: "Vorbis " + strings.ToUpper(k), Value: v})
+			}
+		}
+	}
+	return fs, nil
+}
+
+// vorbisComments 解析 VORBIS_COMMENT 块：厂商字符串（编码器名称）与若干 KEY=value，均为小端长度前缀
+func vorbisComments(p []byte) []string {
+	next := func() (string, bool) {
+		if len(p) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(p))
+		if n > len(p)-4 {
+			return "", false
+		}
+		v := string(p[4 : 4+n])
+		p = p[4+n:]
+		return v, true
+	}
+	vendor, ok := next()
+	if !ok {
+		return nil
+	}
+	res := []string{"VENDOR=" + vendor}
+	if len(p) < 4 {
+		return res
+	}
+	count := int(binary.LittleEndian.Uint32(p))
+	p = p[4:]
+	for i := 0; i < count; i++ {
+		c, ok := next()
+		if !ok {
+			break
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// verifyAudio 检查 MP3 首尾没有 ID3 标签、FLAC 中没有注释与图片块
+func verifyAudio(path, ext string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if ext == ".flac" {
+		if id3v2Size(f, 0) > 0 {
+			return errors.New("仍包含 ID3v2 标签")
+		}
+		blocks, _, err := flacBlocks(f, info.Size())
+		if err != nil {
+			return err
+		}
+		for _, b := range blocks {
+			if flacDrop(b.typ) {
+				return fmt.Errorf("仍包含类型为 %d 的元数据块", b.typ)
+			}
+		}
+		return nil
+	}
+	if start, end := mp3Audio(f, info.Size()); start > 0 || end < info.Size() {
+		return errors.New("仍包含 ID3 标签")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// id3v23 写出 ID3v2.3 标签；frames 为帧 ID 与内容交替排列
+func id3v23(frames ...string) []byte {
+	var body []byte
+	for i := 0; i+1 < len(frames); i += 2 {
+		body = append(body, frames[i]...)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(frames[i+1])))
+		body = append(append(body, 0, 0), frames[i+1]...)
+	}
+	n := len(body)
+	h := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	return append(h, body...)
+}
+
+func TestMP3CommentRemovedAudioKept(t *testing.T) {
+	audio := bytes.Repeat([]byte("\xFF\xFB\x90\x00frame-data"), 4)
+	v1 := make([]byte, id3v1Size)
+	copy(v1, "TAGSong Title")
+	copy(v1[33:], "Alice Secret")
+	in := append(append(id3v23(
+		"TPE1", "\x00Alice Secret",
+		"COMM", "\x00eng\x00Recorded at home by Alice",
+	), audio...), v1...)
+
+	p := writeTestFile(t, t.TempDir(), "a.mp3", in)
+	s := newTestScrubber()
+	fs, err := s.Inspect(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := map[string]string{}
+	for _, f := range fs {
+		items[f.Item] = f.Value
+	}
+	if _, ok := items["ID3v2 COMM"]; !ok || items["ID3v2 TPE1"] != "Alice Secret" || items["ID3v1"] == "" {
+		t.Errorf("dry-run 应列出注释、艺术家与 ID3v1，实际: %v", fs)
+	}
+
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, audio) {
+		t.Errorf("输出应只剩音频帧（%d 字节），实际 %d 字节", len(audio), len(out))
+	}
+}
@@ -0,0 +1,95 @@
+package scrub
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// —— 审计日志（Audit）——
+// 合规场景需要跨多次运行的持久记录，而不只是每次运行的 stdout 与 JSON 报告。
+// 审计日志以追加方式打开，每行一个 JSON 对象（JSON Lines）：每次运行开始时写一条 start（版本与选项），
+// 每个文件处理后写一条 file，结束时写一条 end（汇总计数）。
+// 每条记录以一次 Write 直接写入文件、不经缓冲，进程中途退出时已处理的文件也已记下；多个 worker 并发写入时加锁串行。
+
+// AuditLog 是追加写入的审计日志，可被多个 worker 并发使用
+type AuditLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// auditEntry 是审计日志中的一行；Event 为 start、file 或 end
+type auditEntry struct {
+	Time  string `json:"time"`
+	Event string `json:"event"`
+
+	Version string            `json:"version,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+
+	Path        string `json:"path,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Error       string `json:"error,omitempty"`
+	BytesBefore int64  `json:"bytes_before,omitempty"`
+	BytesAfter  int64  `json:"bytes_after,omitempty"`
+	Output      string `json:"output,omitempty"`
+	BackupPath  string `json:"backup_path,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+
+	OK       int64 `json:"ok,omitempty"`
+	Failed   int64 `json:"failed,omitempty"`
+	Canceled int64 `json:"canceled,omitempty"`
+}
+
+// OpenAuditLog 以追加方式打开（不存在时创建）审计日志
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{f: f}, nil
+}
+
+// Start 记录一次运行的开始：程序版本与本次使用的选项
+func (a *AuditLog) Start(version string, options map[string]string) error {
+	return a.write(auditEntry{Event: "start", Version: version, Options: options})
+}
+
+// End 记录一次运行的汇总计数
+func (a *AuditLog) End(rep Report) error {
+	return a.write(auditEntry{Event: "end", OK: rep.OK, Failed: rep.Failed, Canceled: rep.Canceled})
+}
+
+// Close 关闭审计日志
+func (a *AuditLog) Close() error {
+	return a.f.Close()
+}
+
+// record 记录单个文件的处理结果
+func (a *AuditLog) record(r FileResult) error {
+	return a.write(auditEntry{
+		Event:       "file",
+		Path:        r.Path,
+		Type:        r.Type,
+		Status:      r.Status,
+		Error:       r.Error,
+		BytesBefore: r.BytesBefore,
+		BytesAfter:  r.BytesAfter,
+		Output:      r.Output,
+		BackupPath:  r.BackupPath,
+		SHA256:      r.SHA256,
+	})
+}
+
+func (a *AuditLog) write(e auditEntry) error {
+	e.Time = time.Now().Format(time.RFC3339)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,88 @@
+package scrub
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// —— BMP：解码后重新编码为基本 BMP ——
+// BITMAPV4HEADER/V5HEADER 在基本的 40 字节信息头之后带有色彩空间、伽马，以及 ICC 配置
+// （内嵌数据或指向外部配置文件的路径字符串，后者可能暴露用户名与目录结构）。
+// x/image/bmp 的编码器只写出 40 字节的 BITMAPINFOHEADER，重新编码即丢弃上述全部扩展字段，
+// 因此与 TIFF 一样整体重新编码，不做逐字段删除。像素（含 32 位 alpha）不变。
+
+const (
+	bmpFileHeaderLen = 14
+	bmpInfoHeaderLen = 40
+	bmpV4HeaderLen   = 108
+	bmpV5HeaderLen   = 124
+)
+
+// bmpHeaderLens 为解码器支持的信息头长度，识别类型时用来核对 "BM" 魔数
+var bmpHeaderLens = map[uint32]bool{
+	bmpInfoHeaderLen: true, bmpV4HeaderLen: true, bmpV5HeaderLen: true,
+}
+
+// V5 信息头中色彩空间类型的取值
+const (
+	bmpProfileLinked   = 0x4C494E4B // 'LINK'
+	bmpProfileEmbedded = 0x4D424544 // 'MBED'
+)
+
+// bmpInfoLen 返回信息头长度
+func bmpInfoLen(b []byte) (int, error) {
+	if len(b) < bmpFileHeaderLen+4 || string(b[:2]) != "BM" {
+		return 0, errors.New("不是合法的 BMP 文件")
+	}
+	return int(binary.LittleEndian.Uint32(b[bmpFileHeaderLen:])), nil
+}
+
+// inspectBMP 列出 V4/V5 信息头中的色彩空间与 ICC 配置
+func inspectBMP(b []byte) ([]Finding, error) {
+	n, err := bmpInfoLen(b)
+	if err != nil {
+		return nil, err
+	}
+	if n < bmpV4HeaderLen || len(b) < bmpFileHeaderLen+n {
+		return nil, nil
+	}
+	h := b[bmpFileHeaderLen : bmpFileHeaderLen+n]
+	if n < bmpV5HeaderLen {
+		return []Finding{{Item: "BMP V4 信息头（色彩空间与伽马）"}}, nil
+	}
+	fs := []Finding{{Item: "BMP V5 信息头（色彩空间与伽马）"}}
+	off := int(binary.LittleEndian.Uint32(h[112:]))
+	size := int(binary.LittleEndian.Uint32(h[116:]))
+	switch binary.LittleEndian.Uint32(h[56:]) {
+	case bmpProfileEmbedded:
+		fs = append(fs, Finding{Item: "ICC 色彩配置", Value: fmt.Sprintf("%d 字节", size)})
+	case bmpProfileLinked:
+		f := Finding{Item: "外部 ICC 配置路径"}
+		// 配置数据的偏移相对信息头起点，路径以 NUL 结尾
+		if start := bmpFileHeaderLen + off; off > 0 && size > 0 && start+size <= len(b) {
+			p := b[start : start+size]
+			for i, c := range p {
+				if c == 0 {
+					p = p[:i]
+					break
+				}
+			}
+			f.Value = string(p)
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// verifyBMP 检查输出只有基本的 40 字节信息头
+func verifyBMP(b []byte) error {
+	n, err := bmpInfoLen(b)
+	if err != nil {
+		return err
+	}
+	if n != bmpInfoHeaderLen {
+		return fmt.Errorf("信息头长度为 %d，仍包含扩展字段", n)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"image"
+	"io"
+	"os"
+	"strings"
+
+	_ "golang.org/x/image/webp" // 仅用于 --checksum-content 解码 WebP 像素
+)
+
+// —— --checksum-content：确认只删了元数据、可见内容没有变化 ——
+// 处理前记录一份内容指纹，处理后从输出文件重新计算并比较，用来发现编码器或 zip 重写的缺陷：
+//   - 图片：比较解码后的像素。JPEG 重新编码本身有损，因此按逐通道平均差判断，超过 maxPixelDiff 才告警；
+//   - Office/OpenDocument：对正文部件（word/document.xml、工作表、幻灯片、content.xml 等）中的文本计算哈希。
+//     只计入字符数据，不计入属性，因此匿名化修订作者等预期内的改写不会误报。
+//
+// 不一致只记录 warn 日志，不把文件记为失败。
+
+// maxPixelDiff 为允许的逐通道平均差（0–255）；质量 95 的重新编码通常在 1–2 之间
+const maxPixelDiff = 8.0
+
+// maxPixelSamples 限制参与比较的像素数，巨幅图片按步长抽样
+const maxPixelSamples = 1 << 20
+
+// contentPrint 是处理前记录的内容指纹
+type contentPrint struct {
+	img   image.Image                  // 图片：解码后的像素
+	parts map[string][sha256.Size]byte // 归档：正文部件名 -> 文本哈希
+}
+
+// contentPrint 计算 path 的内容指纹；该类型不做内容比较时返回 nil, nil
+func (s *Scrubber) contentPrint(path, ext string) (*contentPrint, error) {
+	switch kindOf(ext) {
+	case "image", "heic":
+		img, err := decodeImageFile(path, ext)
+		if err != nil {
+			return nil, err
+		}
+		if s.bakesOrientation(ext) {
+			// 输出已按 EXIF 方向旋转，与按同样方向显示的原图比较
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			img = orient(img, imageOrientation(data, ext))
+		}
+		return &contentPrint{img: img}, nil
+	case "openxml", "opendoc":
+		parts, err := zipTextHashes(path)
+		if err != nil {
+			return nil, err
+		}
+		return &contentPrint{parts: parts}, nil
+	}
+	return nil, nil
+}
+
+// compare 从输出文件 out 重新计算指纹，与处理前不一致时返回说明差异的错误
+func (c *contentPrint) compare(out string) error {
+	if c.img != nil {
+		// HEIC 的输出为 JPEG，按输出文件实际格式解码
+		img, err := decodeImageFile(out, "")
+		if err != nil {
+			return err
+		}
+		return comparePixels(c.img, img)
+	}
+	parts, err := zipTextHashes(out)
+	if err != nil {
+		return err
+	}
+	for name, sum := range c.parts {
+		got, ok := parts[name]
+		if !ok {
+			return fmt.Errorf("正文部件 %s 缺失", name)
+		}
+		if got != sum {
+			return fmt.Errorf("正文部件 %s 的文本发生变化", name)
+		}
+	}
+	return nil
+}
+
+// decodeImageFile 解码图片的第一帧；ext 为 HEIC 时使用 HEIC 解码器，其余按文件头识别
+func decodeImageFile(path, ext string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if heicSet[ext] {
+		return decodeHEIC(bufio.NewReader(f))
+	}
+	img, _, err := image.Decode(bufio.NewReader(f))
+	return img, err
+}
+
+// comparePixels 比较两幅图的尺寸与逐通道平均差
+func comparePixels(a, b image.Image) error {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return fmt.Errorf("尺寸由 %dx%d 变为 %dx%d", ab.Dx(), ab.Dy(), bb.Dx(), bb.Dy())
+	}
+	step := 1
+	for ab.Dx()*ab.Dy()/(step*step) > maxPixelSamples {
+		step *= 2
+	}
+	var sum float64
+	var n int
+	for y := 0; y < ab.Dy(); y += step {
+		for x := 0; x < ab.Dx(); x += step {
+			r1, g1, b1, a1 := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			sum += absDiff(r1, r2) + absDiff(g1, g2) + absDiff(b1, b2) + absDiff(a1, a2)
+			n += 4
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	// RGBA() 返回 16 位分量，换算回 0–255
+	if diff := sum / float64(n) / 257; diff > maxPixelDiff {
+		return fmt.Errorf("像素平均差 %.1f 超过阈值 %.0f", diff, maxPixelDiff)
+	}
+	return nil
+}
+
+func absDiff(a, b uint32) float64 {
+	if a > b {
+		return float64(a - b)
+	}
+	return float64(b - a)
+}
+
+// isContentPart 判断归档条目是否为承载正文的部件（name 为小写）
+func isContentPart(lower string) bool {
+	switch {
+	case lower == "word/document.xml", lower == "xl/sharedstrings.xml", lower == "content.xml":
+		return true
+	case strings.HasPrefix(lower, "xl/worksheets/sheet"), strings.HasPrefix(lower, "ppt/slides/slide"):
+		return strings.HasSuffix(lower, ".xml")
+	}
+	return false
+}
+
+// zipTextHashes 对归档中每个正文部件的文本内容计算哈希
+func zipTextHashes(path string) (map[string][sha256.Size]byte, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	res := map[string][sha256.Size]byte{}
+	for _, zf := range zr.File {
+		if !isContentPart(strings.ToLower(zf.Name)) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		err = hashXMLText(r, h)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], h.Sum(nil))
+		res[zf.Name] = sum
+	}
+	return res, nil
+}
+
+// hashXMLText 将 XML 中的字符数据依次写入 h
+func hashXMLText(r io.Reader, h hash.Hash) error {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			h.Write(cd)
+		}
+	}
+}
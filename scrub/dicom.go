@@ -0,0 +1,643 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// —— DICOM：按 PS3.15 附录 E 基本去标识化配置删除或清空 PHI 数据元素 ——
+// 医学影像的数据集中直接保存患者姓名、ID、出生日期、机构与医生姓名、检查日期等受保护的健康信息。
+// 这里不依赖第三方库：按传输语法逐个解析数据元素（含嵌套序列），对配置表中的标签执行
+// X（删除）或 Z（保留元素、清空取值，供 Type 2 必需元素使用），删除全部私有标签与过时的组长度元素，
+// 再按原传输语法重新写出；像素数据（含封装的压缩帧）逐字节保留。
+// 重复组中的叠加数据与叠加注释（60xx,3000/4000）、整个曲线组（50xx）以及图形注释序列（0070,0001）
+// 可能带有烧录式或自由文本的 PHI，一并删除（见 dcmPHIAction）。
+// 文件元信息（0002 组）中的应用实体名称与私有信息同样删除，组长度按删除后的内容重新计算。
+// 处理后补上 PatientIdentityRemoved=YES 与 DeidentificationMethod，便于下游系统识别。
+//
+// 限制：
+//   - 配置表覆盖基本配置中常见的标签而非全部，未列出的公有标签（如检查所用的设备型号）原样保留；
+//   - 各类 UID（Study/Series/SOP Instance UID）保留，以免破坏影像之间的关联；需要重映射时请使用专门的工具；
+//   - 像素中烧录的文字（超声、截图等）无法去除；
+//   - 128 字节前导区可能含应用数据，一律清零（DICOM/TIFF 双格式文件因此不再能按 TIFF 打开）；
+//   - 不支持 Deflate 压缩的传输语法。
+
+var dicomSet = map[string]bool{
+	".dcm": true, ".dicom": true,
+}
+
+func init() {
+	registerSet(dicomSet, handlerFuncs{
+		kind:    "dicom",
+		scrub:   (*Scrubber).scrubDICOM,
+		inspect: (*Scrubber).inspectDICOM,
+		verify:  (*Scrubber).verifyDICOM,
+		info: HandlerInfo{
+			Method:  "按 PS3.15 基本去标识化配置编辑数据集",
+			Removes: "患者姓名、ID、出生日期、机构、医生与检查日期等标签，以及私有标签",
+		},
+	})
+}
+
+// dcmAction 是配置表中标签的处理方式
+type dcmAction struct {
+	name  string
+	empty bool // true 为 Z（清空取值），false 为 X（删除）
+}
+
+func dcmTag(group, elem uint16) uint32 { return uint32(group)<<16 | uint32(elem) }
+
+// dcmPHI 为基本去标识化配置中常见的 PHI 标签（未列出的 UID 类标签保留）
+var dcmPHI = map[uint32]dcmAction{
+	dcmTag(0x0008, 0x0020): {"StudyDate", true},
+	dcmTag(0x0008, 0x0021): {"SeriesDate", false},
+	dcmTag(0x0008, 0x0022): {"AcquisitionDate", false},
+	dcmTag(0x0008, 0x0023): {"ContentDate", true},
+	dcmTag(0x0008, 0x0024): {"OverlayDate", false},
+	dcmTag(0x0008, 0x0025): {"CurveDate", false},
+	dcmTag(0x0008, 0x002A): {"AcquisitionDateTime", false},
+	dcmTag(0x0008, 0x0030): {"StudyTime", true},
+	dcmTag(0x0008, 0x0031): {"SeriesTime", false},
+	dcmTag(0x0008, 0x0032): {"AcquisitionTime", false},
+	dcmTag(0x0008, 0x0033): {"ContentTime", true},
+	dcmTag(0x0008, 0x0050): {"AccessionNumber", true},
+	dcmTag(0x0008, 0x0080): {"InstitutionName", false},
+	dcmTag(0x0008, 0x0081): {"InstitutionAddress", false},
+	dcmTag(0x0008, 0x0082): {"InstitutionCodeSequence", false},
+	dcmTag(0x0008, 0x0090): {"ReferringPhysicianName", true},
+	dcmTag(0x0008, 0x0092): {"ReferringPhysicianAddress", false},
+	dcmTag(0x0008, 0x0094): {"ReferringPhysicianTelephoneNumbers", false},
+	dcmTag(0x0008, 0x0096): {"ReferringPhysicianIdentificationSequence", false},
+	dcmTag(0x0008, 0x1010): {"StationName", false},
+	dcmTag(0x0008, 0x1030): {"StudyDescription", false},
+	dcmTag(0x0008, 0x103E): {"SeriesDescription", false},
+	dcmTag(0x0008, 0x1040): {"InstitutionalDepartmentName", false},
+	dcmTag(0x0008, 0x1048): {"PhysiciansOfRecord", false},
+	dcmTag(0x0008, 0x1049): {"PhysiciansOfRecordIdentificationSequence", false},
+	dcmTag(0x0008, 0x1050): {"PerformingPhysicianName", false},
+	dcmTag(0x0008, 0x1052): {"PerformingPhysicianIdentificationSequence", false},
+	dcmTag(0x0008, 0x1060): {"NameOfPhysiciansReadingStudy", false},
+	dcmTag(0x0008, 0x1062): {"PhysiciansReadingStudyIdentificationSequence", false},
+	dcmTag(0x0008, 0x1070): {"OperatorsName", false},
+	dcmTag(0x0008, 0x1072): {"OperatorIdentificationSequence", false},
+	dcmTag(0x0008, 0x1080): {"AdmittingDiagnosesDescription", false},
+	dcmTag(0x0008, 0x1084): {"AdmittingDiagnosesCodeSequence", false},
+	dcmTag(0x0008, 0x1120): {"ReferencedPatientSequence", false},
+	dcmTag(0x0008, 0x2111): {"DerivationDescription", false},
+	dcmTag(0x0010, 0x0010): {"PatientName", true},
+	dcmTag(0x0010, 0x0020): {"PatientID", true},
+	dcmTag(0x0010, 0x0021): {"IssuerOfPatientID", false},
+	dcmTag(0x0010, 0x0030): {"PatientBirthDate", true},
+	dcmTag(0x0010, 0x0032): {"PatientBirthTime", false},
+	dcmTag(0x0010, 0x0040): {"PatientSex", true},
+	dcmTag(0x0010, 0x0050): {"PatientInsurancePlanCodeSequence", false},
+	dcmTag(0x0010, 0x1000): {"OtherPatientIDs", false},
+	dcmTag(0x0010, 0x1001): {"OtherPatientNames", false},
+	dcmTag(0x0010, 0x1002): {"OtherPatientIDsSequence", false},
+	dcmTag(0x0010, 0x1005): {"PatientBirthName", false},
+	dcmTag(0x0010, 0x1010): {"PatientAge", false},
+	dcmTag(0x0010, 0x1020): {"PatientSize", false},
+	dcmTag(0x0010, 0x1030): {"PatientWeight", false},
+	dcmTag(0x0010, 0x1040): {"PatientAddress", false},
+	dcmTag(0x0010, 0x1060): {"PatientMotherBirthName", false},
+	dcmTag(0x0010, 0x1080): {"MilitaryRank", false},
+	dcmTag(0x0010, 0x1081): {"BranchOfService", false},
+	dcmTag(0x0010, 0x1090): {"MedicalRecordLocator", false},
+	dcmTag(0x0010, 0x2000): {"MedicalAlerts", false},
+	dcmTag(0x0010, 0x2110): {"Allergies", false},
+	dcmTag(0x0010, 0x2150): {"CountryOfResidence", false},
+	dcmTag(0x0010, 0x2152): {"RegionOfResidence", false},
+	dcmTag(0x0010, 0x2154): {"PatientTelephoneNumbers", false},
+	dcmTag(0x0010, 0x2160): {"EthnicGroup", false},
+	dcmTag(0x0010, 0x2180): {"Occupation", false},
+	dcmTag(0x0010, 0x21A0): {"SmokingStatus", false},
+	dcmTag(0x0010, 0x21B0): {"AdditionalPatientHistory", false},
+	dcmTag(0x0010, 0x21C0): {"PregnancyStatus", false},
+	dcmTag(0x0010, 0x21D0): {"LastMenstrualDate", false},
+	dcmTag(0x0010, 0x21F0): {"PatientReligiousPreference", false},
+	dcmTag(0x0010, 0x4000): {"PatientComments", false},
+	dcmTag(0x0018, 0x1000): {"DeviceSerialNumber", false},
+	dcmTag(0x0018, 0x1030): {"ProtocolName", false},
+	dcmTag(0x0018, 0x1400): {"AcquisitionDeviceProcessingDescription", false},
+	dcmTag(0x0020, 0x0010): {"StudyID", true},
+	dcmTag(0x0020, 0x4000): {"ImageComments", false},
+	dcmTag(0x0032, 0x1032): {"RequestingPhysician", false},
+	dcmTag(0x0032, 0x1033): {"RequestingService", false},
+	dcmTag(0x0032, 0x1060): {"RequestedProcedureDescription", false},
+	dcmTag(0x0032, 0x4000): {"StudyComments", false},
+	dcmTag(0x0038, 0x0010): {"AdmissionID", false},
+	dcmTag(0x0038, 0x0300): {"CurrentPatientLocation", false},
+	dcmTag(0x0038, 0x0400): {"PatientInstitutionResidence", false},
+	dcmTag(0x0038, 0x0500): {"PatientState", false},
+	dcmTag(0x0038, 0x4000): {"VisitComments", false},
+	dcmTag(0x0040, 0x0006): {"ScheduledPerformingPhysicianName", false},
+	dcmTag(0x0040, 0x0241): {"PerformedStationAETitle", false},
+	dcmTag(0x0040, 0x0242): {"PerformedStationName", false},
+	dcmTag(0x0040, 0x0243): {"PerformedLocation", false},
+	dcmTag(0x0040, 0x0244): {"PerformedProcedureStepStartDate", false},
+	dcmTag(0x0040, 0x0245): {"PerformedProcedureStepStartTime", false},
+	dcmTag(0x0040, 0x0253): {"PerformedProcedureStepID", false},
+	dcmTag(0x0040, 0x0254): {"PerformedProcedureStepDescription", false},
+	dcmTag(0x0040, 0x0275): {"RequestAttributesSequence", false},
+	dcmTag(0x0040, 0x1001): {"RequestedProcedureID", false},
+	dcmTag(0x0040, 0x2016): {"PlacerOrderNumberImagingServiceRequest", false},
+	dcmTag(0x0040, 0x2017): {"FillerOrderNumberImagingServiceRequest", false},
+	dcmTag(0x0070, 0x0001): {"GraphicAnnotationSequence", false},
+
+	// 文件元信息
+	dcmTag(0x0002, 0x0016): {"SourceApplicationEntityTitle", false},
+	dcmTag(0x0002, 0x0017): {"SendingApplicationEntityTitle", false},
+	dcmTag(0x0002, 0x0018): {"ReceivingApplicationEntityTitle", false},
+	dcmTag(0x0002, 0x0100): {"PrivateInformationCreatorUID", false},
+	dcmTag(0x0002, 0x0102): {"PrivateInformation", false},
+}
+
+// dcmPHIAction 返回标签的处理方式：先查配置表，再按重复组匹配叠加与曲线数据
+func dcmPHIAction(tag uint32) (dcmAction, bool) {
+	if a, ok := dcmPHI[tag]; ok {
+		return a, true
+	}
+	g, el := tag>>16, tag&0xFFFF
+	if g&1 == 1 || g < 0x5000 || g > 0x601E || (g > 0x501E && g < 0x6000) {
+		return dcmAction{}, false
+	}
+	switch {
+	case g <= 0x501E:
+		return dcmAction{name: "CurveData"}, true // 已退役的曲线模块，整组删除
+	case el == 0x3000:
+		return dcmAction{name: "OverlayData"}, true
+	case el == 0x4000:
+		return dcmAction{name: "OverlayComments"}, true
+	}
+	return dcmAction{}, false
+}
+
+var (
+	dcmMetaLength      = dcmTag(0x0002, 0x0000)
+	dcmPixelData       = dcmTag(0x7FE0, 0x0010)
+	dcmIdentityRemoved = dcmTag(0x0012, 0x0062)
+	dcmDeidentMethod   = dcmTag(0x0012, 0x0063)
+	dcmItemTag         = dcmTag(0xFFFE, 0xE000)
+	dcmItemDelim       = dcmTag(0xFFFE, 0xE00D)
+	dcmSeqDelim        = dcmTag(0xFFFE, 0xE0DD)
+)
+
+const dcmUndefined = 0xFFFFFFFF
+
+// 显式 VR 中使用 4 字节长度的 VR
+var dcmLongVR = map[string]bool{
+	"OB": true, "OD": true, "OF": true, "OL": true, "OV": true, "OW": true, "SQ": true,
+	"SV": true, "UC": true, "UN": true, "UR": true, "UT": true, "UV": true,
+}
+
+// dcmCodec 为数据集的编码方式（由传输语法决定）
+type dcmCodec struct {
+	bo interface {
+		binary.ByteOrder
+		binary.AppendByteOrder
+	}
+	explicit bool
+}
+
+var (
+	dcmImplicitLE = dcmCodec{bo: binary.LittleEndian}
+	dcmMetaCodec  = dcmCodec{bo: binary.LittleEndian, explicit: true} // 文件元信息总是显式 VR 小端
+)
+
+// dcmElem 是一个数据元素；序列元素的取值在 items 中，封装的像素数据以原始字节保存
+type dcmElem struct {
+	tag       uint32
+	vr        string // 显式 VR 时为两个字符，隐式 VR 时为空
+	value     []byte
+	sq        bool
+	items     []dcmItem
+	inner     dcmCodec // 序列条目的编码（UN 未定义长度时按隐式 VR 小端）
+	undefined bool     // 原长度未定义（序列以分隔符结束）
+	encap     bool     // 封装的像素数据：value 为包含序列分隔符在内的全部片段
+}
+
+type dcmItem struct {
+	elems     []dcmElem
+	undefined bool
+}
+
+// dcmFile 是解析后的 DICOM 文件
+type dcmFile struct {
+	meta  []dcmElem // "DICM" 之后的文件元信息（0002 组），写出时重新计算组长度
+	codec dcmCodec
+	elems []dcmElem
+}
+
+func parseDICOM(b []byte) (*dcmFile, error) {
+	if len(b) < 132 || string(b[128:132]) != "DICM" {
+		return nil, errors.New("不是合法的 DICOM 文件（缺少 DICM 前导标记）")
+	}
+	// 逐个读取 0002 组的元素以确定其范围与传输语法
+	f := &dcmFile{}
+	p, ts := 132, ""
+	for p+4 <= len(b) && binary.LittleEndian.Uint16(b[p:]) == 0x0002 {
+		e, n, err := dcmMetaCodec.readElem(b[p:])
+		if err != nil {
+			return nil, fmt.Errorf("解析文件元信息失败: %w", err)
+		}
+		if e.tag == dcmTag(0x0002, 0x0010) {
+			ts = strings.TrimRight(string(e.value), "\x00 ")
+		}
+		f.meta = append(f.meta, e)
+		p += n
+	}
+	switch ts {
+	case "1.2.840.10008.1.2":
+		f.codec = dcmImplicitLE
+	case "1.2.840.10008.1.2.2":
+		f.codec = dcmCodec{bo: binary.BigEndian, explicit: true}
+	case "1.2.840.10008.1.2.1.99":
+		return nil, errors.New("不支持 Deflate 压缩的 DICOM 传输语法")
+	default:
+		f.codec = dcmMetaCodec
+	}
+	elems, _, err := f.codec.readDataset(b[p:], false)
+	if err != nil {
+		return nil, err
+	}
+	f.elems = elems
+	return f, nil
+}
+
+// readDataset 读取元素直到 b 结束；inItem 时读到条目结束标记为止，n 包含该标记
+func (c dcmCodec) readDataset(b []byte, inItem bool) (elems []dcmElem, n int, err error) {
+	p := 0
+	for p < len(b) {
+		if inItem && p+8 <= len(b) && c.tagAt(b[p:]) == dcmItemDelim {
+			return elems, p + 8, nil
+		}
+		e, m, err := c.readElem(b[p:])
+		if err != nil {
+			return nil, 0, err
+		}
+		elems = append(elems, e)
+		p += m
+	}
+	if inItem {
+		return nil, 0, errors.New("DICOM 条目缺少结束标记")
+	}
+	return elems, p, nil
+}
+
+func (c dcmCodec) tagAt(b []byte) uint32 {
+	return dcmTag(c.bo.Uint16(b), c.bo.Uint16(b[2:]))
+}
+
+func (c dcmCodec) readElem(b []byte) (dcmElem, int, error) {
+	if len(b) < 8 {
+		return dcmElem{}, 0, errors.New("DICOM 元素头被截断")
+	}
+	e := dcmElem{tag: c.tagAt(b)}
+	var length uint32
+	hdr := 8
+	if c.explicit {
+		e.vr = string(b[4:6])
+		if dcmLongVR[e.vr] {
+			if len(b) < 12 {
+				return dcmElem{}, 0, errors.New("DICOM 元素头被截断")
+			}
+			length, hdr = c.bo.Uint32(b[8:]), 12
+		} else {
+			length = uint32(c.bo.Uint16(b[6:]))
+		}
+	} else {
+		length = c.bo.Uint32(b[4:])
+	}
+	body := b[hdr:]
+
+	if length == dcmUndefined {
+		e.undefined = true
+		if e.tag == dcmPixelData {
+			n, err := c.skipFragments(body)
+			if err != nil {
+				return dcmElem{}, 0, err
+			}
+			e.encap, e.value = true, body[:n]
+			return e, hdr + n, nil
+		}
+		e.sq, e.inner = true, c
+		if e.vr == "UN" {
+			e.inner = dcmImplicitLE
+		}
+		items, n, err := e.inner.readItems(body, true)
+		if err != nil {
+			return dcmElem{}, 0, err
+		}
+		e.items = items
+		return e, hdr + n, nil
+	}
+
+	if uint64(length) > uint64(len(body)) {
+		return dcmElem{}, 0, fmt.Errorf("DICOM 元素 (%04X,%04X) 长度越界", e.tag>>16, e.tag&0xFFFF)
+	}
+	val := body[:length]
+	// 隐式 VR 无法从数据得知 VR：取值以条目标记开头时按序列解析，失败则作为普通取值
+	if e.vr == "SQ" || (!c.explicit && len(val) >= 8 && c.tagAt(val) == dcmItemTag) {
+		if items, _, err := c.readItems(val, false); err == nil {
+			e.sq, e.inner, e.items = true, c, items
+			return e, hdr + int(length), nil
+		} else if e.vr == "SQ" {
+			return dcmElem{}, 0, err
+		}
+	}
+	e.value = val
+	return e, hdr + int(length), nil
+}
+
+// readItems 读取序列中的条目；undefined 时读到序列结束标记为止，n 包含该标记
+func (c dcmCodec) readItems(b []byte, undefined bool) (items []dcmItem, n int, err error) {
+	p := 0
+	for {
+		if p+8 > len(b) {
+			if !undefined && p == len(b) {
+				return items, p, nil
+			}
+			return nil, 0, errors.New("DICOM 序列被截断")
+		}
+		tag, length := c.tagAt(b[p:]), c.bo.Uint32(b[p+4:])
+		switch {
+		case tag == dcmSeqDelim && undefined:
+			return items, p + 8, nil
+		case tag != dcmItemTag:
+			return nil, 0, fmt.Errorf("DICOM 序列中出现非条目标签 (%04X,%04X)", tag>>16, tag&0xFFFF)
+		case length == dcmUndefined:
+			elems, m, err := c.readDataset(b[p+8:], true)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, dcmItem{elems: elems, undefined: true})
+			p += 8 + m
+		default:
+			if uint64(length) > uint64(len(b)-p-8) {
+				return nil, 0, errors.New("DICOM 条目长度越界")
+			}
+			elems, _, err := c.readDataset(b[p+8:p+8+int(length)], false)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, dcmItem{elems: elems})
+			p += 8 + int(length)
+		}
+	}
+}
+
+// skipFragments 返回封装像素数据（偏移表与各帧片段）直到序列结束标记（含）的长度
+func (c dcmCodec) skipFragments(b []byte) (int, error) {
+	p := 0
+	for p+8 <= len(b) {
+		tag, length := c.tagAt(b[p:]), c.bo.Uint32(b[p+4:])
+		if tag == dcmSeqDelim {
+			return p + 8, nil
+		}
+		if tag != dcmItemTag || length == dcmUndefined || uint64(length) > uint64(len(b)-p-8) {
+			return 0, errors.New("封装的像素数据格式错误")
+		}
+		p += 8 + int(length)
+	}
+	return 0, errors.New("封装的像素数据缺少结束标记")
+}
+
+// —— 写出 ——
+
+func (f *dcmFile) bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 128)) // 前导区清零
+	buf.WriteString("DICM")
+	var meta bytes.Buffer
+	for _, e := range f.meta {
+		if e.tag != dcmMetaLength {
+			dcmMetaCodec.writeElem(&meta, e)
+		}
+	}
+	length := dcmElem{tag: dcmMetaLength, vr: "UL", value: binary.LittleEndian.AppendUint32(nil, uint32(meta.Len()))}
+	dcmMetaCodec.writeElem(&buf, length)
+	buf.Write(meta.Bytes())
+	f.codec.writeDataset(&buf, f.elems)
+	return buf.Bytes()
+}
+
+func (c dcmCodec) writeDataset(buf *bytes.Buffer, elems []dcmElem) {
+	for _, e := range elems {
+		c.writeElem(buf, e)
+	}
+}
+
+func (c dcmCodec) writeTag(buf *bytes.Buffer, tag uint32) {
+	buf.Write(c.bo.AppendUint16(nil, uint16(tag>>16)))
+	buf.Write(c.bo.AppendUint16(nil, uint16(tag)))
+}
+
+func (c dcmCodec) writeHeader(buf *bytes.Buffer, e dcmElem, length uint32) {
+	c.writeTag(buf, e.tag)
+	if !c.explicit {
+		buf.Write(c.bo.AppendUint32(nil, length))
+		return
+	}
+	buf.WriteString(e.vr)
+	if dcmLongVR[e.vr] {
+		buf.Write([]byte{0, 0})
+		buf.Write(c.bo.AppendUint32(nil, length))
+	} else {
+		buf.Write(c.bo.AppendUint16(nil, uint16(length)))
+	}
+}
+
+func (c dcmCodec) writeElem(buf *bytes.Buffer, e dcmElem) {
+	switch {
+	case e.encap:
+		c.writeHeader(buf, e, dcmUndefined)
+		buf.Write(e.value)
+	case e.sq:
+		var body bytes.Buffer
+		for _, it := range e.items {
+			e.inner.writeItem(&body, it)
+		}
+		if e.undefined {
+			c.writeHeader(buf, e, dcmUndefined)
+			buf.Write(body.Bytes())
+			e.inner.writeTag(buf, dcmSeqDelim)
+			buf.Write(make([]byte, 4))
+			return
+		}
+		c.writeHeader(buf, e, uint32(body.Len()))
+		buf.Write(body.Bytes())
+	default:
+		c.writeHeader(buf, e, uint32(len(e.value)))
+		buf.Write(e.value)
+	}
+}
+
+func (c dcmCodec) writeItem(buf *bytes.Buffer, it dcmItem) {
+	var body bytes.Buffer
+	c.writeDataset(&body, it.elems)
+	c.writeTag(buf, dcmItemTag)
+	if it.undefined {
+		buf.Write(c.bo.AppendUint32(nil, dcmUndefined))
+		buf.Write(body.Bytes())
+		c.writeTag(buf, dcmItemDelim)
+		buf.Write(make([]byte, 4))
+		return
+	}
+	buf.Write(c.bo.AppendUint32(nil, uint32(body.Len())))
+	buf.Write(body.Bytes())
+}
+
+// —— 去标识化 ——
+
+// dcmPrivate 判断是否为私有标签（奇数组号）
+func dcmPrivate(tag uint32) bool {
+	g := tag >> 16
+	return g&1 == 1 && g > 0x0008
+}
+
+// deidentify 按配置表处理元素，递归进入序列；同时删除私有标签与组长度元素
+func deidentify(elems []dcmElem) []dcmElem {
+	out := elems[:0:0]
+	for _, e := range elems {
+		if dcmPrivate(e.tag) || e.tag&0xFFFF == 0 {
+			continue
+		}
+		if a, ok := dcmPHIAction(e.tag); ok {
+			if !a.empty {
+				continue
+			}
+			e.value, e.items = nil, nil
+			out = append(out, e)
+			continue
+		}
+		if e.sq {
+			items := make([]dcmItem, len(e.items))
+			for i, it := range e.items {
+				items[i] = dcmItem{elems: deidentify(it.elems), undefined: it.undefined}
+			}
+			e.items = items
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// setDeidentified 写入（或替换）PatientIdentityRemoved 与 DeidentificationMethod，保持标签升序
+func setDeidentified(elems []dcmElem, explicit bool) []dcmElem {
+	add := []dcmElem{
+		{tag: dcmIdentityRemoved, vr: "CS", value: []byte("YES ")},
+		{tag: dcmDeidentMethod, vr: "LO", value: []byte("DICOM PS3.15 E.1 Basic Profile")},
+	}
+	out := make([]dcmElem, 0, len(elems)+len(add))
+	for _, e := range elems {
+		if e.tag != dcmIdentityRemoved && e.tag != dcmDeidentMethod {
+			out = append(out, e)
+		}
+	}
+	for _, a := range add {
+		if !explicit {
+			a.vr = ""
+		}
+		i := sort.Search(len(out), func(i int) bool { return out[i].tag > a.tag })
+		out = append(out[:i], append([]dcmElem{a}, out[i:]...)...)
+	}
+	return out
+}
+
+func (s *Scrubber) scrubDICOM(path, dst, _ string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := parseDICOM(data)
+	if err != nil {
+		return err
+	}
+	f.meta = deidentify(f.meta) // 组长度元素一并去掉，写出时重新计算
+	f.elems = setDeidentified(deidentify(f.elems), f.codec.explicit)
+	return s.writeReplace(path, dst, f.bytes())
+}
+
+// —— 检查与校验 ——
+
+// walkDICOM 依次对每个元素（含序列中的）调用 fn
+func walkDICOM(elems []dcmElem, fn func(e dcmElem)) {
+	for _, e := range elems {
+		fn(e)
+		for _, it := range e.items {
+			walkDICOM(it.elems, fn)
+		}
+	}
+}
+
+// dcmText 返回适合展示的文本取值，含不可打印字符时返回空串
+func dcmText(v []byte) string {
+	t := strings.TrimRight(string(v), "\x00 ")
+	for _, r := range t {
+		if !unicode.IsPrint(r) {
+			return ""
+		}
+	}
+	return t
+}
+
+func (s *Scrubber) inspectDICOM(path, _ string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseDICOM(data)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	private := 0
+	walkDICOM(append(f.meta, f.elems...), func(e dcmElem) {
+		if dcmPrivate(e.tag) {
+			private++
+			return
+		}
+		a, ok := dcmPHIAction(e.tag)
+		if !ok || (len(e.value) == 0 && len(e.items) == 0) {
+			return
+		}
+		item := fmt.Sprintf("%s (%04X,%04X)", a.name, e.tag>>16, e.tag&0xFFFF)
+		fs = append(fs, Finding{Item: item, Value: dcmText(e.value)})
+	})
+	if private > 0 {
+		fs = append(fs, Finding{Item: "私有标签", Value: fmt.Sprintf("%d 个", private)})
+	}
+	return fs, nil
+}
+
+// verifyDICOM 检查输出中没有应删除的标签、应清空的标签取值为空，且没有私有标签
+func (s *Scrubber) verifyDICOM(out, _ string) error {
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return err
+	}
+	f, err := parseDICOM(data)
+	if err != nil {
+		return err
+	}
+	var bad error
+	walkDICOM(append(f.meta, f.elems...), func(e dcmElem) {
+		if bad != nil {
+			return
+		}
+		if dcmPrivate(e.tag) {
+			bad = fmt.Errorf("仍包含私有标签 (%04X,%04X)", e.tag>>16, e.tag&0xFFFF)
+			return
+		}
+		if a, ok := dcmPHIAction(e.tag); ok && (!a.empty || len(e.value) > 0 || len(e.items) > 0) {
+			bad = fmt.Errorf("仍包含 %s", a.name)
+		}
+	})
+	return bad
+}
@@ -0,0 +1,198 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// dcmEl 以显式（vr 非空）或隐式 VR 小端写出一个元素；length 为 dcmUndefined 时按未定义长度写出，value 须自带结束标记
+func dcmEl(group, elem uint16, vr string, value []byte, length uint32) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, group)
+	b = binary.LittleEndian.AppendUint16(b, elem)
+	switch {
+	case vr == "":
+		b = binary.LittleEndian.AppendUint32(b, length)
+	case dcmLongVR[vr]:
+		b = append(b, vr...)
+		b = append(b, 0, 0)
+		b = binary.LittleEndian.AppendUint32(b, length)
+	default:
+		b = append(b, vr...)
+		b = binary.LittleEndian.AppendUint16(b, uint16(length))
+	}
+	return append(b, value...)
+}
+
+// dcmVal 写出定长元素
+func dcmVal(group, elem uint16, vr, value string) []byte {
+	return dcmEl(group, elem, vr, []byte(value), uint32(len(value)))
+}
+
+// dcmDelim 写出条目或序列的结束标记
+func dcmDelim(elem uint16) []byte {
+	return dcmEl(0xFFFE, elem, "", nil, 0)
+}
+
+// testDICOM 返回带有文件元信息与给定数据集的 DICOM 文件，ts 为传输语法 UID
+func testDICOM(ts string, dataset ...[]byte) []byte {
+	meta := bytes.Join([][]byte{
+		dcmEl(0x0002, 0x0001, "OB", []byte{0, 1}, 2),
+		dcmVal(0x0002, 0x0010, "UI", ts),
+		dcmVal(0x0002, 0x0016, "AE", "HOSPITAL_PACS "),
+	}, nil)
+	b := make([]byte, 128)
+	b = append(b, "DICM"...)
+	b = append(b, dcmEl(0x0002, 0x0000, "UL", binary.LittleEndian.AppendUint32(nil, uint32(len(meta))), 4)...)
+	b = append(b, meta...)
+	return append(b, bytes.Join(dataset, nil)...)
+}
+
+const (
+	dcmExplicitLE = "1.2.840.10008.1.2.1\x00"
+	dcmImplicitTS = "1.2.840.10008.1.2\x00"
+)
+
+// scrubDICOMBytes 把 data 写入临时文件处理并返回输出
+func scrubDICOMBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	p := writeTestFile(t, t.TempDir(), "a.dcm", data)
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.verifyDICOM(p, ".dcm"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// assertNoPHI 检查输出中不含任何 PHI 字符串，且 PatientName 保留为空值
+func assertNoPHI(t *testing.T, out []byte, phi ...string) {
+	t.Helper()
+	for _, s := range phi {
+		if bytes.Contains(out, []byte(s)) {
+			t.Errorf("输出仍含有 %q", s)
+		}
+	}
+	f, err := parseDICOM(out)
+	if err != nil {
+		t.Fatalf("输出无法解析: %v", err)
+	}
+	found := false
+	walkDICOM(f.elems, func(e dcmElem) {
+		if e.tag == dcmTag(0x0010, 0x0010) && len(e.value) == 0 {
+			found = true
+		}
+	})
+	if !found {
+		t.Error("PatientName（Type 2）应保留为空值")
+	}
+}
+
+func TestDICOMExplicitVR(t *testing.T) {
+	item := bytes.Join([][]byte{
+		dcmVal(0x0008, 0x1150, "UI", "1.2.3\x00"),
+		dcmVal(0x0010, 0x0010, "PN", "NESTED^PATIENT"),
+	}, nil)
+	seqBody := dcmEl(0xFFFE, 0xE000, "", item, uint32(len(item)))
+	data := testDICOM(dcmExplicitLE,
+		dcmVal(0x0008, 0x0080, "LO", "General Hospital"),
+		dcmEl(0x0008, 0x1115, "SQ", seqBody, uint32(len(seqBody))),
+		dcmVal(0x0010, 0x0010, "PN", "DOE^JOHN"),
+		dcmVal(0x0010, 0x0020, "LO", "MRN12345"),
+		dcmVal(0x0011, 0x0010, "LO", "PRIVATE CREATOR"),
+		dcmVal(0x5000, 0x0005, "US", "\x02\x00"),
+		dcmEl(0x5000, 0x3000, "OW", []byte("CURVEPHI"), 8),
+		dcmEl(0x6000, 0x3000, "OW", []byte("OVERLAYPHI"), 10),
+		dcmVal(0x6000, 0x4000, "LT", "Overlay by Dr Smith"),
+		dcmEl(0x7FE0, 0x0010, "OW", []byte{1, 2, 3, 4}, 4),
+	)
+	out := scrubDICOMBytes(t, data)
+	assertNoPHI(t, out, "General Hospital", "NESTED^PATIENT", "DOE^JOHN", "MRN12345", "PRIVATE CREATOR",
+		"CURVEPHI", "OVERLAYPHI", "Dr Smith", "HOSPITAL_PACS")
+
+	f, err := parseDICOM(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walkDICOM(f.elems, func(e dcmElem) {
+		if e.tag == dcmPixelData && !bytes.Equal(e.value, []byte{1, 2, 3, 4}) {
+			t.Error("像素数据应原样保留")
+		}
+	})
+	// 组长度应恰好覆盖其后的 0002 组元素
+	metaLen := int(binary.LittleEndian.Uint32(out[132+8:]))
+	p, end := 132+12, 132+12+metaLen
+	for p < end {
+		e, n, err := dcmMetaCodec.readElem(out[p:])
+		if err != nil || e.tag>>16 != 0x0002 {
+			t.Fatalf("文件元信息组长度 %d 与内容不符", metaLen)
+		}
+		p += n
+	}
+	if p != end || binary.LittleEndian.Uint16(out[end:]) == 0x0002 {
+		t.Errorf("文件元信息组长度 %d 与内容不符", metaLen)
+	}
+}
+
+func TestDICOMImplicitVRWithUndefinedLengthSequence(t *testing.T) {
+	item := bytes.Join([][]byte{
+		dcmVal(0x0010, 0x0010, "", "NESTED^IMPLICIT"),
+		dcmVal(0x0008, 0x0090, "", "REFERRER^DR"),
+		dcmDelim(0xE00D),
+	}, nil)
+	seq := append(dcmEl(0xFFFE, 0xE000, "", item, dcmUndefined), dcmDelim(0xE0DD)...)
+	graphic := dcmVal(0x0070, 0x0006, "", "Tumor seen by Dr Who")
+	graphicItem := dcmEl(0xFFFE, 0xE000, "", graphic, uint32(len(graphic)))
+	data := testDICOM(dcmImplicitTS,
+		dcmEl(0x0008, 0x1110, "", seq, dcmUndefined),
+		dcmVal(0x0010, 0x0010, "", "ROE^JANE"),
+		dcmVal(0x0010, 0x0030, "", "19700101"),
+		dcmEl(0x0070, 0x0001, "", graphicItem, uint32(len(graphicItem))),
+	)
+	out := scrubDICOMBytes(t, data)
+	assertNoPHI(t, out, "NESTED^IMPLICIT", "REFERRER^DR", "ROE^JANE", "19700101", "Dr Who", "HOSPITAL_PACS")
+
+	f, err := parseDICOM(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.codec.explicit {
+		t.Error("输出应保持隐式 VR")
+	}
+	var nested bool
+	for _, e := range f.elems {
+		if e.tag == dcmTag(0x0008, 0x1110) && e.sq && e.undefined && len(e.items) == 1 {
+			nested = true
+		}
+	}
+	if !nested {
+		t.Error("未定义长度的序列及其条目应保留")
+	}
+}
+
+func TestDCMPHIActionRepeatingGroups(t *testing.T) {
+	for _, c := range []struct {
+		tag  uint32
+		want bool
+	}{
+		{dcmTag(0x5000, 0x3000), true},
+		{dcmTag(0x501E, 0x0010), true},
+		{dcmTag(0x6002, 0x3000), true},
+		{dcmTag(0x601E, 0x4000), true},
+		{dcmTag(0x6000, 0x0010), false}, // OverlayRows 不含 PHI
+		{dcmTag(0x6001, 0x3000), false}, // 奇数组为私有标签，另行处理
+		{dcmTag(0x5020, 0x3000), false},
+	} {
+		if _, ok := dcmPHIAction(c.tag); ok != c.want {
+			t.Errorf("dcmPHIAction(%04X,%04X) = %v, 期望 %v", c.tag>>16, c.tag&0xFFFF, ok, c.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// —— EPUB：编辑 OPF 包文档中的元数据 ——
+// 与 Office 不同，EPUB 的元数据与书名、语言、标识符等必需项放在同一个 OPF 文件中，
+// 不能整份删除，因此只删掉标识个人/机构的条目：dc:creator、dc:contributor、dc:publisher、dc:date，
+// 以及 calibre 写入的 <meta name="calibre:*">。OPF 路径从 META-INF/container.xml 读取。
+// mimetype 必须是第一个且不压缩的条目，writeZip 保持原顺序与压缩方式，因此天然满足。
+
+// epubDropDC 为要删除的 Dublin Core 元素（按本地名匹配）
+var epubDropDC = map[string]bool{
+	"creator": true, "contributor": true, "publisher": true, "date": true,
+}
+
+func init() {
+	RegisterHandler(".epub", handlerFuncs{
+		kind:    "epub",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubEPUB(p, dst) },
+		inspect: func(_ *Scrubber, p, _ string) ([]Finding, error) { return inspectEPUB(p) },
+		verify:  func(_ *Scrubber, out, _ string) error { return verifyEPUB(out) },
+		info: HandlerInfo{
+			Method:  "编辑 OPF 包文档",
+			Removes: "dc:creator、dc:contributor、dc:publisher、dc:date、calibre 元数据与书签",
+		},
+	})
+}
+
+func (s *Scrubber) scrubEPUB(p, dst string) error {
+	opf, err := epubOPFPath(p)
+	if err != nil {
+		return err
+	}
+	edit := func(name string) func(r io.Reader, w io.Writer) error {
+		if name != opf {
+			return nil
+		}
+		return func(r io.Reader, w io.Writer) error {
+			return filterXML(r, w, newEPUBMetaDrop(), nil)
+		}
+	}
+	return s.rewriteZip(p, dst, keepEPUBEntry, s.nestedEdit(edit, 0, newZipBudget()))
+}
+
+// keepEPUBEntry 丢弃 calibre 的阅读位置书签，其余条目全部保留
+func keepEPUBEntry(name string) bool {
+	return strings.ToLower(name) != "meta-inf/calibre_bookmarks.txt"
+}
+
+// epubOPFPath 从 container.xml 中读取第一个 rootfile 的路径
+func epubOPFPath(p string) (string, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return "", fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+
+	f, err := zr.Open("META-INF/container.xml")
+	if err != nil {
+		return "", errors.New("缺少 META-INF/container.xml，不是合法的 EPUB")
+	}
+	defer f.Close()
+
+	var c struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.NewDecoder(f).Decode(&c); err != nil {
+		return "", fmt.Errorf("解析 container.xml 失败: %w", err)
+	}
+	if len(c.Rootfiles) == 0 || c.Rootfiles[0].FullPath == "" {
+		return "", errors.New("container.xml 中没有 rootfile")
+	}
+	return path.Clean(c.Rootfiles[0].FullPath), nil
+}
+
+// newEPUBMetaDrop 返回 OPF 的元素过滤器。EPUB 3 用 <meta refines="#id"> 为 dc:creator 补充
+// file-as、role 等信息（其中往往又是作者名），因此记下被删除元素的 id，一并删除引用它们的 meta。
+func newEPUBMetaDrop() xmlDrop {
+	dropped := map[string]bool{}
+	return func(el xml.StartElement) bool {
+		if epubDropDC[el.Name.Local] && el.Name.Space != "" {
+			for _, a := range el.Attr {
+				if a.Name.Local == "id" {
+					dropped["#"+a.Value] = true
+				}
+			}
+			return true
+		}
+		if el.Name.Local != "meta" {
+			return false
+		}
+		for _, a := range el.Attr {
+			switch a.Name.Local {
+			case "name", "property":
+				if strings.HasPrefix(a.Value, "calibre:") {
+					return true
+				}
+			case "refines":
+				if dropped[a.Value] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
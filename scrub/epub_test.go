@@ -0,0 +1,80 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testEPUB 返回最小的 EPUB 3：mimetype 为第一个、不压缩的条目
+func testEPUB(t *testing.T, opf string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range []struct {
+		name, data string
+		method     uint16
+	}{
+		{"mimetype", "application/epub+zip", zip.Store},
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">` +
+			`<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`, zip.Deflate},
+		{"OEBPS/content.opf", opf, zip.Deflate},
+		{"OEBPS/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Chapter</p></body></html>`, zip.Deflate},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, e.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEPUBCreatorRemovedMimetypeFirst(t *testing.T) {
+	opf := `<?xml version="1.0" encoding="UTF-8"?><package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">` +
+		`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:identifier id="uid">urn:uuid:1234</dc:identifier><dc:title>Public Title</dc:title><dc:language>en</dc:language>` +
+		`<dc:creator id="c1">Alice Secret</dc:creator><meta refines="#c1" property="file-as">Secret, Alice</meta>` +
+		`<meta name="calibre:user_metadata" content="Reviewer Bob"/>` +
+		`</metadata><manifest><item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/></manifest>` +
+		`<spine><itemref idref="ch1"/></spine></package>`
+
+	for _, method := range []string{"", "deflate"} {
+		t.Run("compression="+method, func(t *testing.T) {
+			p := writeTestFile(t, t.TempDir(), "book.epub", testEPUB(t, opf))
+			s := newTestScrubber()
+			s.Verify = true
+			s.CompressionMethod = method
+			if err := s.ScrubFile(p); err != nil {
+				t.Fatal(err)
+			}
+			zr, err := zip.OpenReader(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			first := zr.File[0]
+			if first.Name != "mimetype" || first.Method != zip.Store || len(first.Extra) != 0 {
+				t.Errorf("第一个条目 %s（方式 %d，扩展字段 %d 字节），期望不压缩、无扩展字段的 mimetype",
+					first.Name, first.Method, len(first.Extra))
+			}
+			_, parts := readZip(t, p)
+			got := parts["OEBPS/content.opf"]
+			for _, leak := range []string{"dc:creator", "Alice", "Reviewer Bob", "calibre:"} {
+				if strings.Contains(got, leak) {
+					t.Errorf("OPF 仍含有 %q", leak)
+				}
+			}
+			for _, keep := range []string{"Public Title", "urn:uuid:1234", "<dc:language>en</dc:language>"} {
+				if !strings.Contains(got, keep) {
+					t.Errorf("OPF 缺少应保留的 %q", keep)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// —— JPEG 段结构 ——
+// SOI 之后是一串 FFxx 标记段，直到 SOS（FFDA）为止；SOS 之后是熵编码的扫描数据，
+// 选择性脱敏只改动 SOS 之前的标记段，扫描数据原样拷贝，因此像素逐字节不变。
+
+const (
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+type jpegSegment struct {
+	marker byte
+	data   []byte // 不含 FFxx 标记与 2 字节长度
+}
+
+// splitJPEG 把 JPEG 拆为 SOS 之前的标记段和从 SOS 开始的剩余数据
+func splitJPEG(b []byte) ([]jpegSegment, []byte, error) {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != markerSOI {
+		return nil, nil, errors.New("缺少 SOI 标记，不是 JPEG")
+	}
+	var segs []jpegSegment
+	i := 2
+	for {
+		if i+2 > len(b) {
+			return nil, nil, errors.New("在 SOS 之前遇到文件结尾")
+		}
+		if b[i] != 0xFF {
+			return nil, nil, fmt.Errorf("偏移 %d 处不是合法标记", i)
+		}
+		marker := b[i+1]
+		switch {
+		case marker == 0xFF:
+			// 填充字节
+			i++
+			continue
+		case marker == markerSOS || marker == markerEOI:
+			return segs, b[i:], nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// TEM/RSTn 没有长度字段
+			segs = append(segs, jpegSegment{marker: marker})
+			i += 2
+			continue
+		}
+		if i+4 > len(b) {
+			return nil, nil, errors.New("标记段长度字段被截断")
+		}
+		n := int(binary.BigEndian.Uint16(b[i+2 : i+4]))
+		if n < 2 || i+2+n > len(b) {
+			return nil, nil, fmt.Errorf("标记段 FF%02X 长度非法: %d", marker, n)
+		}
+		segs = append(segs, jpegSegment{marker: marker, data: b[i+4 : i+2+n]})
+		i += 2 + n
+	}
+}
+
+// joinJPEG 是 splitJPEG 的逆过程
+func joinJPEG(segs []jpegSegment, rest []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, markerSOI})
+	for _, s := range segs {
+		buf.Write([]byte{0xFF, s.marker})
+		if s.marker == 0x01 || (s.marker >= 0xD0 && s.marker <= 0xD7) {
+			continue
+		}
+		if len(s.data)+2 > 0xFFFF {
+			return nil, fmt.Errorf("标记段 FF%02X 过长", s.marker)
+		}
+		var l [2]byte
+		binary.BigEndian.PutUint16(l[:], uint16(len(s.data)+2))
+		buf.Write(l[:])
+		buf.Write(s.data)
+	}
+	buf.Write(rest)
+	return buf.Bytes(), nil
+}
+
+// stripJPEGSelective 只移除定位/设备/拍摄时间相关的 EXIF 字段，不重新编码；stripICC 时一并删除 ICC 配置
+func stripJPEGSelective(b []byte, keepThumb, stripICC bool) ([]byte, error) {
+	segs, rest, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	out := segs[:0]
+	for _, s := range segs {
+		if stripICC && isICCSegment(s) {
+			continue
+		}
+		if s.marker == markerAPP1 {
+			switch {
+			case bytes.HasPrefix(s.data, exifHeader):
+				d := append([]byte(nil), s.data...)
+				if err := scrubExifTIFF(d[len(exifHeader):], keepThumb); err != nil {
+					return nil, err
+				}
+				s.data = d
+			case bytes.HasPrefix(s.data, xmpHeader):
+				// XMP 中往往有 GPS/设备信息的副本，逐项编辑代价高，直接整段丢弃
+				continue
+			}
+		}
+		out = append(out, s)
+	}
+	return joinJPEG(out, rest)
+}
+
+// —— EXIF（TIFF 结构）原地编辑 ——
+// 删除条目时只在 IFD 内部前移其余条目并把被删除的外置数据清零，
+// 不改变任何偏移量，因此无需重新排布整个 EXIF 块。
+
+const (
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagJPEGInterchange    = 0x0201
+	tagJPEGInterchangeLen = 0x0202
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagMakerNote          = 0x927C // 厂商私有数据，常含机身序列号
+	tagCameraOwnerName    = 0xA430
+	tagBodySerialNumber   = 0xA431
+	tagLensSerialNumber   = 0xA435
+)
+
+// TIFF 字段类型对应的单个值字节数
+var tiffTypeSize = map[uint16]int{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4,
+}
+
+type tiffBlock struct {
+	b  []byte
+	bo binary.ByteOrder
+}
+
+func newTIFFBlock(b []byte) (*tiffBlock, uint32, error) {
+	if len(b) < 8 {
+		return nil, 0, errors.New("EXIF 数据过短")
+	}
+	t := &tiffBlock{b: b}
+	switch string(b[:2]) {
+	case "II":
+		t.bo = binary.LittleEndian
+	case "MM":
+		t.bo = binary.BigEndian
+	default:
+		return nil, 0, errors.New("EXIF 字节序标记非法")
+	}
+	if t.bo.Uint16(b[2:4]) != 42 {
+		return nil, 0, errors.New("EXIF 缺少 TIFF 魔数")
+	}
+	return t, t.bo.Uint32(b[4:8]), nil
+}
+
+// entries 返回 IFD 的条目数，并校验整个 IFD（含 next 指针）都在范围内
+func (t *tiffBlock) entries(off uint32) (int, error) {
+	if int(off)+2 > len(t.b) {
+		return 0, fmt.Errorf("IFD 偏移越界: %d", off)
+	}
+	n := int(t.bo.Uint16(t.b[off : off+2]))
+	if int(off)+2+n*12+4 > len(t.b) {
+		return 0, fmt.Errorf("IFD 条目越界: %d", off)
+	}
+	return n, nil
+}
+
+func (t *tiffBlock) entryPos(off uint32, i int) int { return int(off) + 2 + i*12 }
+
+func (t *tiffBlock) tag(pos int) uint16 { return t.bo.Uint16(t.b[pos : pos+2]) }
+
+func (t *tiffBlock) value32(pos int) uint32 { return t.bo.Uint32(t.b[pos+8 : pos+12]) }
+
+// clearValue 将条目存放在 IFD 之外的数据清零
+func (t *tiffBlock) clearValue(pos int) {
+	size := tiffTypeSize[t.bo.Uint16(t.b[pos+2:pos+4])] * int(t.bo.Uint32(t.b[pos+4:pos+8]))
+	if size <= 4 {
+		return
+	}
+	start := int(t.value32(pos))
+	if start < 0 || start+size > len(t.b) {
+		return
+	}
+	clear(t.b[start : start+size])
+}
+
+// find 返回指定标签的条目位置，不存在时返回 -1
+func (t *tiffBlock) find(off uint32, tag uint16) int {
+	n, err := t.entries(off)
+	if err != nil {
+		return -1
+	}
+	for i := 0; i < n; i++ {
+		if p := t.entryPos(off, i); t.tag(p) == tag {
+			return p
+		}
+	}
+	return -1
+}
+
+// remove 删除 IFD 中满足 drop 的条目，其余条目保持原顺序
+func (t *tiffBlock) remove(off uint32, drop func(tag uint16) bool) error {
+	n, err := t.entries(off)
+	if err != nil {
+		return err
+	}
+	next := t.b[t.entryPos(off, n) : t.entryPos(off, n)+4]
+	var kept [][]byte
+	for i := 0; i < n; i++ {
+		p := t.entryPos(off, i)
+		if drop(t.tag(p)) {
+			t.clearValue(p)
+			continue
+		}
+		kept = append(kept, append([]byte(nil), t.b[p:p+12]...))
+	}
+	nextPtr := append([]byte(nil), next...)
+	end := t.entryPos(off, n) + 4
+	clear(t.b[off:end])
+	t.bo.PutUint16(t.b[off:off+2], uint16(len(kept)))
+	for i, e := range kept {
+		copy(t.b[t.entryPos(off, i):], e)
+	}
+	copy(t.b[t.entryPos(off, len(kept)):], nextPtr)
+	return nil
+}
+
+// wipe 清零整个 IFD 及其外置数据
+func (t *tiffBlock) wipe(off uint32) error {
+	n, err := t.entries(off)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		t.clearValue(t.entryPos(off, i))
+	}
+	clear(t.b[off : t.entryPos(off, n)+4])
+	return nil
+}
+
+// scrubExifTIFF 删除 GPS IFD、拍摄时间、设备型号与序列号；keepThumb 为 false 时同时删除缩略图（IFD1）
+func scrubExifTIFF(b []byte, keepThumb bool) error {
+	t, ifd0, err := newTIFFBlock(b)
+	if err != nil {
+		return err
+	}
+	n0, err := t.entries(ifd0)
+	if err != nil {
+		return err
+	}
+
+	if p := t.find(ifd0, tagGPSIFD); p >= 0 {
+		if err := t.wipe(t.value32(p)); err != nil {
+			return fmt.Errorf("GPS IFD: %w", err)
+		}
+	}
+	if p := t.find(ifd0, tagExifIFD); p >= 0 {
+		err := t.remove(t.value32(p), func(tag uint16) bool {
+			switch tag {
+			case tagDateTimeOriginal, tagMakerNote, tagCameraOwnerName, tagBodySerialNumber, tagLensSerialNumber:
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return fmt.Errorf("Exif IFD: %w", err)
+		}
+	}
+
+	if !keepThumb {
+		nextPos := t.entryPos(ifd0, n0)
+		if ifd1 := t.bo.Uint32(b[nextPos : nextPos+4]); ifd1 != 0 {
+			// 缩略图本身是一张带独立元数据的小 JPEG
+			if p := t.find(ifd1, tagJPEGInterchange); p >= 0 {
+				if q := t.find(ifd1, tagJPEGInterchangeLen); q >= 0 {
+					start, size := int(t.value32(p)), int(t.value32(q))
+					if start >= 0 && size >= 0 && start+size <= len(b) {
+						clear(b[start : start+size])
+					}
+				}
+			}
+			if err := t.wipe(ifd1); err != nil {
+				return fmt.Errorf("IFD1: %w", err)
+			}
+			t.bo.PutUint32(b[nextPos:nextPos+4], 0)
+		}
+	}
+
+	return t.remove(ifd0, func(tag uint16) bool {
+		return tag == tagMake || tag == tagModel || tag == tagGPSIFD
+	})
+}
+
+// —— 根据 DQT 估算源 JPEG 的质量（IJG 缩放公式的逆运算）——
+
+const markerDQT = 0xDB
+
+// IJG 标准亮度量化表（zigzag 顺序，与 DQT 段内顺序一致）
+var stdLumaQuant = [64]int{
+	16, 11, 12, 14, 12, 10, 16, 14,
+	13, 14, 18, 17, 16, 19, 24, 40,
+	26, 24, 22, 22, 24, 49, 35, 37,
+	29, 40, 58, 51, 61, 60, 57, 51,
+	56, 55, 64, 72, 92, 78, 64, 68,
+	87, 69, 55, 56, 80, 109, 81, 87,
+	95, 98, 103, 104, 103, 62, 77, 113,
+	121, 112, 100, 120, 92, 101, 103, 99,
+}
+
+// estimateJPEGQuality 用亮度量化表相对标准表的平均缩放比反推质量（1-100）
+func estimateJPEGQuality(b []byte) (int, error) {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range segs {
+		if s.marker != markerDQT {
+			continue
+		}
+		d := s.data
+		for len(d) > 0 {
+			precision, id := d[0]>>4, d[0]&0x0F
+			size := 64 * (1 + int(precision))
+			if len(d) < 1+size {
+				return 0, errors.New("DQT 段被截断")
+			}
+			if id == 0 {
+				sum := 0.0
+				for i := 0; i < 64; i++ {
+					v := int(d[1+i])
+					if precision == 1 {
+						v = int(binary.BigEndian.Uint16(d[1+2*i:]))
+					}
+					sum += float64(v) * 100 / float64(stdLumaQuant[i])
+				}
+				scale := sum / 64
+				var q float64
+				if scale <= 100 {
+					q = (200 - scale) / 2
+				} else {
+					q = 5000 / scale
+				}
+				return min(100, max(1, int(q+0.5))), nil
+			}
+			d = d[1+size:]
+		}
+	}
+	return 0, errors.New("未找到亮度量化表")
+}
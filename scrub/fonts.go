@@ -0,0 +1,240 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// —— 嵌入字体的 name 表（仅检查）——
+// Word 可把字体嵌入 word/fonts/（.odttf：前 32 字节按 fontTable.xml 中该字体的 w:fontKey 混淆），
+// PowerPoint 嵌入到 ppt/fonts/（.fntdata）。自行制作或经工具子集化的字体，常在 name 表的
+// 版权、唯一标识、厂商与设计者字段中留下个人姓名、用户名或机器信息，多数工具都会忽略这一处。
+// 删除或改写字体会影响排版，目前只在 dry-run（Inspect）中列出这些字段供人工判断，处理时不做修改。
+
+// fontNameIDs 为值得展示的 name 表字段
+var fontNameIDs = map[uint16]string{
+	0: "版权",
+	3: "唯一标识",
+	8: "厂商",
+	9: "设计者",
+}
+
+// maxFontSize 为解析的嵌入字体大小上限，超过时只报告字体存在
+const maxFontSize = 32 << 20
+
+// isEmbeddedFont 判断条目是否为嵌入字体（name 为小写）
+func isEmbeddedFont(lower string) bool {
+	return (strings.HasPrefix(lower, "word/fonts/") || strings.HasPrefix(lower, "ppt/fonts/")) &&
+		!strings.HasSuffix(lower, "/")
+}
+
+// inspectEmbeddedFonts 列出嵌入字体 name 表中的可疑字段；无法解析的字体只报告其存在
+func inspectEmbeddedFonts(path string) ([]Finding, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	keys, err := odttfKeys(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for _, zf := range zr.File {
+		lower := strings.ToLower(zf.Name)
+		if !isEmbeddedFont(lower) {
+			continue
+		}
+		item := "嵌入字体（仅提示）" + zf.Name
+		if zf.UncompressedSize64 > maxFontSize {
+			fs = append(fs, Finding{Item: item, Value: "（过大，未检查）"})
+			continue
+		}
+		data, err := readZipEntry(zf)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := keys[lower]; ok {
+			deobfuscateODTTF(data, key)
+		}
+		names, err := sfntNames(data)
+		if err != nil {
+			fs = append(fs, Finding{Item: item, Value: "（无法解析: " + err.Error() + "）"})
+			continue
+		}
+		for _, id := range []uint16{0, 3, 8, 9} {
+			if v := strings.TrimSpace(names[id]); v != "" {
+				fs = append(fs, Finding{Item: item + " " + fontNameIDs[id], Value: v})
+			}
+		}
+	}
+	return fs, nil
+}
+
+// readZipEntry 读出条目的全部内容
+func readZipEntry(zf *zip.File) ([]byte, error) {
+	r, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// odttfKeys 读取 word/fontTable.xml 中各嵌入字体的 w:fontKey，返回 小写部件名 -> 16 字节密钥
+func odttfKeys(zr *zip.Reader) (map[string][]byte, error) {
+	targets := map[string]string{} // 关系 Id -> 部件名
+	keys := map[string][]byte{}
+	for _, name := range []string{"word/_rels/fontTable.xml.rels", "word/fontTable.xml"} {
+		f, err := zr.Open(name)
+		if err != nil {
+			return keys, nil // 没有嵌入 Word 字体
+		}
+		err = rewriteXML(f, io.Discard, func(el *xml.StartElement) {
+			switch {
+			case el.Name.Local == "Relationship":
+				targets[xmlAttr(*el, "Id")] = resolveTarget(relsBase(name), xmlAttr(*el, "Target"))
+			case strings.HasPrefix(el.Name.Local, "embed"):
+				// w:embedRegular / w:embedBold 等：r:id 指向字体部件
+				var id string
+				for _, a := range el.Attr {
+					if a.Name.Local == "id" && a.Name.Space != "" {
+						id = a.Value
+					}
+				}
+				if key := fontKey(xmlAttr(*el, "fontKey")); key != nil && targets[id] != "" {
+					keys[strings.ToLower(targets[id])] = key
+				}
+			}
+		})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", name, err)
+		}
+	}
+	return keys, nil
+}
+
+// fontKey 将 {GUID} 形式的 w:fontKey 转换为混淆密钥：十六进制数字按字节逆序
+func fontKey(guid string) []byte {
+	b, err := hex.DecodeString(strings.NewReplacer("{", "", "}", "", "-", "").Replace(guid))
+	if err != nil || len(b) != 16 {
+		return nil
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// deobfuscateODTTF 还原 .odttf 的前 32 字节（ECMA-376 第 17.8.1 节）
+func deobfuscateODTTF(data, key []byte) {
+	for i := 0; i < 32 && i < len(data); i++ {
+		data[i] ^= key[i%16]
+	}
+}
+
+// sfntNames 读取 TrueType/OpenType 字体 name 表中 fontNameIDs 列出的字段；
+// 优先取 Windows 平台（UTF-16BE）的美式英语记录，其次任意 Windows 记录，最后 Mac Roman
+func sfntNames(data []byte) (map[uint16]string, error) {
+	if len(data) < 12 {
+		return nil, errors.New("文件过短")
+	}
+	base := 0
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	case "ttcf":
+		// 字体集合：取第一个字体
+		if len(data) < 16 {
+			return nil, errors.New("字体集合头不完整")
+		}
+		base = int(binary.BigEndian.Uint32(data[12:16]))
+		if base+12 > len(data) {
+			return nil, errors.New("字体集合偏移越界")
+		}
+	default:
+		return nil, errors.New("不是 TrueType/OpenType 字体")
+	}
+	n := int(binary.BigEndian.Uint16(data[base+4 : base+6]))
+	var table []byte
+	for i := 0; i < n; i++ {
+		rec := base + 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errors.New("表目录越界")
+		}
+		if string(data[rec:rec+4]) != "name" {
+			continue
+		}
+		off, size := int(binary.BigEndian.Uint32(data[rec+8:rec+12])), int(binary.BigEndian.Uint32(data[rec+12:rec+16]))
+		if off < 0 || size < 6 || off+size > len(data) {
+			return nil, errors.New("name 表越界")
+		}
+		table = data[off : off+size]
+	}
+	if table == nil {
+		return nil, errors.New("没有 name 表")
+	}
+
+	count := int(binary.BigEndian.Uint16(table[2:4]))
+	strs := int(binary.BigEndian.Uint16(table[4:6]))
+	names := map[uint16]string{}
+	rank := map[uint16]int{} // 已取记录的优先级，数值越大越优先
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[rec:])
+		lang := binary.BigEndian.Uint16(table[rec+4:])
+		id := binary.BigEndian.Uint16(table[rec+6:])
+		length := int(binary.BigEndian.Uint16(table[rec+8:]))
+		off := strs + int(binary.BigEndian.Uint16(table[rec+10:]))
+		if _, ok := fontNameIDs[id]; !ok || off+length > len(table) {
+			continue
+		}
+		raw := table[off : off+length]
+		var r int
+		var v string
+		switch platform {
+		case 3:
+			r = 2
+			if lang == 0x409 {
+				r = 3
+			}
+			v = decodeUTF16BE(raw)
+		case 1:
+			r = 1
+			v = string(latin1(raw))
+		default:
+			continue
+		}
+		if r > rank[id] {
+			rank[id], names[id] = r, v
+		}
+	}
+	return names, nil
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// latin1 把单字节编码的字符串按 Latin-1 转换为 rune（Mac Roman 的 ASCII 部分与之相同）
+func latin1(b []byte) []rune {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return r
+}
@@ -0,0 +1,126 @@
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// —— --path 通配符 ——
+// 支持 filepath.Match 语法（* ? [...]），另外 ** 匹配任意层目录（含零层），
+// 例如 reports/**/*.docx。从不含通配符的前缀目录开始遍历，因此该目录同时作为输出根目录。
+
+// IsGlob 判断 p 是否包含通配符
+func IsGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// CollectGlob 展开通配符模式，返回遍历的起始目录与匹配到的受支持文件（同样按 include/exclude 过滤）
+func (s *Scrubber) CollectGlob(pattern string) (root string, files []string, err error) {
+	segs := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	i := 0
+	for i < len(segs)-1 && !IsGlob(segs[i]) {
+		i++
+	}
+	root = filepath.FromSlash(strings.Join(segs[:i], "/"))
+	switch {
+	case root == "" && strings.HasPrefix(pattern, "/"):
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	for _, seg := range segs[i:] {
+		if _, err := path.Match(seg, ""); err != nil {
+			return "", nil, fmt.Errorf("通配符模式非法: %s", pattern)
+		}
+	}
+
+	pat := segs[i:]
+	deep := false
+	for _, seg := range pat {
+		deep = deep || seg == "**"
+	}
+	err = s.walkFiles(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		name := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			// 没有 ** 时模式层数固定，更深的目录不可能匹配
+			if !deep && len(name) >= len(pat) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchGlob(pat, name) && s.accept(p, rel, d) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("遍历目录失败: %w", err)
+	}
+	return root, files, nil
+}
+
+// matchGlob 逐段匹配，** 可吞掉任意多段
+func matchGlob(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for k := 0; k <= len(name); k++ {
+				if matchGlob(pat[1:], name[k:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// —— --include-glob / --exclude-glob：按相对路径过滤 ——
+// 模式不含 / 时与文件名比较（如 *_draft.docx），含 / 时与相对输入根目录的完整路径比较，
+// 语法同 --path 通配符，** 匹配任意层目录（如 2023-*/invoices/*.pdf、**/templates/**）。
+// 与扩展名过滤同时生效（AND）：文件须同时通过扩展名与路径两类过滤；每一类中排除优先于包含。
+
+// checkPathGlobs 按路径过滤判断 rel（相对输入根目录的路径），不处理时返回原因
+func (s *Scrubber) checkPathGlobs(rel string) error {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	for _, pat := range s.ExcludeGlobs {
+		if matchPathGlob(pat, rel) {
+			return fmt.Errorf("匹配 exclude-glob %s: %s", pat, rel)
+		}
+	}
+	if len(s.IncludeGlobs) == 0 {
+		return nil
+	}
+	for _, pat := range s.IncludeGlobs {
+		if matchPathGlob(pat, rel) {
+			return nil
+		}
+	}
+	return fmt.Errorf("不匹配 include-glob: %s", rel)
+}
+
+// matchPathGlob 判断 rel（/ 分隔）是否匹配 pat
+func matchPathGlob(pat, rel string) bool {
+	pat = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pat)), "./")
+	if !strings.Contains(pat, "/") {
+		ok, _ := path.Match(pat, path.Base(rel))
+		return ok
+	}
+	return matchGlob(strings.Split(pat, "/"), strings.Split(rel, "/"))
+}
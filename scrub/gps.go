@@ -0,0 +1,199 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// —— 只删除位置信息（StripMode = "gps"）——
+// 很多人分享照片时只担心泄露拍摄地点，拍摄时间、机型、方向与色彩配置都希望保留。
+// 该模式直接编辑 EXIF，不重新编码：清零并摘除 IFD0 中的 GPS IFD；
+// XMP 中往往有一份 exif:GPSLatitude 等的副本，删除本地名以 GPS 开头的元素与属性，其余保留。
+// JPEG 与 TIFF 支持这种精确删除；其他格式回退为完整脱敏并输出警告。
+
+const tagXMLPacket = 700 // TIFF 中存放 XMP 的字段
+
+// removeGPSIFD 清零 GPS IFD 并从 IFD0 中摘除指向它的条目，没有 GPS 时什么也不做
+func removeGPSIFD(t *tiffBlock, ifd0 uint32) error {
+	p := t.find(ifd0, tagGPSIFD)
+	if p < 0 {
+		return nil
+	}
+	if err := t.wipe(t.value32(p)); err != nil {
+		return err
+	}
+	return t.remove(ifd0, func(tag uint16) bool { return tag == tagGPSIFD })
+}
+
+// stripJPEGGPS 只删除 JPEG 中 EXIF 与 XMP 里的位置信息，扫描数据与其余段原样保留
+func stripJPEGGPS(b []byte) ([]byte, error) {
+	segs, rest, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range segs {
+		if s.marker != markerAPP1 {
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(s.data, exifHeader):
+			d := append([]byte(nil), s.data...)
+			t, ifd0, err := newTIFFBlock(d[len(exifHeader):])
+			if err != nil {
+				return nil, err
+			}
+			if err := removeGPSIFD(t, ifd0); err != nil {
+				return nil, err
+			}
+			segs[i].data = d
+		case bytes.HasPrefix(s.data, xmpHeader):
+			x, err := stripXMPGPS(s.data[len(xmpHeader):])
+			if err != nil {
+				return nil, err
+			}
+			segs[i].data = append(append([]byte(nil), xmpHeader...), x...)
+		}
+	}
+	return joinJPEG(segs, rest)
+}
+
+// stripTIFFGPS 只删除 TIFF 首个 IFD 中的 GPS IFD 与 XMP 里的位置信息。
+// 偏移量均不改变：XMP 删减后以空格补足原长度（XMP 规范允许包尾的空白填充）
+func stripTIFFGPS(b []byte) ([]byte, error) {
+	out := append([]byte(nil), b...)
+	t, ifd0, err := newTIFFBlock(out)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeGPSIFD(t, ifd0); err != nil {
+		return nil, err
+	}
+	if p := t.find(ifd0, tagXMLPacket); p >= 0 {
+		n := int(t.bo.Uint32(t.b[p+4 : p+8]))
+		start := int(t.value32(p))
+		if n > 4 && start >= 0 && start+n <= len(out) {
+			packet := out[start : start+n]
+			x, err := stripXMPGPS(packet)
+			if err != nil {
+				return nil, err
+			}
+			if len(x) > n {
+				return nil, errors.New("XMP 改写后变长，无法原位写回")
+			}
+			copy(packet, x)
+			for i := len(x); i < n; i++ {
+				packet[i] = ' '
+			}
+		}
+	}
+	return out, nil
+}
+
+// isGPSName 判断 XMP 属性名是否属于位置信息（exif:GPSLatitude、exif:GPSTimeStamp 等）
+func isGPSName(n xml.Name) bool {
+	return strings.HasPrefix(n.Local, "GPS")
+}
+
+// stripXMPGPS 删除 XMP 包中本地名以 GPS 开头的元素与属性；没有位置信息时原样返回
+func stripXMPGPS(packet []byte) ([]byte, error) {
+	if !xmpHasGPS(packet) {
+		return packet, nil
+	}
+	var buf bytes.Buffer
+	err := filterXML(bytes.NewReader(packet), &buf,
+		func(el xml.StartElement) bool { return isGPSName(el.Name) },
+		func(el *xml.StartElement) {
+			attrs := el.Attr[:0]
+			for _, a := range el.Attr {
+				if !isGPSName(a.Name) {
+					attrs = append(attrs, a)
+				}
+			}
+			el.Attr = attrs
+		})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmpHasGPS 判断 XMP 包中是否有位置信息元素或属性；无法解析时按有处理
+func xmpHasGPS(packet []byte) bool {
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return false
+		}
+		if err != nil {
+			return true
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			if isGPSName(el.Name) {
+				return true
+			}
+			for _, a := range el.Attr {
+				if isGPSName(a.Name) {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// tiffHasGPS 判断 TIFF/EXIF 结构的 IFD0 中是否有 GPS IFD
+func tiffHasGPS(b []byte) bool {
+	t, ifd0, err := newTIFFBlock(b)
+	return err == nil && t.find(ifd0, tagGPSIFD) >= 0
+}
+
+// inspectGPS 列出 gps 模式下将被删除的位置信息（JPEG 或 TIFF 数据）
+func inspectGPS(b []byte, ext string) ([]Finding, error) {
+	var fs []Finding
+	if ext == ".tif" || ext == ".tiff" {
+		t, ifd0, err := newTIFFBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		if t.find(ifd0, tagGPSIFD) >= 0 {
+			fs = append(fs, Finding{Item: "EXIF GPS"})
+		}
+		if p := t.find(ifd0, tagXMLPacket); p >= 0 {
+			n, start := int(t.bo.Uint32(t.b[p+4:p+8])), int(t.value32(p))
+			if n > 4 && start >= 0 && start+n <= len(b) && xmpHasGPS(b[start:start+n]) {
+				fs = append(fs, Finding{Item: "XMP GPS"})
+			}
+		}
+		return fs, nil
+	}
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segs {
+		switch {
+		case seg.marker != markerAPP1:
+		case bytes.HasPrefix(seg.data, exifHeader) && tiffHasGPS(seg.data[len(exifHeader):]):
+			fs = append(fs, Finding{Item: "EXIF GPS"})
+		case bytes.HasPrefix(seg.data, xmpHeader) && xmpHasGPS(seg.data[len(xmpHeader):]):
+			fs = append(fs, Finding{Item: "XMP GPS"})
+		}
+	}
+	return fs, nil
+}
+
+// verifyNoGPS 检查 JPEG 或 TIFF 数据中不再有位置信息
+func verifyNoGPS(b []byte, ext string) error {
+	fs, err := inspectGPS(b, ext)
+	if err != nil {
+		return err
+	}
+	if len(fs) > 0 {
+		return fmt.Errorf("仍包含位置信息: %s", fs[0].Item)
+	}
+	return nil
+}
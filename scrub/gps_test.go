@@ -0,0 +1,78 @@
+package scrub
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// jpegExif 返回 JPEG 中 EXIF 段的 TIFF 结构与 IFD0 偏移
+func jpegExif(t *testing.T, b []byte) (*tiffBlock, uint32) {
+	t.Helper()
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range segs {
+		if s.marker == markerAPP1 && bytes.HasPrefix(s.data, exifHeader) {
+			tb, ifd0, err := newTIFFBlock(s.data[len(exifHeader):])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tb, ifd0
+		}
+	}
+	t.Fatal("没有 EXIF 段")
+	return nil, 0
+}
+
+func TestGPSOnlyKeepsCaptureTime(t *testing.T) {
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:xmp="http://ns.adobe.com/xap/1.0/"` +
+		` exif:GPSLatitude="31,14.5N" xmp:CreateDate="2023-04-05T06:07:08"/></rdf:RDF></x:xmpmeta>`
+	in := testJPEG(t, testImage(16, 16), 90,
+		exifSeg(
+			[]tiffEntry{
+				tiffASCII(tagMake, "SecretCam"),
+				{tag: tagExifIFD, typ: 4, count: 1, sub: 1},
+				{tag: tagGPSIFD, typ: 4, count: 1, sub: 2},
+			},
+			[]tiffEntry{tiffASCII(tagDateTimeOriginal, "2023:04:05 06:07:08")},
+			[]tiffEntry{tiffASCII(1, "N"), tiffASCII(0x1B, "GPS-METHOD-SECRET")},
+		),
+		jpegSeg(markerAPP1, append(bytes.Clone(xmpHeader), xmp...)),
+	)
+	p := writeTestFile(t, t.TempDir(), "a.jpg", in)
+	s := newTestScrubber()
+	s.StripMode = "gps"
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tb, ifd0 := jpegExif(t, out)
+	if tb.find(ifd0, tagGPSIFD) >= 0 {
+		t.Error("IFD0 仍指向 GPS IFD")
+	}
+	for _, s := range []string{"GPS-METHOD-SECRET", "GPSLatitude", "31,14.5N"} {
+		if bytes.Contains(out, []byte(s)) {
+			t.Errorf("输出仍含有 %q", s)
+		}
+	}
+	exif := tb.find(ifd0, tagExifIFD)
+	if exif < 0 || tb.find(tb.value32(exif), tagDateTimeOriginal) < 0 {
+		t.Fatal("DateTimeOriginal 应保留")
+	}
+	for _, s := range []string{"2023:04:05 06:07:08", "SecretCam", "2023-04-05T06:07:08"} {
+		if !bytes.Contains(out, []byte(s)) {
+			t.Errorf("输出缺少应保留的 %q", s)
+		}
+	}
+	_, inScan, _ := splitJPEG(in)
+	if _, outScan, _ := splitJPEG(out); !bytes.Equal(inScan, outScan) {
+		t.Error("扫描数据应逐字节不变")
+	}
+}
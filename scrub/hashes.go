@@ -0,0 +1,56 @@
+package scrub
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// —— 输出文件哈希清单（HashOutputs）——
+// 供下游系统核对脱敏产物的完整性。哈希在各 worker 中随处理一并计算（并发），
+// 清单在全部处理结束后由 WriteHashManifest 一次写出，按输出路径排序，同样的结果总是得到同样的清单。
+// 格式与 sha256sum 兼容，可直接用 sha256sum -c 校验；原文件的哈希写在对应行之前的注释行中
+// （sha256sum 会忽略 # 开头的行）：
+//
+//	# original 9f86d08…  报告/年报.docx
+//	3a7bd3e…  报告/年报.docx
+
+// fileSHA256 流式计算文件的 SHA-256，返回十六进制字符串
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteHashManifest 将成功处理的文件的输出哈希写入 path，返回写出的条目数
+func (r Report) WriteHashManifest(path string) (int, error) {
+	var rows []FileResult
+	for _, res := range r.Results {
+		if res.Status == StatusOK && res.SHA256 != "" {
+			rows = append(rows, res)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Output < rows[j].Output })
+
+	var buf bytes.Buffer
+	for _, res := range rows {
+		name := filepath.ToSlash(res.Output)
+		if res.OrigSHA256 != "" {
+			fmt.Fprintf(&buf, "# original %s  %s\n", res.OrigSHA256, name)
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", res.SHA256, name)
+	}
+	return len(rows), os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,87 @@
+package scrub
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// —— HEIC/HEIF：解码后重新编码为 JPEG ——
+// 纯 Go 生态没有 HEIF 编码器，因此输出格式会变为 JPEG：photo.heic -> photo.jpg。
+// 解码依赖 cgo（github.com/jdeng/goheif），需要以 -tags withheic 构建，见 heic_goheif.go；
+// 默认构建使用 heic_stub.go，遇到 HEIC 时返回明确的错误。
+
+var heicSet = map[string]bool{
+	".heic": true, ".heif": true,
+}
+
+func init() {
+	registerSet(heicSet, handlerFuncs{
+		kind: "heic",
+		scrub: func(s *Scrubber, p, dst, _ string) error {
+			if !s.WithHEIC {
+				return errors.New("检测到 HEIC，请使用 --with-heic 以启用 HEIC 脱敏（需要以 -tags withheic 构建）")
+			}
+			return s.scrubHEIC(p, dst)
+		},
+		inspect: (*Scrubber).inspectImage,
+		verify:  (*Scrubber).verifyImage,
+		info: HandlerInfo{
+			Method:  "解码后重新编码为 JPEG（goheif，需要 cgo）",
+			Removes: "EXIF、XMP 等全部非像素数据；输出扩展名变为 .jpg",
+			Flag:    "--with-heic", BuildTag: "withheic", Built: heicBuilt,
+		},
+	})
+}
+
+func (s *Scrubber) scrubHEIC(path, dst string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	img, err := decodeHEIC(bufio.NewReader(in))
+	in.Close()
+	if err != nil {
+		return fmt.Errorf("HEIC 解码失败: %w", err)
+	}
+
+	jpgDst := heicOutput(dst)
+	if _, err := os.Stat(jpgDst); err == nil {
+		return fmt.Errorf("目标文件已存在，拒绝覆盖: %s", jpgDst)
+	}
+
+	q := s.JPEGQuality
+	if q == 0 {
+		q = 95
+	}
+	// 先编码到内存：编码成功后才备份原文件
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q}); err != nil {
+		return err
+	}
+
+	if dst != path {
+		// 输出到独立目录：原文件保持不动
+		return s.writeReplace(path, jpgDst, buf.Bytes())
+	}
+	// 原地处理：先备份 .heic，写出 .jpg 后删除原文件
+	if s.Backup {
+		if err := s.makeBackup(path); err != nil {
+			return err
+		}
+	}
+	if err := s.writeReplace(path, jpgDst, buf.Bytes()); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// heicOutput 返回 HEIC 转码后的输出路径：photo.heic -> photo.jpg
+func heicOutput(dst string) string {
+	return strings.TrimSuffix(dst, filepath.Ext(dst)) + ".jpg"
+}
@@ -0,0 +1,16 @@
+//go:build withheic
+
+package scrub
+
+import (
+	"image"
+	"io"
+
+	"github.com/jdeng/goheif"
+)
+
+const heicBuilt = true
+
+func decodeHEIC(r io.Reader) (image.Image, error) {
+	return goheif.Decode(r)
+}
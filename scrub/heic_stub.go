@@ -0,0 +1,16 @@
+//go:build !withheic
+
+package scrub
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// heicBuilt 表示当前构建是否包含 HEIC 解码器
+const heicBuilt = false
+
+func decodeHEIC(r io.Reader) (image.Image, error) {
+	return nil, errors.New("未编译 HEIC 支持：请使用 go build -tags withheic 重新构建（需要 cgo）")
+}
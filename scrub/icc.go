@@ -0,0 +1,91 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// —— ICC 色彩配置 ——
+// 重新编码会丢掉源文件中的 ICC 配置，导致色彩管理流程中颜色偏移；
+// 默认从源文件取出配置原样嵌回输出，--strip-icc 时则一并删除
+// （配置中可能含有设备型号、创建者等字符串）。
+
+const markerAPP2 = 0xE2
+
+var iccHeader = []byte("ICC_PROFILE\x00")
+
+func isICCSegment(s jpegSegment) bool {
+	return s.marker == markerAPP2 && bytes.HasPrefix(s.data, iccHeader)
+}
+
+// jpegICC 返回源 JPEG 中的 ICC 段（较大的配置会拆成多个 APP2 段，按原顺序返回）
+func jpegICC(b []byte) []jpegSegment {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return nil
+	}
+	var icc []jpegSegment
+	for _, s := range segs {
+		if isICCSegment(s) {
+			icc = append(icc, s)
+		}
+	}
+	return icc
+}
+
+// embedJPEGICC 把 ICC 段插入重新编码后的 JPEG（紧跟 JFIF APP0 之后）
+func embedJPEGICC(b []byte, icc []jpegSegment) ([]byte, error) {
+	if len(icc) == 0 {
+		return b, nil
+	}
+	segs, rest, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	at := 0
+	if len(segs) > 0 && segs[0].marker == 0xE0 {
+		at = 1
+	}
+	out := make([]jpegSegment, 0, len(segs)+len(icc))
+	out = append(out, segs[:at]...)
+	out = append(out, icc...)
+	out = append(out, segs[at:]...)
+	return joinJPEG(out, rest)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngICC 返回源 PNG 中完整的 iCCP 块（含长度、类型与 CRC），没有时返回 nil
+func pngICC(b []byte) []byte {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil
+	}
+	for p := len(pngSignature); p+8 <= len(b); {
+		n := int(binary.BigEndian.Uint32(b[p : p+4]))
+		end := p + 12 + n
+		if n < 0 || end > len(b) {
+			return nil
+		}
+		switch string(b[p+4 : p+8]) {
+		case "iCCP":
+			return b[p:end]
+		case "IDAT":
+			// iCCP 只能出现在图像数据之前
+			return nil
+		}
+		p = end
+	}
+	return nil
+}
+
+// embedPNGICC 把 iCCP 块插入重新编码后的 PNG（紧跟 IHDR 之后，满足“位于 PLTE/IDAT 之前”的要求）
+func embedPNGICC(b, chunk []byte) []byte {
+	const ihdrEnd = 8 + 12 + 13 // 签名 + IHDR 块
+	if chunk == nil || len(b) < ihdrEnd || !bytes.HasPrefix(b, pngSignature) {
+		return b
+	}
+	out := make([]byte, 0, len(b)+len(chunk))
+	out = append(out, b[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, b[ihdrEnd:]...)
+}
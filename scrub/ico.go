@@ -0,0 +1,170 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// —— ICO：逐个尺寸删除 PNG 元数据后重新拼装 ——
+// .ico 由图标目录（6 字节头 + 每个尺寸一个 16 字节的目录项）与各尺寸的图像数据组成，
+// 图像数据要么是完整的 PNG（常见于 256×256），要么是不带文件头的 DIB（BITMAPINFOHEADER + 颜色与透明掩码）。
+// PNG 条目与独立的 PNG 文件一样可能带有 tEXt/iTXt（作者、软件、XMP）、tIME 与 eXIf，按块删除（见 png.go）；
+// DIB 条目只有 40 字节信息头与像素，原样保留。全部尺寸及其在目录中的顺序不变，只重新计算各条目的长度与偏移，
+// 条目之间与文件末尾的多余数据不再写出。
+
+var icoSet = map[string]bool{".ico": true}
+
+const (
+	icoHeaderLen = 6
+	icoEntryLen  = 16
+)
+
+func init() {
+	registerSet(icoSet, handlerFuncs{
+		kind:    "image",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubICO(p, dst) },
+		inspect: func(s *Scrubber, p, _ string) ([]Finding, error) { return s.inspectICO(p) },
+		verify:  func(_ *Scrubber, out, _ string) error { return verifyICO(out) },
+		info:    HandlerInfo{Method: "逐个尺寸按块删除 PNG 元数据，保留全部尺寸", Removes: "PNG 条目中的 tEXt/zTXt/iTXt、tIME、eXIf 块；可选 iCCP"},
+	})
+}
+
+// icoImage 为图标中的一个尺寸：目录项与图像数据
+type icoImage struct {
+	entry []byte
+	data  []byte
+}
+
+// size 返回尺寸说明，如 "256×256"（目录项中 0 表示 256）
+func (im icoImage) size() string {
+	w, h := int(im.entry[0]), int(im.entry[1])
+	if w == 0 {
+		w = 256
+	}
+	if h == 0 {
+		h = 256
+	}
+	return fmt.Sprintf("%d×%d", w, h)
+}
+
+func (im icoImage) isPNG() bool {
+	return bytes.HasPrefix(im.data, pngSignature)
+}
+
+// parseICO 解析图标目录，按目录顺序返回各尺寸
+func parseICO(b []byte) ([]icoImage, error) {
+	if len(b) < icoHeaderLen || binary.LittleEndian.Uint16(b) != 0 || binary.LittleEndian.Uint16(b[2:]) != 1 {
+		return nil, errors.New("不是合法的 ICO 文件")
+	}
+	n := int(binary.LittleEndian.Uint16(b[4:]))
+	if n == 0 || icoHeaderLen+n*icoEntryLen > len(b) {
+		return nil, errors.New("ICO 图标目录被截断")
+	}
+	ims := make([]icoImage, n)
+	for i := range ims {
+		e := b[icoHeaderLen+i*icoEntryLen : icoHeaderLen+(i+1)*icoEntryLen]
+		size := int64(binary.LittleEndian.Uint32(e[8:]))
+		off := int64(binary.LittleEndian.Uint32(e[12:]))
+		if size == 0 || off < int64(icoHeaderLen+n*icoEntryLen) || off+size > int64(len(b)) {
+			return nil, fmt.Errorf("ICO 第 %d 个图像的位置越界", i+1)
+		}
+		ims[i] = icoImage{entry: e, data: b[off : off+size]}
+	}
+	return ims, nil
+}
+
+func (s *Scrubber) scrubICO(path, dst string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := s.cleanICO(data)
+	if err != nil {
+		return err
+	}
+	return s.writeReplace(path, dst, out)
+}
+
+// cleanICO 删除各 PNG 条目中的元数据块，按原顺序重新写出目录与图像数据
+func (s *Scrubber) cleanICO(b []byte) ([]byte, error) {
+	ims, err := parseICO(b)
+	if err != nil {
+		return nil, err
+	}
+	for i, im := range ims {
+		if !im.isPNG() {
+			continue
+		}
+		cleaned, err := stripPNGChunks(im.data, s.StripICC)
+		if err != nil {
+			return nil, fmt.Errorf("ICO 中 %s 的 PNG: %w", im.size(), err)
+		}
+		ims[i].data = cleaned
+	}
+
+	off := icoHeaderLen + len(ims)*icoEntryLen
+	var out bytes.Buffer
+	out.Write(b[:icoHeaderLen])
+	for _, im := range ims {
+		e := bytes.Clone(im.entry)
+		binary.LittleEndian.PutUint32(e[8:], uint32(len(im.data)))
+		binary.LittleEndian.PutUint32(e[12:], uint32(off))
+		out.Write(e)
+		off += len(im.data)
+	}
+	for _, im := range ims {
+		out.Write(im.data)
+	}
+	return out.Bytes(), nil
+}
+
+// inspectICO 列出各 PNG 条目中将被删除的块，条目前加尺寸
+func (s *Scrubber) inspectICO(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ims, err := parseICO(data)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for _, im := range ims {
+		if !im.isPNG() {
+			continue
+		}
+		pf, err := s.inspectPNG(im.data)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range pf {
+			f.Item = im.size() + " " + f.Item
+			fs = append(fs, f)
+		}
+	}
+	return fs, nil
+}
+
+// verifyICO 检查每个 PNG 条目都没有文本与 EXIF 块
+func verifyICO(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ims, err := parseICO(data)
+	if err != nil {
+		return err
+	}
+	for _, im := range ims {
+		if !im.isPNG() {
+			continue
+		}
+		if err := verifyPNG(im.data); err != nil {
+			return fmt.Errorf("%s: %w", im.size(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// buildICO 把各尺寸的图像数据依次写成图标；sizes 为目录项中的宽高（0 表示 256）
+func buildICO(sizes []byte, images ...[]byte) []byte {
+	le := binary.LittleEndian
+	b := le.AppendUint16(nil, 0)
+	b = le.AppendUint16(b, 1)
+	b = le.AppendUint16(b, uint16(len(images)))
+	off := icoHeaderLen + len(images)*icoEntryLen
+	for i, im := range images {
+		b = append(b, sizes[i], sizes[i], 0, 0)
+		b = le.AppendUint16(b, 1)
+		b = le.AppendUint16(b, 32)
+		b = le.AppendUint32(b, uint32(len(im)))
+		b = le.AppendUint32(b, uint32(off))
+		off += len(im)
+	}
+	return append(b, bytes.Join(images, nil)...)
+}
+
+func TestICOPNGEntryTextRemoved(t *testing.T) {
+	dib := append(binary.LittleEndian.AppendUint32(nil, 40), bytes.Repeat([]byte{0x7F}, 16*16*4+64-4)...)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(32, 32)); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+	pngEntry := append(append(bytes.Clone(b[:ihdrEnd]), pngChunk("tEXt", []byte("Author\x00Alice Secret"))...), b[ihdrEnd:]...)
+	in := buildICO([]byte{16, 0}, dib, pngEntry)
+
+	p := writeTestFile(t, t.TempDir(), "favicon.ico", in)
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ims, err := parseICO(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ims) != 2 || ims[0].size() != "16×16" || ims[1].size() != "256×256" {
+		t.Fatalf("输出的尺寸与顺序不符: %d 个", len(ims))
+	}
+	if !bytes.Equal(ims[0].data, dib) {
+		t.Error("DIB 条目应原样保留")
+	}
+	if bytes.Contains(out, []byte("Alice Secret")) {
+		t.Error("PNG 条目仍含有 tEXt")
+	}
+	_, before := pngChunks(t, pngEntry)
+	if _, after := pngChunks(t, ims[1].data); !bytes.Equal(after["IDAT"], before["IDAT"]) {
+		t.Error("PNG 条目的 IDAT 应逐字节不变")
+	}
+	if len(out) != len(in)-len(pngChunk("tEXt", []byte("Author\x00Alice Secret"))) {
+		t.Errorf("输出 %d 字节，与删除 tEXt 后的长度不符", len(out))
+	}
+}
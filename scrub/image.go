@@ -0,0 +1,249 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// 图片：jpeg/jpg 默认在标记段层删除元数据（见 lossless.go），tiff/tif、gif、bmp 通过解码再无元数据重编码；
+// png、webp 在块层删除元数据
+var imageSet = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true,
+	".tif": true, ".tiff": true, ".webp": true,
+	".gif": true, ".bmp": true,
+}
+
+func init() {
+	registerSet(imageSet, handlerFuncs{
+		kind:    "image",
+		scrub:   (*Scrubber).scrubImage,
+		inspect: (*Scrubber).inspectImage,
+		verify:  (*Scrubber).verifyImage,
+		describe: func(ext string) HandlerInfo {
+			switch ext {
+			case ".png":
+				return HandlerInfo{Method: "块级删除，不重新编码", Removes: "tEXt/zTXt/iTXt、tIME、eXIf 块；可选 iCCP"}
+			case ".webp":
+				return HandlerInfo{Method: "RIFF 块级删除，不重新编码", Removes: "EXIF、XMP 块；可选 ICCP"}
+			case ".gif":
+				return HandlerInfo{Method: "解码后重新编码（保留全部帧）", Removes: "注释扩展与 XMP 等应用扩展"}
+			case ".bmp":
+				return HandlerInfo{Method: "解码后重新编码为基本 BMP", Removes: "V4/V5 信息头中的色彩空间与 ICC 配置"}
+			case ".tif", ".tiff":
+				return HandlerInfo{Method: "解码后重新编码（仅首页）；--gps-only 时原位编辑", Removes: "EXIF、GPS、XMP、ICC 等全部非像素数据"}
+			}
+			return HandlerInfo{
+				Method:  "删除元数据段，不重新编码；--strip-mode=full 或指定 --jpeg-quality 时解码后重新编码，selective/gps 时直接编辑 EXIF",
+				Removes: "EXIF（含 GPS、拍摄时间、机型与序列号）、XMP、IPTC/Photoshop（APP13）、注释",
+			}
+		},
+	})
+}
+
+// —— 图片：解码->无元数据重编码 ——
+func (s *Scrubber) scrubImage(path, dst, ext string) error {
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		return s.scrubImageStream(path, in, ext, w)
+	})
+}
+
+// scrubImageStream 读取 r 中的整张图片，把脱敏后的内容写到 w，不涉及文件路径；name 仅用于日志
+func (s *Scrubber) scrubImageStream(name string, r io.Reader, ext string, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	out, err := s.cleanImage(name, data, ext)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// cleanImage 返回图片数据 data 脱敏后的内容，name 仅用于日志；
+// 内嵌在其他格式中的图片（如 iWork 的预览图）也经由它按独立图片的规则处理
+func (s *Scrubber) cleanImage(name string, data []byte, ext string) ([]byte, error) {
+	if s.StripMode == "gps" {
+		var cleaned []byte
+		var err error
+		switch ext {
+		case ".jpg", ".jpeg":
+			cleaned, err = stripJPEGGPS(data)
+		case ".tif", ".tiff":
+			cleaned, err = stripTIFFGPS(data)
+		default:
+			err = fmt.Errorf("不支持只删除 %s 的位置信息", ext)
+		}
+		if err == nil {
+			return cleaned, nil
+		}
+		s.logger().Warnf("%s: 无法只删除位置信息，改为完整脱敏: %v", name, err)
+	}
+
+	if ext == ".webp" {
+		// WebP 无法重新编码，直接在容器层删除元数据块
+		return stripWebP(data, s.StripICC)
+	}
+
+	if ext == ".gif" {
+		return cleanGIF(data)
+	}
+
+	if ext == ".png" {
+		cleaned, err := stripPNGChunks(data, s.StripICC)
+		if err == nil {
+			return cleaned, nil
+		}
+		// 块结构损坏时回退为解码后重新编码
+		s.logger().Warnf("%s: PNG 块解析失败，回退为重新编码: %v", name, err)
+	}
+
+	if s.jpegMode() == "lossless" && (ext == ".jpg" || ext == ".jpeg") {
+		cleaned, err := stripJPEGLossless(data, s.StripICC)
+		if err == nil {
+			return cleaned, nil
+		}
+		// 段结构损坏（如缺少 EOI）时回退为重新编码
+		s.logger().Warnf("%s: JPEG 段解析失败，回退为重新编码: %v", name, err)
+	}
+
+	if s.StripMode == "selective" && (ext == ".jpg" || ext == ".jpeg") {
+		cleaned, err := stripJPEGSelective(data, s.KeepThumbnail, s.StripICC)
+		if err == nil {
+			return cleaned, nil
+		}
+		// 仅在 EXIF 解析失败时回退为重新编码
+		s.logger().Warnf("%s: EXIF 解析失败，回退为重新编码: %v", name, err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("图片解码失败: %w", err)
+	}
+	_ = format // 仅供调试
+	// 重新编码的输出不再带 EXIF，方向直接体现在像素上（selective/gps 模式解析失败回退到这里时同样如此）
+	img = orient(img, imageOrientation(data, ext))
+
+	var buf bytes.Buffer
+	switch ext {
+	case ".jpg", ".jpeg":
+		// 重新编码会丢弃 EXIF/XMP；ICC 配置默认嵌回
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: s.jpegQuality(name, data)}); err != nil {
+			return nil, err
+		}
+	case ".png":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	case ".tif", ".tiff":
+		// x/image/tiff 只解码首页，且编码时只写像素相关标签，EXIF/GPS IFD 与 ICC 配置都不会保留
+		if n, err := tiffPageCount(data); err == nil && n > 1 {
+			s.logger().Warnf("%s: 多页 TIFF 共 %d 页，仅保留首页", name, n)
+		}
+		if err := tiff.Encode(&buf, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true}); err != nil {
+			return nil, err
+		}
+	case ".bmp":
+		// 编码器只写出 40 字节的基本信息头，V4/V5 的色彩空间与 ICC 配置随之丢弃
+		if err := bmp.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("未知图片类型: %s", ext)
+	}
+
+	out := buf.Bytes()
+	if !s.StripICC {
+		switch ext {
+		case ".jpg", ".jpeg":
+			if b, err := embedJPEGICC(out, jpegICC(data)); err == nil {
+				out = b
+			} else {
+				s.logger().Warnf("%s: 嵌回 ICC 配置失败: %v", name, err)
+			}
+		case ".png":
+			out = embedPNGICC(out, pngICC(data))
+		}
+	}
+	return out, nil
+}
+
+// —— GIF：DecodeAll/EncodeAll 保留全部帧与时序 ——
+// 编码器只写出图像、图形控制与循环次数（NETSCAPE2.0）扩展，
+// 注释扩展以及 XMP 等其他应用扩展在往返后自然消失。
+func cleanGIF(data []byte) ([]byte, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("GIF 解码失败: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, fmt.Errorf("GIF 编码失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// —— JPEG 输出质量：优先使用 JPEGQuality，否则按源数据估算，估算失败时退回 95 ——
+// 注意：标准库编码器固定使用 4:2:0 色度抽样，无法匹配源文件的抽样方式
+func (s *Scrubber) jpegQuality(name string, data []byte) int {
+	if s.JPEGQuality > 0 {
+		return s.JPEGQuality
+	}
+	q, err := estimateJPEGQuality(data)
+	if err != nil {
+		s.logger().Debugf("%s: 无法估算 JPEG 质量，使用 95: %v", name, err)
+		return 95
+	}
+	return q
+}
+
+// —— TIFF 页数：沿 IFD 链计数（x/image/tiff 只能解码首个 IFD）——
+func tiffPageCount(data []byte) (int, error) {
+	if len(data) < 8 {
+		return 0, errors.New("TIFF 头部过短")
+	}
+	var bo binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 0, errors.New("非法的 TIFF 字节序标记")
+	}
+	n := 0
+	seen := map[uint32]bool{}
+	off := bo.Uint32(data[4:8])
+	for off != 0 && !seen[off] {
+		seen[off] = true // 防止恶意文件构造 IFD 环
+		if int(off)+2 > len(data) {
+			break
+		}
+		entries := int(bo.Uint16(data[off : off+2]))
+		next := int(off) + 2 + entries*12
+		if next+4 > len(data) {
+			break
+		}
+		n++
+		off = bo.Uint32(data[next : next+4])
+	}
+	return n, nil
+}
@@ -0,0 +1,308 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// tiffEntry 为测试用 TIFF 中的一个字段；sub 大于 0 时值为第 sub 个 IFD 的偏移（EXIF/GPS 子 IFD 指针）
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+	sub      int
+}
+
+func tiffASCII(tag uint16, s string) tiffEntry {
+	return tiffEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func tiffShort(tag uint16, v uint16) tiffEntry {
+	return tiffEntry{tag: tag, typ: 3, count: 1, data: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func tiffLong(tag uint16, v uint32) tiffEntry {
+	return tiffEntry{tag: tag, typ: 4, count: 1, data: binary.LittleEndian.AppendUint32(nil, v)}
+}
+
+// buildTIFF 按小端序写出 TIFF：头部之后是 pix（首个 IFD 的 StripOffsets 应为 8），再依次是各 IFD 与其外置数据
+func buildTIFF(pix []byte, ifds ...[]tiffEntry) []byte {
+	even := func(n int) int { return n + n%2 }
+	offs := make([]int, len(ifds))
+	pos := even(8 + len(pix))
+	for i, ifd := range ifds {
+		offs[i] = pos
+		pos += 2 + 12*len(ifd) + 4
+		for _, e := range ifd {
+			if len(e.data) > 4 {
+				pos += even(len(e.data))
+			}
+		}
+	}
+
+	bo := binary.LittleEndian
+	b := make([]byte, pos)
+	copy(b, "II")
+	bo.PutUint16(b[2:], 42)
+	bo.PutUint32(b[4:], uint32(offs[0]))
+	copy(b[8:], pix)
+	for i, ifd := range ifds {
+		p := offs[i]
+		bo.PutUint16(b[p:], uint16(len(ifd)))
+		ext := p + 2 + 12*len(ifd) + 4
+		for j, e := range ifd {
+			q := p + 2 + 12*j
+			bo.PutUint16(b[q:], e.tag)
+			bo.PutUint16(b[q+2:], e.typ)
+			bo.PutUint32(b[q+4:], e.count)
+			switch {
+			case e.sub > 0:
+				bo.PutUint32(b[q+8:], uint32(offs[e.sub]))
+			case len(e.data) > 4:
+				bo.PutUint32(b[q+8:], uint32(ext))
+				copy(b[ext:], e.data)
+				ext += even(len(e.data))
+			default:
+				copy(b[q+8:], e.data)
+			}
+		}
+	}
+	return b
+}
+
+// testTIFF 返回 2×2 灰度 TIFF：IFD0 带 Make 与 EXIF、GPS 子 IFD，EXIF 中有 DateTimeOriginal，GPS 中有纬度参考
+func testTIFF() []byte {
+	ifd0 := []tiffEntry{
+		tiffShort(256, 2), tiffShort(257, 2), tiffShort(258, 8), tiffShort(259, 1), tiffShort(262, 1),
+		tiffASCII(tagMake, "SecretCam"),
+		tiffLong(273, 8), tiffShort(277, 1), tiffShort(278, 2), tiffLong(279, 4),
+		{tag: tagExifIFD, typ: 4, count: 1, sub: 1},
+		{tag: tagGPSIFD, typ: 4, count: 1, sub: 2},
+	}
+	exifIFD := []tiffEntry{tiffASCII(tagDateTimeOriginal, "2023:04:05 06:07:08")}
+	gpsIFD := []tiffEntry{tiffASCII(1, "N")}
+	return buildTIFF([]byte{0, 80, 160, 255}, ifd0, exifIFD, gpsIFD)
+}
+
+// tiffTags 返回 TIFF 首个 IFD 中的全部标签
+func tiffTags(t *testing.T, b []byte) map[uint16]bool {
+	t.Helper()
+	tb, ifd0, err := newTIFFBlock(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := tb.entries(ifd0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[uint16]bool{}
+	for i := range n {
+		tags[tb.tag(tb.entryPos(ifd0, i))] = true
+	}
+	return tags
+}
+
+func TestTIFFWithGPSLosesEXIF(t *testing.T) {
+	in := testTIFF()
+	if tags := tiffTags(t, in); !tags[tagGPSIFD] || !tags[tagExifIFD] {
+		t.Fatal("样本应带有 GPS 与 EXIF IFD")
+	}
+	p := writeTestFile(t, t.TempDir(), "photo.tiff", in)
+	if err := newTestScrubber().ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := tiffTags(t, out)
+	for _, tag := range []uint16{tagGPSIFD, tagExifIFD, tagMake} {
+		if tags[tag] {
+			t.Errorf("输出仍带有标签 0x%04X", tag)
+		}
+	}
+	for _, s := range []string{"SecretCam", "2023:04:05"} {
+		if bytes.Contains(out, []byte(s)) {
+			t.Errorf("输出仍含有 %q", s)
+		}
+	}
+	img, err := tiff.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("输出无法解码: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 2 {
+		t.Errorf("尺寸 %v, 期望 2×2", b)
+	}
+}
+
+// testImage 返回 w×h 的彩色渐变图，带少量纹理，使编码结果接近真实照片
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x*y + x*7) % 256), 255})
+		}
+	}
+	return img
+}
+
+// jpegSeg 写出一个 JPEG 标记段（含标记与长度）
+func jpegSeg(marker byte, data []byte) []byte {
+	b := []byte{0xFF, marker}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)+2))
+	return append(b, data...)
+}
+
+// testJPEG 以质量 q 编码 img，并把 segs 插在 SOI 之后
+func testJPEG(t *testing.T, img image.Image, q int, segs ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	return append(append(b[:2:2], bytes.Join(segs, nil)...), b[2:]...)
+}
+
+// 重新编码时按源文件估算质量，低质量的源文件不会因为以默认高质量重新编码而变大
+func TestReencodeKeepsSourceQuality(t *testing.T) {
+	in := testJPEG(t, testImage(128, 96), 60)
+	if q, err := estimateJPEGQuality(in); err != nil || q != 60 {
+		t.Fatalf("估算质量 %d (%v), 期望 60", q, err)
+	}
+	p := writeTestFile(t, t.TempDir(), "a.jpg", in)
+	s := newTestScrubber()
+	s.StripMode = "full"
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > len(in) {
+		t.Errorf("输出 %d 字节，大于输入的 %d 字节", len(out), len(in))
+	}
+	if q, _ := estimateJPEGQuality(out); q != 60 {
+		t.Errorf("输出质量 %d, 期望 60", q)
+	}
+}
+
+func TestGIFCommentRemovedFramesKept(t *testing.T) {
+	g := &gif.GIF{LoopCount: 0}
+	for i := range 3 {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})
+		frame.SetColorIndex(i, i, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10*(i+1))
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	// 在结尾标记之前插入注释扩展
+	b := buf.Bytes()
+	comment := "made by Alice Secret"
+	in := append(b[:len(b)-1:len(b)-1], 0x21, 0xFE, byte(len(comment)))
+	in = append(append(in, comment...), 0x00, 0x3B)
+	if _, err := gif.DecodeAll(bytes.NewReader(in)); err != nil {
+		t.Fatalf("样本无法解码: %v", err)
+	}
+
+	p := writeTestFile(t, t.TempDir(), "a.gif", in)
+	if err := newTestScrubber().ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte(comment)) || bytes.Contains(out, []byte{0x21, 0xFE}) {
+		t.Error("输出仍含有注释扩展")
+	}
+	got, err := gif.DecodeAll(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("输出无法解码: %v", err)
+	}
+	if len(got.Image) != 3 {
+		t.Fatalf("帧数 %d, 期望 3", len(got.Image))
+	}
+	for i, d := range got.Delay {
+		if d != g.Delay[i] {
+			t.Errorf("第 %d 帧延时 %d, 期望 %d", i, d, g.Delay[i])
+		}
+	}
+}
+
+// testBMPV5 返回 2×2、24 位、BITMAPV5HEADER 信息头并在像素之后内嵌 ICC 配置的 BMP
+func testBMPV5(profile string) []byte {
+	le := binary.LittleEndian
+	pix := []byte{
+		0, 0, 255, 0, 255, 0, 0, 0, // 底行：红、绿，补齐到 4 字节
+		255, 0, 0, 255, 255, 255, 0, 0, // 顶行：蓝、白
+	}
+	h := le.AppendUint32(nil, 124)
+	h = le.AppendUint32(h, 2)
+	h = le.AppendUint32(h, 2)
+	h = le.AppendUint16(h, 1)
+	h = le.AppendUint16(h, 24)
+	h = le.AppendUint32(h, 0) // BI_RGB
+	h = le.AppendUint32(h, uint32(len(pix)))
+	h = append(h, make([]byte, 16+16)...) // 分辨率、调色板计数与四个掩码
+	h = append(h, "DEBM"...)              // LCS_PROFILE_EMBEDDED（'MBED'）
+	h = append(h, make([]byte, 36+12+4)...)
+	h = le.AppendUint32(h, uint32(124+len(pix))) // 配置数据相对信息头的偏移
+	h = le.AppendUint32(h, uint32(len(profile)))
+	h = le.AppendUint32(h, 0)
+
+	b := []byte("BM")
+	b = le.AppendUint32(b, uint32(14+len(h)+len(pix)+len(profile)))
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint32(b, uint32(14+len(h)))
+	b = append(append(append(b, h...), pix...), profile...)
+	return b
+}
+
+func TestBMPV5HeaderReduced(t *testing.T) {
+	in := testBMPV5("ICC_PROFILE_BY_ALICE")
+	src, err := bmp.Decode(bytes.NewReader(in))
+	if err != nil {
+		t.Fatalf("样本无法解码: %v", err)
+	}
+	p := writeTestFile(t, t.TempDir(), "a.bmp", in)
+	if err := newTestScrubber().ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := binary.LittleEndian.Uint32(out[14:]); size != 40 {
+		t.Errorf("信息头长度 %d, 期望 40（BITMAPINFOHEADER）", size)
+	}
+	if bytes.Contains(out, []byte("ALICE")) {
+		t.Error("输出仍含有 ICC 配置")
+	}
+	img, err := bmp.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("输出无法解码: %v", err)
+	}
+	for y := range 2 {
+		for x := range 2 {
+			r1, g1, b1, _ := src.At(x, y).RGBA()
+			r2, g2, b2, _ := img.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				t.Errorf("像素 (%d,%d) 改变", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// —— dry-run 元数据审计 ——
+// 演示模式下不写任何文件，只读取每个文件并列出将被删除的元数据，
+// 把 dry-run 从“列出路径”变成一次可审计的元数据检查。
+
+// Finding 是在文件中发现、处理时将被删除的一项元数据
+type Finding struct {
+	Item  string `json:"item"`            // 位置或字段，如 docProps/core.xml、EXIF GPS
+	Value string `json:"value,omitempty"` // 字段取值（作者名等），仅在能读出时填写
+}
+
+// Inspect 只读地检查 path，返回处理时将被删除的元数据
+func (s *Scrubber) Inspect(path string) ([]Finding, error) {
+	ext, _ := s.effectiveExt(path)
+	h, ok := handlerFor(ext)
+	if !ok {
+		return nil, errUnsupported(ext)
+	}
+	return h.Inspect(s, path, ext)
+}
+
+// inspectImage 逐项列出 JPEG/PNG/WebP/BMP 中的元数据；整体重新编码的格式只给出一条说明
+func (s *Scrubber) inspectImage(path, ext string) ([]Finding, error) {
+	switch ext {
+	case ".jpg", ".jpeg", ".tif", ".tiff":
+		if s.StripMode != "gps" {
+			break
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return inspectGPS(data, ext)
+	}
+	switch ext {
+	case ".jpg", ".jpeg":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.inspectJPEG(data)
+	case ".png":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.inspectPNG(data)
+	case ".webp":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.inspectWebP(data)
+	case ".bmp":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fs, err := inspectBMP(data)
+		if err != nil || len(fs) == 0 {
+			return fs, err
+		}
+		return append(fs, Finding{Item: "重新编码为基本 BMP"}), nil
+	}
+	// TIFF/GIF/HEIC 整体重新编码，逐项列出意义不大
+	return []Finding{{Item: "重新编码，丢弃全部非像素数据"}}, nil
+}
+
+// odfMetaFields 为 OpenDocument meta.xml 中值得展示的字段
+var odfMetaFields = map[string][]string{
+	"meta.xml": {"initial-creator", "creator", "creation-date", "date", "generator"},
+}
+
+// coreFields 为 docProps 中值得展示的字段（按本地名）
+var coreFields = map[string][]string{
+	"docprops/core.xml": {"creator", "lastModifiedBy", "created", "modified", "title", "subject", "keywords"},
+	"docprops/app.xml":  {"Company", "Manager", "Application", "Template"},
+}
+
+func (s *Scrubber) inspectOpenXML(path string) ([]Finding, error) {
+	keep := s.keepOpenXMLEntry
+	if s.KeepMinimalProps {
+		// 替换为空属性的部件同样列出，并读出其中将被清除的字段
+		keep = func(name string) bool {
+			return minimalProps[strings.ToLower(name)] == "" && s.keepOpenXMLEntry(name)
+		}
+	}
+	fs, err := inspectZip(path, s.dropMacEntries(keep), coreFields)
+	if err != nil {
+		return nil, err
+	}
+	if s.DeepOffice {
+		authors, err := wordAuthors(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range authors {
+			fs = append(fs, Finding{Item: "Word 修订/批注作者", Value: a})
+		}
+	}
+	authors, err := pptxAuthors(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range authors {
+		fs = append(fs, Finding{Item: "PowerPoint 批注作者", Value: a})
+	}
+	fonts, err := inspectEmbeddedFonts(path)
+	if err != nil {
+		return nil, err
+	}
+	fs = append(fs, fonts...)
+	if s.DeepXLSX {
+		authors, err := xlsxAuthors(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range authors {
+			fs = append(fs, Finding{Item: "Excel 批注作者", Value: a})
+		}
+	}
+	return fs, nil
+}
+
+// inspectZip 列出会被 keep 丢弃的条目，并从 fields 指定的条目中读出字段值
+func inspectZip(path string, keep func(string) bool, fields map[string][]string) ([]Finding, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+
+	var fs []Finding
+	if zr.Comment != "" {
+		fs = append(fs, Finding{Item: "归档注释", Value: zr.Comment})
+	}
+	fs = append(fs, zipExtraFindings(zr.File)...)
+	for _, zf := range zr.File {
+		if keep(zf.Name) {
+			continue
+		}
+		fs = append(fs, Finding{Item: zf.Name})
+		want := fields[strings.ToLower(zf.Name)]
+		if len(want) == 0 {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		vals, err := xmlFieldValues(r, want)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+		for _, v := range vals {
+			v.Item = zf.Name + " " + v.Item
+			fs = append(fs, v)
+		}
+	}
+	return fs, nil
+}
+
+// zipExtraFindings 按字段统计带有 UID/GID、NTFS 时间等扩展字段的条目数
+func zipExtraFindings(files []*zip.File) []Finding {
+	count := map[uint16]int{}
+	for _, zf := range files {
+		for _, id := range zipExtraIDs(zf.Extra) {
+			if _, ok := zipExtraNames[id]; ok {
+				count[id]++
+			}
+		}
+	}
+	ids := make([]uint16, 0, len(count))
+	for id := range count {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var fs []Finding
+	for _, id := range ids {
+		fs = append(fs, Finding{Item: "zip 扩展字段 " + zipExtraNames[id], Value: fmt.Sprintf("%d 个条目", count[id])})
+	}
+	return fs
+}
+
+// xmlFieldValues 读取本地名在 locals 中的元素文本
+func xmlFieldValues(r io.Reader, locals []string) ([]Finding, error) {
+	want := map[string]bool{}
+	for _, l := range locals {
+		want[l] = true
+	}
+	var fs []Finding
+	d := xml.NewDecoder(r)
+	cur := ""
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return fs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			cur = ""
+			if want[t.Name.Local] {
+				cur = t.Name.Local
+			}
+		case xml.CharData:
+			if v := strings.TrimSpace(string(t)); cur != "" && v != "" {
+				fs = append(fs, Finding{Item: cur, Value: v})
+			}
+		case xml.EndElement:
+			cur = ""
+		}
+	}
+}
+
+// wordAuthors 收集 word/ 下各部件中出现的 w:author（去重，按出现顺序）
+func wordAuthors(path string) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	seen := map[string]bool{}
+	var authors []string
+	for _, zf := range zr.File {
+		if wordEdit(zf.Name) == nil {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = rewriteXML(r, io.Discard, func(el *xml.StartElement) {
+			for _, a := range el.Attr {
+				if a.Name.Space == "w" && a.Name.Local == "author" && !seen[a.Value] {
+					seen[a.Value] = true
+					authors = append(authors, a.Value)
+				}
+			}
+		})
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+	}
+	return authors, nil
+}
+
+func inspectEPUB(path string) ([]Finding, error) {
+	opf, err := epubOPFPath(path)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	f, err := zr.Open(opf)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var fs []Finding
+	drop := newEPUBMetaDrop()
+	d := xml.NewDecoder(f)
+	var cur *Finding
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return fs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 OPF 失败: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			cur = nil
+			if drop(t) {
+				fs = append(fs, Finding{Item: qname(t.Name)})
+				cur = &fs[len(fs)-1]
+				for _, a := range t.Attr {
+					if a.Name.Local == "content" {
+						cur.Value = a.Value
+					}
+				}
+			}
+		case xml.CharData:
+			if v := strings.TrimSpace(string(t)); cur != nil && v != "" {
+				cur.Value = v
+			}
+		case xml.EndElement:
+			cur = nil
+		}
+	}
+}
+
+// rtfInfoField 匹配 \info 中常见的文本字段，如 {\author 张三}
+var rtfInfoField = regexp.MustCompile(`\{\\(?:\*\\)?(title|subject|author|operator|manager|company|keywords|doccomm)\s+([^{}\\]*)\}`)
+
+func inspectRTF(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' {
+			i = rtfSkipControl(data, i) - 1
+			continue
+		}
+		if data[i] != '{' || !rtfIsDropGroup(data[i:]) {
+			continue
+		}
+		end, err := rtfGroupEnd(data, i)
+		if err != nil {
+			return nil, err
+		}
+		group := data[i:end]
+		name := `\info`
+		if !bytes.HasPrefix(group, rtfDropGroups[0]) {
+			name = `\*\userprops`
+		}
+		fs = append(fs, Finding{Item: name})
+		for _, m := range rtfInfoField.FindAllSubmatch(group, -1) {
+			if v := strings.TrimSpace(string(m[2])); v != "" {
+				fs = append(fs, Finding{Item: `\` + string(m[1]), Value: v})
+			}
+		}
+		i = end - 1
+	}
+	return fs, nil
+}
+
+// EXIF 中值得展示的 IFD0 文本字段；selective 模式只删除其中的 Make/Model
+var exifTextTags = []struct {
+	tag       uint16
+	name      string
+	selective bool
+}{
+	{tagMake, "Make", true}, {tagModel, "Model", true}, {0x0131, "Software", false}, {0x013B, "Artist", false},
+}
+
+func (s *Scrubber) inspectJPEG(b []byte) ([]Finding, error) {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	// selective 模式只删除 EXIF 中的部分字段与 XMP，其余段保留
+	selective := s.StripMode == "selective"
+	var fs []Finding
+	for _, seg := range segs {
+		switch {
+		case seg.marker == markerAPP1 && bytes.HasPrefix(seg.data, xmpHeader):
+			fs = append(fs, Finding{Item: "XMP", Value: fmt.Sprintf("%d 字节", len(seg.data))})
+		case seg.marker == markerAPP1 && bytes.HasPrefix(seg.data, exifHeader):
+			if !selective {
+				fs = append(fs, Finding{Item: "EXIF", Value: fmt.Sprintf("%d 字节", len(seg.data))})
+			}
+			t, ifd0, err := newTIFFBlock(seg.data[len(exifHeader):])
+			if err != nil {
+				continue
+			}
+			if t.find(ifd0, tagGPSIFD) >= 0 {
+				fs = append(fs, Finding{Item: "EXIF GPS"})
+			}
+			for _, e := range exifTextTags {
+				if selective && !e.selective {
+					continue
+				}
+				if v := t.ascii(ifd0, e.tag); v != "" {
+					fs = append(fs, Finding{Item: "EXIF " + e.name, Value: v})
+				}
+			}
+		case isICCSegment(seg) && s.StripICC:
+			fs = append(fs, Finding{Item: "ICC 色彩配置"})
+		case seg.marker == markerAPP13 && !selective:
+			if isPhotoshopSegment(seg) {
+				fs = append(fs, inspectPhotoshop(seg)...)
+			} else {
+				fs = append(fs, Finding{Item: "APP13 段", Value: fmt.Sprintf("%d 字节", len(seg.data))})
+			}
+		case seg.marker == 0xFE && !selective:
+			fs = append(fs, Finding{Item: "JPEG 注释", Value: string(seg.data)})
+		}
+	}
+	return fs, nil
+}
+
+// ascii 读取 IFD 中 ASCII 类型标签的值，不存在或越界时返回空串
+func (t *tiffBlock) ascii(off uint32, tag uint16) string {
+	p := t.find(off, tag)
+	if p < 0 || t.bo.Uint16(t.b[p+2:p+4]) != 2 {
+		return ""
+	}
+	n := int(t.bo.Uint32(t.b[p+4 : p+8]))
+	v := t.b[p+8 : p+12]
+	if n > 4 {
+		start := int(t.value32(p))
+		if start < 0 || start+n > len(t.b) {
+			return ""
+		}
+		v = t.b[start : start+n]
+	} else {
+		v = v[:n]
+	}
+	return strings.TrimRight(string(v), "\x00 ")
+}
+
+func (s *Scrubber) inspectPNG(b []byte) ([]Finding, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, errors.New("不是合法的 PNG 文件")
+	}
+	var fs []Finding
+	for p := len(pngSignature); p+8 <= len(b); {
+		n := int(binary.BigEndian.Uint32(b[p : p+4]))
+		if p+12+n > len(b) {
+			break
+		}
+		data := b[p+8 : p+8+n]
+		switch typ := string(b[p+4 : p+8]); typ {
+		case "tEXt", "zTXt", "iTXt":
+			key, val, _ := bytes.Cut(data, []byte{0})
+			f := Finding{Item: typ + " " + string(key)}
+			if typ == "tEXt" {
+				f.Value = string(val)
+			}
+			fs = append(fs, f)
+		case "tIME", "eXIf":
+			fs = append(fs, Finding{Item: typ})
+		case "iCCP":
+			if s.StripICC {
+				fs = append(fs, Finding{Item: "ICC 色彩配置"})
+			}
+		}
+		p += 12 + n
+	}
+	return fs, nil
+}
+
+func (s *Scrubber) inspectWebP(b []byte) ([]Finding, error) {
+	b, err := webpRIFF(b)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for p := 12; p+8 <= len(b); {
+		size := int(binary.LittleEndian.Uint32(b[p+4 : p+8]))
+		switch fourCC := string(b[p : p+4]); {
+		case fourCC == "EXIF" || fourCC == "XMP ":
+			fs = append(fs, Finding{Item: strings.TrimSpace(fourCC), Value: fmt.Sprintf("%d 字节", size)})
+		case fourCC == "ICCP" && s.StripICC:
+			fs = append(fs, Finding{Item: "ICC 色彩配置"})
+		}
+		p += 8 + size + size&1
+	}
+	return fs, nil
+}
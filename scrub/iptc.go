@@ -0,0 +1,112 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// —— IPTC 与 Photoshop 资源（APP13）——
+// Photoshop、Lightroom 等把 IPTC-NAA 记录与 Photoshop 图像资源写在 APP13 段中：
+// "Photoshop 3.0\0" 之后是一串 8BIM 资源块，其中 0x0404 为 IPTC 记录，包含作者（By-line）、
+// 版权声明、关键词、拍摄地点等，与 EXIF（APP1）相互独立。
+// lossless 与 full 模式都会删除整个 APP13 段（full 重新编码时自然丢弃），selective 与 gps 模式保留；
+// 这里只负责解析，供 dry-run 列出字段与 --verify 检查。
+
+const markerAPP13 = 0xED
+
+var photoshopHeader = []byte("Photoshop 3.0\x00")
+
+// iptcDataSets 为 dry-run 展示的 IPTC 应用记录（记录号 2）数据集
+var iptcDataSets = map[byte]string{
+	5:   "Object Name",
+	25:  "Keywords",
+	80:  "By-line",
+	85:  "By-line Title",
+	90:  "City",
+	95:  "Province/State",
+	101: "Country",
+	105: "Headline",
+	110: "Credit",
+	115: "Source",
+	116: "Copyright Notice",
+	120: "Caption/Abstract",
+	122: "Writer/Editor",
+}
+
+func isPhotoshopSegment(s jpegSegment) bool {
+	return s.marker == markerAPP13 && bytes.HasPrefix(s.data, photoshopHeader)
+}
+
+// photoshopResources 依次以资源号与内容调用 fn，结构损坏时返回错误
+func photoshopResources(data []byte, fn func(id uint16, res []byte)) error {
+	b := data[len(photoshopHeader):]
+	for len(b) > 0 {
+		if len(b) < 7 || string(b[:4]) != "8BIM" {
+			return errors.New("Photoshop 资源块结构损坏")
+		}
+		id := binary.BigEndian.Uint16(b[4:6])
+		// 资源名为 Pascal 字符串，连同长度字节补齐为偶数
+		p := 6 + 1 + int(b[6])
+		p += p & 1
+		if p+4 > len(b) {
+			return errors.New("Photoshop 资源块被截断")
+		}
+		n := int(binary.BigEndian.Uint32(b[p : p+4]))
+		p += 4
+		if n < 0 || p+n > len(b) {
+			return errors.New("Photoshop 资源块长度非法")
+		}
+		fn(id, b[p:p+n])
+		p += n + n&1
+		if p > len(b) {
+			p = len(b)
+		}
+		b = b[p:]
+	}
+	return nil
+}
+
+// iptcFindings 列出 IPTC 记录中的常见字段；关键词等可重复的数据集合并为一条
+func iptcFindings(rec []byte) []Finding {
+	var fs []Finding
+	at := map[string]int{}
+	for len(rec) >= 5 && rec[0] == 0x1C {
+		record, set := rec[1], rec[2]
+		n := int(binary.BigEndian.Uint16(rec[3:5]))
+		if n&0x8000 != 0 || 5+n > len(rec) {
+			break // 扩展长度的数据集只用于大块二进制，不再继续解析
+		}
+		v := rec[5 : 5+n]
+		rec = rec[5+n:]
+		name, ok := iptcDataSets[set]
+		if record != 2 || !ok || !utf8.Valid(v) {
+			continue
+		}
+		val := strings.TrimSpace(string(v))
+		if i, dup := at[name]; dup {
+			fs[i].Value += ", " + val
+			continue
+		}
+		at[name] = len(fs)
+		fs = append(fs, Finding{Item: "IPTC " + name, Value: val})
+	}
+	return fs
+}
+
+// inspectPhotoshop 列出 APP13 段中的 IPTC 字段；没有可读字段时给出资源块的大小
+func inspectPhotoshop(s jpegSegment) []Finding {
+	var fs []Finding
+	err := photoshopResources(s.data, func(id uint16, res []byte) {
+		if id == 0x0404 {
+			fs = append(fs, iptcFindings(res)...)
+		}
+	})
+	if err != nil || len(fs) == 0 {
+		fs = append(fs, Finding{Item: "Photoshop 资源（APP13）", Value: fmt.Sprintf("%d 字节", len(s.data))})
+	}
+	return fs
+}
@@ -0,0 +1,66 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// iptcSeg 返回带 IPTC 记录的 Photoshop APP13 段；sets 为数据集号与取值交替排列
+func iptcSeg(sets ...any) []byte {
+	var rec []byte
+	for i := 0; i+1 < len(sets); i += 2 {
+		v := sets[i+1].(string)
+		rec = append(rec, 0x1C, 2, byte(sets[i].(int)))
+		rec = binary.BigEndian.AppendUint16(rec, uint16(len(v)))
+		rec = append(rec, v...)
+	}
+	res := append([]byte("8BIM\x04\x04\x00\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(rec)))...)
+	res = append(res, rec...)
+	if len(rec)%2 == 1 {
+		res = append(res, 0)
+	}
+	return jpegSeg(markerAPP13, append(bytes.Clone(photoshopHeader), res...))
+}
+
+func TestPhotoshopIPTCRemoved(t *testing.T) {
+	in := testJPEG(t, testImage(16, 16), 90, iptcSeg(80, "Alice Secret", 25, "beach", 25, "family", 116, "(c) Alice"))
+	p := writeTestFile(t, t.TempDir(), "a.jpg", in)
+
+	s := newTestScrubber()
+	fs, err := s.Inspect(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]string{}
+	for _, f := range fs {
+		found[f.Item] = f.Value
+	}
+	if found["IPTC By-line"] != "Alice Secret" || found["IPTC Keywords"] != "beach, family" {
+		t.Errorf("dry-run 应列出 IPTC 作者与关键词，实际: %v", fs)
+	}
+
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs, _, err := splitJPEG(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seg := range segs {
+		if seg.marker == markerAPP13 {
+			t.Error("输出仍带有 APP13 段")
+		}
+	}
+	for _, v := range []string{"Alice", "Photoshop 3.0", "8BIM"} {
+		if bytes.Contains(out, []byte(v)) {
+			t.Errorf("输出仍含有 %q", v)
+		}
+	}
+}
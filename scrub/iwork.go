@@ -0,0 +1,137 @@
+package scrub
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// —— Apple iWork：Pages / Numbers / Keynote ——
+// 2013 年以后的 iWork 文档是 zip 包：Index/ 下的 .iwa 为文档正文（原样保留），
+// Metadata/ 中的 Properties.plist 记录文档 UUID、修订号与创建它的应用版本，
+// BuildVersionHistory.plist 记录历次保存所用的应用版本，两者删除；
+// 根目录的 preview*.jpg 是文档缩略图，带有自己的 EXIF，按独立 JPEG 的规则脱敏。
+// 更早的“文件夹包”格式在文件系统中是目录，不在处理范围内。
+
+var iworkSet = map[string]bool{
+	".pages": true, ".numbers": true, ".key": true,
+}
+
+func init() {
+	registerSet(iworkSet, handlerFuncs{
+		kind:    "iwork",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubIWork(p, dst) },
+		inspect: func(s *Scrubber, p, _ string) ([]Finding, error) { return s.inspectIWork(p) },
+		verify:  (*Scrubber).verifyIWork,
+		info: HandlerInfo{
+			Method:  "删除 zip 部件",
+			Removes: "Metadata/Properties.plist、BuildVersionHistory.plist；预览图中的 EXIF/XMP",
+		},
+	})
+}
+
+func (s *Scrubber) scrubIWork(p, dst string) error {
+	return s.rewriteZip(p, dst, keepIWorkEntry, s.nestedEdit(s.iworkEdit(), 0, newZipBudget()))
+}
+
+// keepIWorkEntry 丢弃 Metadata/ 下的属性与版本历史，其余条目全部保留
+func keepIWorkEntry(name string) bool {
+	switch strings.ToLower(name) {
+	case "metadata/properties.plist", "metadata/buildversionhistory.plist":
+		return false
+	}
+	return true
+}
+
+// isIWorkPreview 判断条目是否为根目录下的预览图（preview.jpg、preview-micro.jpg、preview-web.jpg）
+func isIWorkPreview(name string) bool {
+	ok, _ := path.Match("preview*.jpg", strings.ToLower(name))
+	return ok
+}
+
+// iworkEdit 对预览图调用 cleanImage，与单独处理一张 JPEG 的结果相同
+func (s *Scrubber) iworkEdit() zipEdit {
+	return func(name string) func(r io.Reader, w io.Writer) error {
+		if !isIWorkPreview(name) {
+			return nil
+		}
+		return func(r io.Reader, w io.Writer) error {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			cleaned, err := s.cleanImage(name, data, ".jpg")
+			if err != nil {
+				return fmt.Errorf("处理预览图 %s 失败: %w", name, err)
+			}
+			_, err = w.Write(cleaned)
+			return err
+		}
+	}
+}
+
+// inspectIWork 列出将被删除的 Metadata 条目，以及各预览图中的 EXIF/XMP
+func (s *Scrubber) inspectIWork(p string) ([]Finding, error) {
+	fs, err := inspectZip(p, s.dropMacEntries(keepIWorkEntry), nil)
+	if err != nil {
+		return nil, err
+	}
+	err = eachIWorkPreview(p, func(name string, data []byte) error {
+		inspect := s.inspectJPEG
+		if s.StripMode == "gps" {
+			inspect = func(b []byte) ([]Finding, error) { return inspectGPS(b, ".jpg") }
+		}
+		pfs, err := inspect(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, f := range pfs {
+			f.Item = name + " " + f.Item
+			fs = append(fs, f)
+		}
+		return nil
+	})
+	return fs, err
+}
+
+// verifyIWork 检查 Metadata 条目已删除，且预览图中不再有应删除的 EXIF/XMP
+func (s *Scrubber) verifyIWork(out, _ string) error {
+	if err := verifyZip(out, s.dropMacEntries(keepIWorkEntry)); err != nil {
+		return err
+	}
+	return eachIWorkPreview(out, func(name string, data []byte) error {
+		if err := s.verifyJPEGData(data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// eachIWorkPreview 依次读出归档中的预览图交给 fn
+func eachIWorkPreview(p string, fn func(name string, data []byte) error) error {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if !isIWorkPreview(zf.Name) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if err := fn(zf.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package scrub
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPagesPropertiesRemoved(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict>` +
+		`<key>documentUUID</key><string>1A2B-SECRET-UUID</string><key>fileFormatVersion</key><string>13.1</string></dict></plist>`
+	iwa := "\x00\x05\x00\x00binary-iwa-body"
+	preview := testJPEG(t, testImage(16, 16), 90, exifSeg([]tiffEntry{tiffASCII(tagMake, "SecretMac")}))
+	data := zipBytes(t,
+		"Index/Document.iwa", iwa,
+		"Metadata/Properties.plist", plist,
+		"Metadata/BuildVersionHistory.plist", `<plist><array><string>Pages-SECRET-BUILD</string></array></plist>`,
+		"Metadata/DocumentIdentifier", "1A2B",
+		"preview.jpg", string(preview),
+	)
+	p := writeTestFile(t, t.TempDir(), "report.pages", data)
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	names, parts := readZip(t, p)
+	for _, name := range []string{"Metadata/Properties.plist", "Metadata/BuildVersionHistory.plist"} {
+		if _, ok := parts[name]; ok {
+			t.Errorf("%s 未被删除", name)
+		}
+	}
+	if parts["Index/Document.iwa"] != iwa {
+		t.Error("文档正文应原样保留")
+	}
+	if _, ok := parts["Metadata/DocumentIdentifier"]; !ok {
+		t.Errorf("其余条目应保留: %v", names)
+	}
+	if bytes.Contains([]byte(parts["preview.jpg"]), []byte("SecretMac")) {
+		t.Error("预览图仍带有 EXIF")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package scrub
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isLockError 判断 err 是否为稍后重试可能成功的占用错误（权限不足等不在此列）
+func isLockError(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY)
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package scrub
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Windows 上杀毒软件、同步客户端短暂打开文件时，重命名会报共享/锁定冲突
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// isLockError 判断 err 是否为稍后重试可能成功的占用错误（权限不足等不在此列）
+func isLockError(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == errorSharingViolation || errno == errorLockViolation
+}
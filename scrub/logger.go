@@ -0,0 +1,103 @@
+package scrub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// —— 分级日志 ——
+// 多个 worker 同时输出时，每条日志先完整格式化，再在互斥锁内一次写出，行与行之间不会交错。
+// 文本格式沿用 log 包的时间前缀；JSON 格式每行一个对象，便于接入日志采集。
+
+// Level 为日志级别，低于 Logger 级别的消息不输出
+type Level int
+
+const (
+	LevelDebug Level = iota // 逐项细节：跳过的链接、重试等
+	LevelInfo               // 每个文件的处理结果
+	LevelWarn               // 可继续处理的异常，如回退为重新编码
+	LevelError              // 处理失败的文件
+)
+
+var levelNames = [...]string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel 解析 debug/info/warn/error（不区分大小写）
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("未知的日志级别: %s（可选 debug/info/warn/error）", s)
+}
+
+// Logger 是并发安全的分级日志
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	json  bool
+}
+
+// NewLogger 返回写入 w 的日志；jsonFormat 为 true 时每行输出 {"time","level","msg"}
+func NewLogger(w io.Writer, level Level, jsonFormat bool) *Logger {
+	return &Logger{w: w, level: level, json: jsonFormat}
+}
+
+// 未设置 Scrubber.Logger 时使用的默认日志
+var (
+	stderrLogger  = NewLogger(os.Stderr, LevelWarn, false)
+	verboseLogger = NewLogger(os.Stderr, LevelDebug, false)
+)
+
+func (l *Logger) Debugf(format string, args ...any) { l.logf(LevelDebug, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.logf(LevelInfo, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.logf(LevelWarn, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.logf(LevelError, format, args...) }
+
+func (l *Logger) logf(level Level, format string, args ...any) {
+	if level < l.level {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	now := time.Now()
+
+	var line []byte
+	if l.json {
+		line, _ = json.Marshal(struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{now.Format(time.RFC3339), level.String(), msg})
+		line = append(line, '\n')
+	} else {
+		line = fmt.Appendf(nil, "%s [%s] %s\n", now.Format("2006/01/02 15:04:05"), strings.ToUpper(level.String()), msg)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line)
+}
+
+// logger 返回任务使用的日志：未设置 Logger 时写到 stderr，Verbose 为 debug 级别，否则为 warn 级别
+func (s *Scrubber) logger() *Logger {
+	switch {
+	case s.Logger != nil:
+		return s.Logger
+	case s.Verbose:
+		return verboseLogger
+	}
+	return stderrLogger
+}
@@ -0,0 +1,159 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// —— 无损删除 JPEG 元数据（StripMode = "lossless"）——
+// 与 jpegtran -copy none 相同，只在标记段层面删除，SOS 之后的熵编码数据逐字节拷贝，
+// 不解码、不重新编码，质量、色度抽样与文件体积都保持不变。
+// 未指定 StripMode 且未指定 JPEGQuality 时 JPEG 默认按此处理（见 jpegMode）。
+//
+// 保留解码所需的段（DQT、SOFn、DHT、DRI 等）以及 JFIF APP0、ICC 配置（StripICC 时删除）、
+// Adobe APP14（决定颜色变换）；删除 APP1（EXIF、XMP）、APP13（IPTC/Photoshop）、
+// 其余 APPn（MPF、FPXR、厂商私有段）、JFXX 缩略图与注释（COM），EOI 之后附加的数据
+// （MPF 的多画面图像、厂商追加的缩略图等）一并截掉。
+// EXIF 方向不是 1 时写回只含 Orientation 一个字段的 EXIF 段，像素不动，显示方向与原图一致。
+
+const markerCOM = 0xFE
+
+var (
+	jfifHeader  = []byte("JFIF\x00")
+	adobeHeader = []byte("Adobe")
+)
+
+// keepLosslessSegment 判断无损模式下是否保留标记段 s
+func keepLosslessSegment(s jpegSegment, stripICC bool) bool {
+	switch {
+	case s.marker == 0xE0:
+		return bytes.HasPrefix(s.data, jfifHeader)
+	case s.marker == markerAPP2:
+		return !stripICC && isICCSegment(s)
+	case s.marker == 0xEE:
+		return bytes.HasPrefix(s.data, adobeHeader)
+	case s.marker >= 0xE0 && s.marker <= 0xEF, s.marker == markerCOM:
+		return false
+	}
+	return true
+}
+
+// stripJPEGLossless 按上述规则删除元数据段，扫描数据原样保留
+func stripJPEGLossless(b []byte, stripICC bool) ([]byte, error) {
+	segs, rest, err := splitJPEG(b)
+	if err != nil {
+		return nil, err
+	}
+	o := imageOrientation(b, ".jpg")
+	out := make([]jpegSegment, 0, len(segs)+1)
+	for _, s := range segs {
+		if keepLosslessSegment(s, stripICC) {
+			out = append(out, s)
+		}
+	}
+	if o != 1 {
+		// 紧跟 JFIF APP0 之后（没有时放在最前），与相机写入的位置一致
+		at := 0
+		if len(out) > 0 && out[0].marker == 0xE0 {
+			at = 1
+		}
+		out = append(out[:at], append([]jpegSegment{orientationExif(o)}, out[at:]...)...)
+	}
+	end, err := jpegScanEnd(rest)
+	if err != nil {
+		return nil, err
+	}
+	return joinJPEG(out, rest[:end])
+}
+
+// orientationExif 构造只含 IFD0 Orientation 一个字段的 APP1/EXIF 段（小端）
+func orientationExif(o int) jpegSegment {
+	d := append([]byte(nil), exifHeader...)
+	d = append(d, 'I', 'I', 42, 0, 8, 0, 0, 0) // TIFF 头，IFD0 位于偏移 8
+	d = binary.LittleEndian.AppendUint16(d, 1)
+	d = binary.LittleEndian.AppendUint16(d, tagOrientation)
+	d = binary.LittleEndian.AppendUint16(d, 3) // SHORT
+	d = binary.LittleEndian.AppendUint32(d, 1)
+	d = binary.LittleEndian.AppendUint16(d, uint16(o))
+	d = append(d, 0, 0, 0, 0, 0, 0) // 值补齐 4 字节，next IFD = 0
+	return jpegSegment{marker: markerAPP1, data: d}
+}
+
+// jpegScanEnd 返回 rest（从 SOS 开始）中 EOI 之后的偏移，用于截掉 EOI 之后附加的数据。
+// 熵编码数据中的 FF 后跟 00（填充）或 D0-D7（RSTn），其余 FFxx 为标记：
+// 渐进式 JPEG 的多个扫描之间夹有 DHT、SOS 等带长度的段，跳过后继续查找
+func jpegScanEnd(rest []byte) (int, error) {
+	i := 0
+	for i+1 < len(rest) {
+		if rest[i] != 0xFF {
+			i++
+			continue
+		}
+		m := rest[i+1]
+		switch {
+		case m == 0x00 || m == 0xFF || (m >= 0xD0 && m <= 0xD7):
+			i += 2
+			if m == 0xFF {
+				i-- // 填充字节：从下一个 FF 重新判断
+			}
+		case m == markerEOI:
+			return i + 2, nil
+		default:
+			if i+4 > len(rest) {
+				return 0, errors.New("扫描数据中的标记段被截断")
+			}
+			n := int(binary.BigEndian.Uint16(rest[i+2 : i+4]))
+			if n < 2 || i+2+n > len(rest) {
+				return 0, fmt.Errorf("扫描数据中的标记段 FF%02X 长度非法: %d", m, n)
+			}
+			i += 2 + n
+		}
+	}
+	return 0, errors.New("缺少 EOI 标记，JPEG 可能被截断")
+}
+
+// verifyJPEGLossless 检查无损模式的输出只剩解码所需的段与允许保留的段：
+// EXIF 只能是 orientationExif 写回的单字段段
+func verifyJPEGLossless(b []byte, stripICC bool) error {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		if keepLosslessSegment(seg, stripICC) {
+			continue
+		}
+		if seg.marker == markerAPP1 && bytes.HasPrefix(seg.data, exifHeader) {
+			t, ifd0, err := newTIFFBlock(seg.data[len(exifHeader):])
+			if err != nil {
+				return err
+			}
+			if n, err := t.entries(ifd0); err == nil && n == 1 && t.find(ifd0, tagOrientation) >= 0 {
+				continue
+			}
+			return errors.New("仍包含 EXIF 段")
+		}
+		switch seg.marker {
+		case markerCOM:
+			return errors.New("仍包含 JPEG 注释")
+		case markerAPP13:
+			return errors.New("仍包含 IPTC/Photoshop（APP13）段")
+		}
+		return fmt.Errorf("仍包含 APP%d 段", seg.marker-0xE0)
+	}
+	return nil
+}
+
+// jpegMode 返回 JPEG 实际使用的脱敏方式：未指定 StripMode 时，
+// 指定了 JPEGQuality 视为要求重新编码（full），否则为 lossless
+func (s *Scrubber) jpegMode() string {
+	switch {
+	case s.StripMode != "":
+		return s.StripMode
+	case s.JPEGQuality > 0:
+		return "full"
+	}
+	return "lossless"
+}
@@ -0,0 +1,82 @@
+package scrub
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// —— macOS 附带文件（StripMacFiles）——
+// macOS 复制到不支持扩展属性的卷（U 盘、网络共享）时，会在每个文件旁生成 ._<文件名>（AppleDouble），
+// 其中保存资源分支与扩展属性（下载来源 URL、Finder 标签与注释等）；Finder 还会在目录中留下 .DS_Store。
+// 用 Finder“压缩”打包时，这些内容以 __MACOSX/ 目录与 ._ 条目进入 zip。
+// 开启后：遍历时遇到的这类文件不作为文档处理（._report.docx 并不是 docx），原地处理时在结束后删除
+// （开启 Backup 时先备份，--restore 可找回），输出到独立目录或带后缀输出时只是不写出，不改动输入；
+// 重写 zip 类格式时一并丢弃 __MACOSX/ 下的条目与 ._*、.DS_Store 条目（dry-run 与 --verify 同样按此检查）。
+
+// isMacJunk 判断文件名是否为 AppleDouble 附带文件或 .DS_Store
+func isMacJunk(base string) bool {
+	return base == ".DS_Store" || strings.HasPrefix(base, "._")
+}
+
+// isMacZipEntry 判断 zip 条目是否为 Finder 打包时带入的 macOS 附带内容
+func isMacZipEntry(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || isMacJunk(path.Base(strings.TrimSuffix(name, "/")))
+}
+
+// dropMacEntries 在 StripMacFiles 时包装 keep，使其同时丢弃 macOS 附带条目
+func (s *Scrubber) dropMacEntries(keep func(string) bool) func(string) bool {
+	if !s.StripMacFiles {
+		return keep
+	}
+	return func(name string) bool {
+		return !isMacZipEntry(name) && keep(name)
+	}
+}
+
+// removeMacFiles 删除收集阶段记录的 macOS 附带文件并计入 rep.MacRemoved；
+// 只在原地处理时删除，dry-run 或已中断时不删除
+func (s *Scrubber) removeMacFiles(ctx context.Context, root string, rep *Report) {
+	if s.DryRun || s.OutputDir != "" || s.Suffix != "" {
+		return
+	}
+	for _, p := range s.macJunk {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.Backup {
+			c := *s
+			if s.BackupDir != "" {
+				c.backupAt = underDir(s.BackupDir, p, root)
+			}
+			if err := c.makeBackup(p); err != nil {
+				s.logger().Errorf("%s: %v", p, err)
+				continue
+			}
+		}
+		if err := os.Remove(p); err != nil {
+			s.logger().Errorf("删除 %s 失败: %v", p, err)
+			continue
+		}
+		s.logger().Debugf("已删除 macOS 附带文件 %s", p)
+		rep.MacRemoved++
+	}
+}
+
+// MacFiles 返回收集阶段记录的 macOS 附带文件（仅 StripMacFiles 时记录）
+func (s *Scrubber) MacFiles() []string {
+	return s.macJunk
+}
+
+// skipMacJunk 供 accept 使用：StripMacFiles 时记录并跳过 macOS 附带文件
+func (s *Scrubber) skipMacJunk(p string) bool {
+	if !s.StripMacFiles || !isMacJunk(filepath.Base(p)) {
+		return false
+	}
+	collectMu.Lock()
+	s.macJunk = append(s.macJunk, p)
+	collectMu.Unlock()
+	return true
+}
@@ -0,0 +1,57 @@
+package scrub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStripMacFiles(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTestFile(t, dir, "report.docx", zipBytes(t, testDocx(`<w:p/>`,
+		"__MACOSX/word/._document.xml", "com.apple.quarantine;Safari;https://intranet.example/",
+		"__MACOSX/", "",
+		"word/.DS_Store", "Bud1",
+		"word/._styles.xml", "AppleDouble",
+	)...))
+	writeTestFile(t, dir, "._report.docx", []byte("\x00\x05\x16\x07AppleDouble"))
+	writeTestFile(t, dir, "sub/.DS_Store", []byte("Bud1"))
+
+	s := newTestScrubber()
+	s.Verify = true
+	s.StripMacFiles = true
+	rep, err := s.ScrubDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK != 1 || rep.Failed != 0 || len(rep.Files) != 1 {
+		t.Errorf("OK=%d Failed=%d Files=%v, 期望只处理 report.docx", rep.OK, rep.Failed, rep.Files)
+	}
+	if rep.MacRemoved != 2 {
+		t.Errorf("MacRemoved = %d, 期望 2", rep.MacRemoved)
+	}
+	for _, junk := range []string{"._report.docx", "sub/.DS_Store"} {
+		if _, err := os.Stat(filepath.Join(dir, junk)); !os.IsNotExist(err) {
+			t.Errorf("%s 应被删除", junk)
+		}
+	}
+	names, parts := readZip(t, p)
+	for _, name := range names {
+		if isMacZipEntry(name) {
+			t.Errorf("条目 %s 未被删除", name)
+		}
+	}
+	if _, ok := parts["word/document.xml"]; !ok {
+		t.Error("word/document.xml 应保留")
+	}
+}
+
+func TestMacEntriesKeptByDefault(t *testing.T) {
+	p := writeTestFile(t, t.TempDir(), "report.docx", zipBytes(t, testDocx(`<w:p/>`, "__MACOSX/word/._document.xml", "AppleDouble")...))
+	if err := newTestScrubber().ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, parts := readZip(t, p); parts["__MACOSX/word/._document.xml"] != "AppleDouble" {
+		t.Error("未开启 StripMacFiles 时 __MACOSX/ 条目应原样保留")
+	}
+}
@@ -0,0 +1,151 @@
+package scrub
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// —— 清单模式（--manifest）：逐文件指定处理方式 ——
+// 大批量、类型混杂的任务常由数据治理系统生成文件清单，不同文件需要不同的处理方式。
+// 清单为 CSV 或 TSV（首行含制表符时按 TSV 解析），首行为表头，列名不区分大小写、顺序不限：
+//
+//	path,strip-mode,output-path
+//	photos/a.jpg,selective,
+//	docs/合同.docx,deep,out/合同.docx
+//
+// path 必填；strip-mode 与 output-path 可以为空，表示沿用全局选项。
+// 格式错误、文件不存在或不受支持的行记录错误日志后跳过，不影响其余行。
+
+// ManifestEntry 是清单中的一行
+type ManifestEntry struct {
+	Path      string
+	StripMode string // lossless/full/selective/gps 覆盖 JPEG（gps 也包括 TIFF）处理方式；deep 开启 DeepOffice、DeepXLSX 与 DeepODF；空表示沿用全局选项
+	Output    string // 输出文件路径；空表示按 OutputDir/Suffix 计算
+}
+
+// manifestStripModes 为 strip-mode 列允许的取值
+var manifestStripModes = map[string]bool{
+	"": true, "lossless": true, "full": true, "selective": true, "gps": true, "deep": true,
+}
+
+// ReadManifest 解析清单，跳过无效的行（记录错误日志；超过大小上限的文件计入跳过数）
+func (s *Scrubber) ReadManifest(r io.Reader) ([]ManifestEntry, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(br.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	cr := csv.NewReader(br)
+	if line, _, _ := strings.Cut(string(first), "\n"); strings.Contains(line, "\t") {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1 // 列数不符的行单独报告，不中断整个清单
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取清单表头失败: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := cols["path"]; !ok {
+		return nil, errors.New("清单缺少 path 列")
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var entries []ManifestEntry
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析清单失败: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) != len(header) {
+			s.logger().Errorf("清单第 %d 行: 有 %d 列，表头为 %d 列，已跳过", line, len(rec), len(header))
+			continue
+		}
+		e := ManifestEntry{
+			Path:      field(rec, "path"),
+			StripMode: strings.ToLower(field(rec, "strip-mode")),
+			Output:    field(rec, "output-path"),
+		}
+		if err := s.checkEntry(e); err != nil {
+			if errors.Is(err, ErrTooLarge) {
+				atomic.AddInt64(&s.skipped, 1)
+			}
+			s.logger().Errorf("清单第 %d 行: %v，已跳过", line, err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// checkEntry 检查清单行的路径与选项
+func (s *Scrubber) checkEntry(e ManifestEntry) error {
+	if e.Path == "" {
+		return errors.New("path 为空")
+	}
+	if !manifestStripModes[e.StripMode] {
+		return fmt.Errorf("未知的 strip-mode: %s（可选 lossless/full/selective/gps/deep）", e.StripMode)
+	}
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s 是目录", e.Path)
+	}
+	return s.forEntry(e).Check(e.Path)
+}
+
+// ScrubManifest 按清单并发处理，每行使用覆盖后的选项
+func (s *Scrubber) ScrubManifest(entries []ManifestEntry) Report {
+	return s.ScrubManifestContext(context.Background(), entries)
+}
+
+// ScrubManifestContext 与 ScrubManifest 相同，中断行为同 ScrubFilesContext
+func (s *Scrubber) ScrubManifestContext(ctx context.Context, entries []ManifestEntry) Report {
+	files := make([]string, len(entries))
+	for i, e := range entries {
+		files[i] = e.Path
+	}
+	// 相对路径在输出目录下按原结构还原，与 CollectList 一致
+	return s.scrubEach(ctx, ".", files, func(i int) *Scrubber { return s.forEntry(entries[i]) })
+}
+
+// forEntry 返回按清单行覆盖选项后的副本；没有覆盖项时返回 s 本身
+func (s *Scrubber) forEntry(e ManifestEntry) *Scrubber {
+	if e.StripMode == "" && e.Output == "" {
+		return s
+	}
+	c := *s
+	switch e.StripMode {
+	case "lossless", "full", "selective", "gps":
+		c.StripMode = e.StripMode
+	case "deep":
+		c.DeepOffice = true
+		c.DeepXLSX = true
+		c.DeepODF = true
+	}
+	c.output = e.Output
+	return &c
+}
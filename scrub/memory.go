@@ -0,0 +1,39 @@
+package scrub
+
+import (
+	"image"
+	"os"
+)
+
+// —— 内存预算 ——
+// 多个 worker 同时处理大文件（2GB 的 pptx、巨幅 TIFF）时容易耗尽内存。
+// 每个文件开始前按估算的内存占用从加权信号量中申请额度，处理完归还，
+// 因此大文件会自动以较低的并发度处理，小文件仍可全速并行。
+
+// defaultMaxMemory 为 MaxMemory 未设置时的预算
+const defaultMaxMemory = 1 << 30
+
+func (s *Scrubber) memoryBudget() int64 {
+	if s.MaxMemory > 0 {
+		return s.MaxMemory
+	}
+	return defaultMaxMemory
+}
+
+// memoryWeight 估算处理 p 时的峰值内存：图片按解码后的像素缓冲计算，其余按文件大小计算。
+// 结果限制在 [1, 预算] 内，超出预算的单个文件仍可独占全部额度运行。
+func (s *Scrubber) memoryWeight(p string) int64 {
+	var w int64
+	if info, err := os.Stat(p); err == nil {
+		w = info.Size()
+	}
+	if ext, _ := s.effectiveExt(p); imageSet[ext] || heicSet[ext] {
+		if f, err := os.Open(p); err == nil {
+			if cfg, _, err := image.DecodeConfig(f); err == nil {
+				w += int64(cfg.Width) * int64(cfg.Height) * 4
+			}
+			f.Close()
+		}
+	}
+	return min(max(w, 1), s.memoryBudget())
+}
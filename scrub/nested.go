@@ -0,0 +1,102 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// —— 嵌套归档（--recursive-zip）——
+// docx 中以 OLE 对象嵌入的其他 Office 文件（word/embeddings/*.docx 等）本身也是 zip，
+// 外层脱敏不会触及它们的 docProps。开启后按魔数识别这类条目，在内存中递归脱敏后写回。
+// 为防 zip 炸弹，限制嵌套深度，并限制单个文件内所有嵌套归档读入与写出的总字节数。
+
+const (
+	maxZipDepth      = 3
+	maxNestedZipSize = 256 << 20
+)
+
+var errNestedZipTooLarge = errors.New("嵌套归档总大小超过上限")
+
+// zipBudget 记录单个顶层文件内嵌套归档剩余可用的字节数
+type zipBudget struct{ left int64 }
+
+func newZipBudget() *zipBudget { return &zipBudget{left: maxNestedZipSize} }
+
+func (b *zipBudget) take(n int) error {
+	b.left -= int64(n)
+	if b.left < 0 {
+		return errNestedZipTooLarge
+	}
+	return nil
+}
+
+// Write 让 zipBudget 可以挂在输出端统计解压后的大小
+func (b *zipBudget) Write(p []byte) (int, error) {
+	if err := b.take(len(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// nestedEdit 在 base 之外为其余条目加上嵌套归档检测；未开启 RecursiveZip 或已达深度上限时原样返回 base
+func (s *Scrubber) nestedEdit(base zipEdit, depth int, budget *zipBudget) zipEdit {
+	if !s.RecursiveZip || depth >= maxZipDepth {
+		return base
+	}
+	return func(name string) func(r io.Reader, w io.Writer) error {
+		if base != nil {
+			if fn := base(name); fn != nil {
+				return fn // XML 部件，不会是归档
+			}
+		}
+		return func(r io.Reader, w io.Writer) error {
+			br := bufio.NewReader(r)
+			if magic, _ := br.Peek(4); string(magic) != "PK\x03\x04" {
+				_, err := io.Copy(w, br)
+				return err
+			}
+			data, err := io.ReadAll(io.LimitReader(br, budget.left+1))
+			if err != nil {
+				return err
+			}
+			if err := budget.take(len(data)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			cleaned, err := s.scrubNestedZip(data, depth+1, budget)
+			if err != nil {
+				return fmt.Errorf("处理嵌套归档 %s 失败: %w", name, err)
+			}
+			_, err = w.Write(cleaned)
+			return err
+		}
+	}
+}
+
+// scrubNestedZip 在内存中脱敏一个嵌套归档：Office/OpenDocument 按对应规则删除属性，
+// 普通 zip 保留全部条目，只继续向内递归
+func (s *Scrubber) scrubNestedZip(data []byte, depth int, budget *zipBudget) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	keep := func(string) bool { return true }
+	var edit zipEdit
+	switch kindOf(zipKind(zr)) {
+	case "openxml":
+		keep = s.keepOpenXMLEntry
+		edit = s.openXMLEdit()
+	case "opendoc":
+		keep = s.keepOpenDocEntry
+		edit = s.openDocEdit()
+	}
+
+	var buf bytes.Buffer
+	if err := s.writeZip(zr, io.MultiWriter(&buf, budget), s.dropMacEntries(keep), s.nestedEdit(edit, depth, budget)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package scrub
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nestedDocx 返回嵌套 levels 层的 docx：每层都带 core.xml，内层放在 word/embeddings/inner.docx
+func nestedDocx(t *testing.T, levels int) []byte {
+	t.Helper()
+	data := zipBytes(t, testDocx(`<w:p/>`)...)
+	for range levels - 1 {
+		data = zipBytes(t, testDocx(`<w:p/>`, "word/embeddings/inner.docx", string(data))...)
+	}
+	return data
+}
+
+func TestRecursiveZipNestedDocx(t *testing.T) {
+	// 顶层 + 3 层可处理的嵌套 + 1 层超出深度上限
+	p := writeTestFile(t, t.TempDir(), "a.docx", nestedDocx(t, maxZipDepth+2))
+	innermost := zipBytes(t, testDocx(`<w:p/>`)...)
+
+	s := newTestScrubber()
+	s.RecursiveZip = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	names, parts := readZip(t, p)
+	for level := 0; level <= maxZipDepth; level++ {
+		if _, ok := parts["docProps/core.xml"]; ok {
+			t.Errorf("第 %d 层的 docProps/core.xml 未被删除", level)
+		}
+		if _, ok := parts["word/document.xml"]; !ok {
+			t.Fatalf("第 %d 层缺少 word/document.xml: %v", level, names)
+		}
+		inner := parts["word/embeddings/inner.docx"]
+		if level == maxZipDepth {
+			// 超出深度上限的一层原样复制
+			if inner != string(innermost) {
+				t.Error("超出深度上限的嵌套归档应原样保留")
+			}
+			break
+		}
+		names, parts = readZip(t, writeTestFile(t, t.TempDir(), "inner.docx", []byte(inner)))
+	}
+}
+
+func TestRecursiveZipBudget(t *testing.T) {
+	s := newTestScrubber()
+	s.RecursiveZip = true
+	inner := zipBytes(t, testDocx(`<w:p/>`, "word/media/big.bin", strings.Repeat("0", 64<<10))...)
+
+	for _, c := range []struct {
+		name string
+		left int64
+	}{
+		{"读入超过上限", int64(len(inner)) - 1},
+		// 压缩后很小，解压写出时才超过上限（zip 炸弹）
+		{"写出超过上限", int64(len(inner)) + 1024},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fn := s.nestedEdit(nil, 0, &zipBudget{left: c.left})("word/embeddings/inner.docx")
+			err := fn(bytes.NewReader(inner), &bytes.Buffer{})
+			if !errors.Is(err, errNestedZipTooLarge) {
+				t.Errorf("err = %v, 期望 errNestedZipTooLarge", err)
+			}
+		})
+	}
+
+	fn := s.nestedEdit(nil, 0, newZipBudget())("word/embeddings/inner.docx")
+	var out bytes.Buffer
+	if err := fn(bytes.NewReader(inner), &out); err != nil {
+		t.Fatalf("未超过上限时应正常处理: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// —— OpenDocument：签名、缩略图、生成器信息与清单 ——
+// 除根目录的 meta.xml 之外，ODF 包中还有几处会泄露信息：
+//   - META-INF/documentsignatures.xml、macrosignatures.xml：签名证书里有签名人姓名、机构与签名时间。
+//     删除 meta.xml 后签名本就不再有效，StripODFExtras 时连同签名文件一起删除；
+//   - Thumbnails/thumbnail.png：首页的预览图，文档内容改了预览图也不会自动更新，StripODFExtras 时删除；
+//   - content.xml 中的 office:meta 与 meta:generator：部分生成器会在正文部件里再写一份，始终删除；
+//   - 修订记录（text:tracked-changes）与批注（office:annotation）中的 dc:creator、dc:date：
+//     记录着每处修改与批注的作者和时间，DeepODF 时匿名化（见下文）。
+// 删除的条目同步从 META-INF/manifest.xml 中去掉，保持清单与包内容一致。
+// ODF 生成器一律使用 office、meta、manifest 这些标准前缀，这里直接按前缀匹配。
+
+const odfManifest = "meta-inf/manifest.xml"
+
+// keepOpenDocEntry 返回 true 表示保留该条目（--verify 也据此检查输出）
+func (s *Scrubber) keepOpenDocEntry(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "meta.xml" {
+		return false
+	}
+	if s.StripODFExtras {
+		switch {
+		case lower == "meta-inf/documentsignatures.xml", lower == "meta-inf/macrosignatures.xml":
+			return false
+		case strings.HasPrefix(lower, "thumbnails/"):
+			return false
+		}
+	}
+	return true
+}
+
+// openDocEdit 返回 content.xml 与清单的改写函数
+func (s *Scrubber) openDocEdit() zipEdit {
+	return func(name string) func(r io.Reader, w io.Writer) error {
+		switch strings.ToLower(name) {
+		case "content.xml":
+			if s.DeepODF {
+				return anonymizeODFChanges
+			}
+			return xmlDropper(isODFMeta)
+		case "styles.xml":
+			if s.DeepODF {
+				return anonymizeODFChanges // 页眉页脚中的修订与批注保存在 styles.xml
+			}
+		case odfManifest:
+			return xmlDropper(func(el xml.StartElement) bool {
+				return s.droppedManifestEntry(el)
+			})
+		}
+		return nil
+	}
+}
+
+// isODFMeta 判断元素是否为 office:meta 或 meta:generator
+func isODFMeta(el xml.StartElement) bool {
+	return el.Name.Space == "office" && el.Name.Local == "meta" ||
+		el.Name.Space == "meta" && el.Name.Local == "generator"
+}
+
+// —— OpenDocument 修订与批注：作者匿名化（--deep-odf）——
+// 修订以 text:changed-region 记录在 text:tracked-changes 下，每处修订的 office:change-info
+// 含 dc:creator（作者）与 dc:date（时间），批注 office:annotation 也直接带这两个子元素。
+// meta.xml 删除后它们仍在 content.xml（页眉页脚中的在 styles.xml）里。作者统一替换为 Author；
+// 规范要求 change-info 必须有 dc:date，因此时间改为固定的 1980-01-01 而不是删除。
+// 修订结构与 text:id 保持不变，接受/拒绝修订不受影响。dc:creator 在这两个部件中只会出现在
+// 修订与批注里，按父元素匹配即可。
+
+// odfAnonDate 替换修订与批注的时间
+var odfAnonDate = zipEpoch.Format("2006-01-02T15:04:05")
+
+// anonymizeODFChanges 删除 office:meta 与 meta:generator，并替换修订/批注的作者与时间
+func anonymizeODFChanges(r io.Reader, w io.Writer) error {
+	return editXML(r, w, isODFMeta, nil, func(parent xml.Name, text string) string {
+		if parent.Space != "dc" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		switch parent.Local {
+		case "creator":
+			return anonAuthor
+		case "date":
+			return odfAnonDate
+		}
+		return text
+	})
+}
+
+// isODFChangePart 判断条目是否可能包含修订与批注
+func isODFChangePart(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "content.xml" || lower == "styles.xml"
+}
+
+// odfAuthors 收集 content.xml 与 styles.xml 中修订与批注的作者（去重，按出现顺序）
+func odfAuthors(zr *zip.Reader) ([]string, error) {
+	seen := map[string]bool{}
+	var authors []string
+	for _, zf := range zr.File {
+		if !isODFChangePart(zf.Name) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = editXML(r, io.Discard, nil, nil, func(parent xml.Name, text string) string {
+			if n := strings.TrimSpace(text); parent.Space == "dc" && parent.Local == "creator" && n != "" && !seen[n] {
+				seen[n] = true
+				authors = append(authors, n)
+			}
+			return text
+		})
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+	}
+	return authors, nil
+}
+
+// droppedManifestEntry 判断清单中的 manifest:file-entry 是否指向被删除的条目
+func (s *Scrubber) droppedManifestEntry(el xml.StartElement) bool {
+	if el.Name.Local != "file-entry" {
+		return false
+	}
+	p := xmlAttr(el, "full-path")
+	return p != "" && p != "/" && !s.keepOpenDocEntry(p)
+}
+
+// inspectOpenDocument 列出将被删除的条目、meta.xml 中的字段与 content.xml 中的生成器信息
+func (s *Scrubber) inspectOpenDocument(path string) ([]Finding, error) {
+	fs, err := inspectZip(path, s.dropMacEntries(s.keepOpenDocEntry), odfMetaFields)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if strings.ToLower(zf.Name) != "content.xml" {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		vals, err := xmlFieldValues(r, []string{"generator"})
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+		for _, v := range vals {
+			v.Item = zf.Name + " " + v.Item
+			fs = append(fs, v)
+		}
+	}
+	if s.DeepODF {
+		authors, err := odfAuthors(&zr.Reader)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range authors {
+			fs = append(fs, Finding{Item: "OpenDocument 修订/批注作者", Value: a})
+		}
+	}
+	return fs, nil
+}
+
+// verifyOpenDocument 检查输出中没有会被删除的条目，content.xml 中没有 office:meta 与 meta:generator，
+// 清单中也没有指向它们的条目；DeepODF 时修订与批注的作者均已替换为 Author
+func (s *Scrubber) verifyOpenDocument(path string) error {
+	if err := verifyZip(path, s.dropMacEntries(s.keepOpenDocEntry)); err != nil {
+		return err
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		var bad func(el xml.StartElement) bool
+		switch strings.ToLower(zf.Name) {
+		case "content.xml":
+			bad = isODFMeta
+		case odfManifest:
+			bad = s.droppedManifestEntry
+		default:
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = findXMLElement(r, bad)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s %w", zf.Name, err)
+		}
+	}
+	if s.DeepODF {
+		authors, err := odfAuthors(&zr.Reader)
+		if err != nil {
+			return err
+		}
+		for _, a := range authors {
+			if a != anonAuthor {
+				return fmt.Errorf("修订/批注仍包含作者 %q", a)
+			}
+		}
+	}
+	return nil
+}
+
+// findXMLElement 在 r 中查找 bad 返回 true 的元素，找到时返回说明该元素的错误
+func findXMLElement(r io.Reader, bad func(el xml.StartElement) bool) error {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解析失败: %w", err)
+		}
+		if el, ok := tok.(xml.StartElement); ok && bad(el) {
+			return fmt.Errorf("仍包含 <%s>", qname(el.Name))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package scrub
+
+import (
+	"strings"
+	"testing"
+)
+
+const testODFNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"` +
+	` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0"`
+
+// testODT 返回最小 odt 的条目（name、content 交替）：text 为 office:text 的内容，extra 为清单中另外列出并追加在后的条目
+func testODT(text string, extra ...string) []string {
+	manifest := `<?xml version="1.0" encoding="UTF-8"?><manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.3">` +
+		`<manifest:file-entry manifest:full-path="/" manifest:media-type="application/vnd.oasis.opendocument.text"/>` +
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+		`<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>`
+	for i := 0; i+1 < len(extra); i += 2 {
+		manifest += `<manifest:file-entry manifest:full-path="` + extra[i] + `" manifest:media-type=""/>`
+	}
+	manifest += `</manifest:manifest>`
+	entries := []string{
+		"mimetype", "application/vnd.oasis.opendocument.text",
+		"META-INF/manifest.xml", manifest,
+		"meta.xml", `<?xml version="1.0" encoding="UTF-8"?><office:document-meta ` + testODFNS + `><office:meta>` +
+			`<meta:initial-creator>Alice Secret</meta:initial-creator><meta:generator>SecretOffice/1.0</meta:generator></office:meta></office:document-meta>`,
+		"content.xml", `<?xml version="1.0" encoding="UTF-8"?><office:document-content ` + testODFNS + `><office:body><office:text>` +
+			text + `</office:text></office:body></office:document-content>`,
+	}
+	return append(entries, extra...)
+}
+
+func TestODTSignaturesRemoved(t *testing.T) {
+	sig := `<?xml version="1.0" encoding="UTF-8"?><document-signatures xmlns="urn:oasis:names:tc:opendocument:xmlns:digitalsignature:1.0">` +
+		`<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><KeyInfo><X509Data><X509IssuerName>CN=Alice Secret,O=ACME Corp</X509IssuerName>` +
+		`</X509Data></KeyInfo></Signature></document-signatures>`
+	data := zipBytes(t, testODT(`<text:p>Body</text:p>`,
+		"META-INF/documentsignatures.xml", sig,
+		"META-INF/macrosignatures.xml", sig,
+		"Thumbnails/thumbnail.png", "PNG-PREVIEW",
+	)...)
+
+	for _, extras := range []bool{false, true} {
+		p := writeTestFile(t, t.TempDir(), "signed.odt", data)
+		s := newTestScrubber()
+		s.Verify = true
+		s.StripODFExtras = extras
+		if err := s.ScrubFile(p); err != nil {
+			t.Fatal(err)
+		}
+		names, parts := readZip(t, p)
+		if _, ok := parts["meta.xml"]; ok {
+			t.Error("meta.xml 未被删除")
+		}
+		manifest := parts["META-INF/manifest.xml"]
+		if !strings.Contains(manifest, `manifest:full-path="content.xml"`) {
+			t.Error("清单中 content.xml 的条目应保留")
+		}
+		for _, name := range []string{"META-INF/documentsignatures.xml", "META-INF/macrosignatures.xml", "Thumbnails/thumbnail.png"} {
+			_, ok := parts[name]
+			if ok == extras {
+				t.Errorf("StripODFExtras=%v 时 %s 存在: %v, 条目: %v", extras, name, ok, names)
+			}
+			if listed := strings.Contains(manifest, `"`+name+`"`); listed != ok {
+				t.Errorf("清单与包内容不一致: %s 存在 %v, 清单中列出 %v", name, ok, listed)
+			}
+		}
+		if extras {
+			for name, content := range parts {
+				if strings.Contains(content, "Alice Secret") {
+					t.Errorf("%s 仍含有签名人", name)
+				}
+			}
+		}
+	}
+}
+
+func TestODTTrackedChangesAnonymized(t *testing.T) {
+	change := func(id, who string) string {
+		return `<text:changed-region text:id="` + id + `"><text:insertion><office:change-info>` +
+			`<dc:creator>` + who + `</dc:creator><dc:date>2024-03-04T05:06:07.123</dc:date>` +
+			`</office:change-info></text:insertion></text:changed-region>`
+	}
+	text := `<text:tracked-changes>` + change("ct1", "Bob Reviewer") + change("ct2", "Carol Editor") + `</text:tracked-changes>` +
+		`<text:p><text:change-start text:change-id="ct1"/>added<text:change-end text:change-id="ct1"/>` +
+		`<office:annotation><dc:creator>Bob Reviewer</dc:creator><dc:date>2024-03-04T05:06:07</dc:date><text:p>check this</text:p></office:annotation></text:p>`
+	styles := `<?xml version="1.0" encoding="UTF-8"?><office:document-styles ` + testODFNS + `><office:master-styles>` +
+		`<text:tracked-changes>` + change("ct3", "Dave Header") + `</text:tracked-changes></office:master-styles></office:document-styles>`
+	p := writeTestFile(t, t.TempDir(), "a.odt", zipBytes(t, testODT(text, "styles.xml", styles)...))
+
+	s := newTestScrubber()
+	s.DeepODF = true
+	fs, err := s.Inspect(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authors []string
+	for _, f := range fs {
+		if f.Item == "OpenDocument 修订/批注作者" {
+			authors = append(authors, f.Value)
+		}
+	}
+	if strings.Join(authors, ",") != "Bob Reviewer,Carol Editor,Dave Header" {
+		t.Errorf("dry-run 列出的作者 %v", authors)
+	}
+
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readZip(t, p)
+	for name, content := range parts {
+		for _, leak := range []string{"Bob", "Carol", "Dave", "2024-03-04"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+	content := parts["content.xml"]
+	if n := strings.Count(content, "<dc:creator>"+anonAuthor+"</dc:creator>"); n != 3 {
+		t.Errorf("content.xml 中匿名作者 %d 处, 期望 3", n)
+	}
+	if n := strings.Count(content, "<dc:date>"+odfAnonDate+"</dc:date>"); n != 3 {
+		t.Errorf("content.xml 中固定时间 %d 处, 期望 3（规范要求 change-info 带 dc:date）", n)
+	}
+	for _, keep := range []string{`text:id="ct1"`, `text:change-id="ct1"`, "<text:p>check this</text:p>"} {
+		if !strings.Contains(content, keep) {
+			t.Errorf("修订结构应保留 %q", keep)
+		}
+	}
+	if !strings.Contains(parts["styles.xml"], "<dc:creator>"+anonAuthor+"</dc:creator>") {
+		t.Error("styles.xml 中的修订作者应匿名化")
+	}
+}
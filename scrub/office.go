@@ -0,0 +1,420 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// zipEpoch 是 ZIP（DOS 时间）可表示的最早时间，ZeroTimestamps 或 Deterministic 时所有条目统一使用它
+var zipEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zipEdit 按条目名返回内容改写函数，返回 nil 表示原样复制
+type zipEdit func(name string) func(r io.Reader, w io.Writer) error
+
+var (
+	// Office OpenXML：docx/xlsx/pptx 与 Visio 的 vsdx 通过删除 zip 内的 docProps/* 实现属性清除；
+	// 启用宏的 docm/xlsm/pptm/vsdm 结构相同，另有 vbaProject.bin，见 StripMacros
+	openXMLSet = map[string]bool{
+		".docx": true, ".xlsx": true, ".pptx": true, ".vsdx": true,
+		".docm": true, ".xlsm": true, ".pptm": true, ".vsdm": true,
+	}
+	// OpenDocument：odt/ods/odp 通过删除 zip 内的 meta.xml 实现属性清除
+	openDocSet = map[string]bool{
+		".odt": true, ".ods": true, ".odp": true,
+	}
+)
+
+func init() {
+	registerSet(openXMLSet, handlerFuncs{
+		kind:    "openxml",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubOpenXML(p, dst) },
+		inspect: func(s *Scrubber, p, _ string) ([]Finding, error) { return s.inspectOpenXML(p) },
+		verify:  (*Scrubber).verifyOpenXML,
+		info: HandlerInfo{
+			Method:  "删除 zip 部件",
+			Removes: "docProps/*（作者、公司、时间、应用信息、自定义属性）与归档注释；可选宏工程、修订/批注作者、演讲者备注",
+		},
+	})
+	registerSet(openDocSet, handlerFuncs{
+		kind:    "opendoc",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubOpenDocument(p, dst) },
+		inspect: func(s *Scrubber, p, _ string) ([]Finding, error) { return s.inspectOpenDocument(p) },
+		verify:  func(s *Scrubber, out, _ string) error { return s.verifyOpenDocument(out) },
+		info: HandlerInfo{
+			Method:  "删除 zip 部件并同步清单",
+			Removes: "meta.xml（作者、时间、生成工具）、content.xml 中的生成器信息与归档注释；可选数字签名与缩略图",
+		},
+	})
+}
+
+// —— Office OpenXML: 过滤 zip 中的 docProps/* ——
+func (s *Scrubber) scrubOpenXML(path, dst string) error {
+	return s.rewriteZip(path, dst, s.keepOpenXMLEntry, s.nestedEdit(s.openXMLEdit(), 0, newZipBudget()))
+}
+
+// openXMLEdit 按选项组合各部件的改写函数；各函数处理的条目互不重叠，取第一个非 nil 的结果
+func (s *Scrubber) openXMLEdit() zipEdit {
+	var edits []zipEdit
+	if s.KeepMinimalProps {
+		edits = append(edits, minimalPropsEdit)
+	}
+	if s.DeepOffice {
+		edits = append(edits, wordEdit)
+	}
+	if s.DeepXLSX {
+		edits = append(edits, xlsxEdit)
+	}
+	edits = append(edits, pptxEdit, refsEdit(s.droppedPart))
+	return func(name string) func(r io.Reader, w io.Writer) error {
+		for _, e := range edits {
+			if fn := e(name); fn != nil {
+				return fn
+			}
+		}
+		return nil
+	}
+}
+
+// keepOpenXMLEntry 返回 true 表示保留该条目（--verify 也据此检查输出）
+func (s *Scrubber) keepOpenXMLEntry(name string) bool {
+	lower := strings.ToLower(name)
+	if s.KeepMinimalProps && minimalProps[lower] != "" {
+		return true // 内容由 minimalPropsEdit 替换为空属性
+	}
+	if strings.HasPrefix(lower, "docprops/") {
+		return false // 丢弃所有属性文件: core.xml, app.xml, custom.xml
+	}
+	if s.DeepOffice && (strings.HasPrefix(lower, "customxml/") || strings.HasPrefix(lower, "docmetadata/")) {
+		return false // 自定义 XML 数据与敏感度标签（LabelInfo.xml）常含作者、租户信息
+	}
+	if s.DeepOffice && lower == "visio/comments.xml" {
+		return false // Visio 批注：AuthorList 中有作者姓名与缩写，指向它的关系由 refsEdit 去掉
+	}
+	if s.DeepXLSX && lower == "xl/calcchain.xml" {
+		return false // 计算链缓存，Excel 打开时会重建
+	}
+	if s.StripMacros && isVBAPart(lower) {
+		return false
+	}
+	return s.keepPPTXEntry(lower)
+}
+
+// isVBAPart 判断条目是否属于 VBA 宏工程：word/、xl/、ppt/、visio/ 下的 vbaProject.bin、
+// 其签名（vbaProjectSignature*.bin）、关系文件 _rels/vbaProject.bin.rels 与 vbaData.xml。
+// 宏工程中保存着模块源码、工程路径与作者机器上的引用路径，对外分享时既泄露信息也是安全隐患。
+// 删除后指向它们的关系与内容类型声明由 refsEdit 一并去掉；扩展名对应的 Default 类型声明保留，不影响打开。
+func isVBAPart(lower string) bool {
+	base := path.Base(lower)
+	return strings.HasPrefix(base, "vbaproject") || base == "vbadata.xml"
+}
+
+// droppedPart 判断 part 是否为被删除、且需要同步清理引用的部件。
+// docProps/ 是包级属性，只由 _rels/.rels 引用，缺失时 Office 照常打开，默认不改动引用；
+// 部分企业文档校验工具会把悬空的关系判为损坏，DerefContentTypes 时一并清理
+func (s *Scrubber) droppedPart(part string) bool {
+	if s.keepOpenXMLEntry(part) {
+		return false
+	}
+	return s.DerefContentTypes || !strings.HasPrefix(strings.ToLower(part), "docprops/")
+}
+
+// —— 最小属性部件（--keep-minimal-props）——
+// 部分下游系统与严格的查看器要求包中存在 docProps/app.xml（缺失时报告需要修复）。
+// 此时不删除 core.xml 与 app.xml，而是把内容替换为只有根元素的空属性：作者、公司、标题、
+// 时间与应用信息都不再存在。两个部件的所有子元素在规范中都是可选的，因此仍然有效；
+// 时间元素要求合法的 W3CDTF 日期，不能写成空值，因此直接省略。custom.xml 等其余 docProps/ 部件照常删除。
+// 只替换原本存在的部件，不会新增；关系与内容类型声明中的引用因此保持有效。
+
+// opcXMLDecl 是 Office 写出的 XML 声明
+const opcXMLDecl = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\r\n"
+
+// minimalProps 为替换后的部件内容（按小写条目名）
+var minimalProps = map[string]string{
+	"docprops/core.xml": opcXMLDecl +
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+		` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/"` +
+		` xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"/>`,
+	"docprops/app.xml": opcXMLDecl +
+		`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"` +
+		` xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"/>`,
+}
+
+func minimalPropsEdit(name string) func(r io.Reader, w io.Writer) error {
+	content := minimalProps[strings.ToLower(name)]
+	if content == "" {
+		return nil
+	}
+	return func(_ io.Reader, w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	}
+}
+
+// verifyMinimalProps 检查 core.xml 与 app.xml（如存在）已替换为空属性
+func verifyMinimalProps(out string) error {
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		want := minimalProps[strings.ToLower(zf.Name)]
+		if want == "" {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if string(data) != want {
+			return fmt.Errorf("%s 仍包含属性", zf.Name)
+		}
+	}
+	return nil
+}
+
+// —— Word 修订与批注：作者匿名化、删除修订时间（--deep-office）——
+// 修订（w:ins/w:del/w:rPrChange…）可能出现在正文、页眉页脚、脚注尾注中，
+// 因此对 word/ 下所有 XML 部件（关系文件除外）做同样处理；修订结构本身保持不变。
+
+// anonAuthor 替换所有修订/批注作者，多位作者会合并为同一个名字
+const anonAuthor = "Author"
+
+func wordEdit(name string) func(r io.Reader, w io.Writer) error {
+	lower := strings.ToLower(name)
+	if !strings.HasPrefix(lower, "word/") || !strings.HasSuffix(lower, ".xml") || strings.Contains(lower, "/_rels/") {
+		return nil
+	}
+	if lower == "word/people.xml" {
+		return xmlEditor(anonymizeWordPeople)
+	}
+	return xmlEditor(anonymizeWordAuthors)
+}
+
+// anonymizeWordAuthors 处理 w:author、w:initials 与 w:date。
+// Word 始终以 w 作为主命名空间前缀，这里直接按前缀匹配。
+func anonymizeWordAuthors(el *xml.StartElement) {
+	attrs := el.Attr[:0]
+	for _, a := range el.Attr {
+		if a.Name.Space == "w" {
+			switch a.Name.Local {
+			case "author":
+				a.Value = anonAuthor
+			case "initials":
+				a.Value = anonAuthor[:1]
+			case "date":
+				continue
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	el.Attr = attrs
+}
+
+// anonymizeWordPeople 处理 word/people.xml：w15:person 的作者名与 presenceInfo 中的账号
+func anonymizeWordPeople(el *xml.StartElement) {
+	for i, a := range el.Attr {
+		switch a.Name.Local {
+		case "author":
+			el.Attr[i].Value = anonAuthor
+		case "userId":
+			el.Attr[i].Value = anonAuthor
+		case "providerId":
+			el.Attr[i].Value = "None" // Word 对本地账户写入的取值
+		}
+	}
+}
+
+// —— OpenDocument: 删除根目录 meta.xml（其余见 odf.go）——
+func (s *Scrubber) scrubOpenDocument(path, dst string) error {
+	return s.rewriteZip(path, dst, s.keepOpenDocEntry, s.nestedEdit(s.openDocEdit(), 0, newZipBudget()))
+}
+
+// dosTime 将 t 转换为 zip 头中的 MS-DOS 时间与日期（精度 2 秒，1980 年以前按 1980-01-01 处理）
+func dosTime(t time.Time) (uint16, uint16) {
+	if t.Before(zipEpoch) {
+		t = zipEpoch
+	}
+	return uint16(t.Hour()<<11 | t.Minute()<<5 | t.Second()>>1),
+		uint16((t.Year()-1980)<<9 | int(t.Month())<<5 | t.Day())
+}
+
+// —— 条目扩展字段 ——
+// 源条目的扩展字段从不复制（writeZip 总是新建 FileHeader）：Linux 上打包的 zip 带有 0x7875 字段，
+// 记录打包用户的 UID/GID；Windows 工具写出的 0x000a 字段含 NTFS 创建、访问与修改时间，都随之丢弃。
+// archive/zip 在设置 Modified 时会自行写出只含修改时间的 0x5455 扩展时间戳；StripZipExtra 时改用 DOS 时间
+// （精度 2 秒，不含时区），输出条目不带任何扩展字段。
+
+// zipExtraNames 为 dry-run 列出、--verify 检查的扩展字段
+var zipExtraNames = map[uint16]string{
+	0x000a: "NTFS 时间戳",
+	0x5855: "Unix 时间与 UID/GID（旧格式）",
+	0x7855: "Unix UID/GID（旧格式）",
+	0x7875: "Unix UID/GID",
+}
+
+// zipExtraIDs 返回扩展字段数据中依次出现的字段标识；结构损坏时只返回已解析的部分
+func zipExtraIDs(extra []byte) []uint16 {
+	var ids []uint16
+	for len(extra) >= 4 {
+		ids = append(ids, binary.LittleEndian.Uint16(extra))
+		n := 4 + int(binary.LittleEndian.Uint16(extra[2:]))
+		if n > len(extra) {
+			break
+		}
+		extra = extra[n:]
+	}
+	return ids
+}
+
+// —— --deterministic：条目顺序与源归档无关 ——
+// 同样的内容由不同工具或不同次保存写出时，条目顺序可能不同。按名称字节序排序后，
+// 只要保留下来的内容相同，输出就逐字节相同（时间另由 zipEpoch 固定）。
+// mimetype 必须是第一个条目（EPUB/ODF），因此始终排在最前。
+// 压缩方式由 entryMethod 按条目决定，Deflate 固定使用 compress/flate 的默认级别，同一 Go 版本下输出只取决于输入。
+func canonicalZipOrder(files []*zip.File) []*zip.File {
+	sorted := append([]*zip.File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Name, sorted[j].Name
+		if (a == "mimetype") != (b == "mimetype") {
+			return a == "mimetype"
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// —— 压缩方式（CompressionMethod）——
+// 默认沿用源条目的压缩方式。部分导出工具对 XML 部件也使用 Store，输出因此偏大：
+// deflate 对文本等部件统一使用 Deflate，已经压缩过的媒体（图片、音视频、嵌套归档等）沿用源方式，再压缩只会白费 CPU；
+// store 全部不压缩，便于差异比较或交给外层再压缩。mimetype 必须不压缩（EPUB/ODF），目录条目没有内容，两者始终 Store。
+
+// compressedExts 为本身已经压缩、Deflate 几乎无收益的条目扩展名
+var compressedExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".heic": true, ".wdp": true,
+	".mp3": true, ".m4a": true, ".mp4": true, ".mov": true, ".wmv": true,
+	".zip": true, ".gz": true, ".7z": true, ".iwa": true,
+}
+
+// entryMethod 返回条目 zf 在输出中的压缩方式
+func (s *Scrubber) entryMethod(zf *zip.File) uint16 {
+	if s.CompressionMethod == "" || s.CompressionMethod == "keep" {
+		return zf.Method
+	}
+	if zf.Name == "mimetype" || strings.HasSuffix(zf.Name, "/") || s.CompressionMethod == "store" {
+		return zip.Store
+	}
+	if compressedExts[strings.ToLower(path.Ext(zf.Name))] {
+		return zf.Method
+	}
+	return zip.Deflate
+}
+
+// —— ZIP 重写通用函数 ——
+func (s *Scrubber) rewriteZip(path, dst string, keep func(name string) bool, edit zipEdit) error {
+	keep = s.dropMacEntries(keep)
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		// 经由中央目录按条目从磁盘读取，不把整个归档读入内存
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		info, err := in.Stat()
+		if err != nil {
+			return err
+		}
+		return s.scrubZipStream(in, info.Size(), w, keep, edit)
+	})
+}
+
+// scrubZipStream 读取长度为 size 的归档 r，把保留的条目（经 edit 改写后）写到 w，不涉及文件路径；
+// rewriteZip 在其外层负责临时文件与替换
+func (s *Scrubber) scrubZipStream(r io.ReaderAt, size int64, w io.Writer, keep func(name string) bool, edit zipEdit) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	return s.writeZip(zr, w, keep, edit)
+}
+
+// writeZip 将 zr 中保留的条目（经 edit 改写后）写成新的归档；嵌套归档也复用它在内存中处理
+func (s *Scrubber) writeZip(zr *zip.Reader, out io.Writer, keep func(name string) bool, edit zipEdit) error {
+	zw := zip.NewWriter(out)
+	// 归档注释可能含导出工具的构建标识等信息，始终置空
+	if err := zw.SetComment(""); err != nil {
+		return err
+	}
+
+	files := zr.File
+	if s.Deterministic {
+		files = canonicalZipOrder(files)
+	}
+	seen := map[string]bool{}
+	for _, zf := range files {
+		name, err := s.zipEntryName(zf.Name)
+		if err != nil {
+			return err
+		}
+		if !keep(name) {
+			continue
+		}
+		if seen[name] {
+			// 规范化后重名（如 a\b 与 a/b），写出两个同名条目会让解压结果取决于工具
+			return fmt.Errorf("条目名重复: %s", name)
+		}
+		seen[name] = true
+
+		// 打开源条目
+		r, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("读取条目失败 %s: %w", zf.Name, err)
+		}
+		// 创建目标条目，尽量保留压缩方式；不复制 zf.Comment 与 zf.Extra，条目注释与扩展字段随之丢弃
+		h := &zip.FileHeader{Name: name, Method: s.entryMethod(zf)}
+		h.SetMode(zf.Mode())
+		h.Modified = zf.Modified
+		if s.ZeroTimestamps || s.Deterministic {
+			// 条目时间往往等于保存时间，统一改为固定值以消除时间指纹
+			h.Modified = zipEpoch
+		}
+		if name == "mimetype" || s.StripZipExtra {
+			// EPUB/ODF 要求 mimetype 条目不带扩展字段，而设置 Modified 会写出扩展时间戳字段，改用 DOS 时间
+			h.ModifiedTime, h.ModifiedDate = dosTime(h.Modified)
+			h.Modified = time.Time{}
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		var fn func(r io.Reader, w io.Writer) error
+		if edit != nil {
+			fn = edit(name)
+		}
+		if fn != nil {
+			err = fn(r, w)
+		} else {
+			_, err = io.Copy(w, r)
+		}
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,388 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeepOfficeAnonymizesTrackedChanges(t *testing.T) {
+	const (
+		author = "Bob Reviewer"
+		date   = "2024-03-04T05:06:07Z"
+	)
+	change := `<w:ins w:id="1" w:author="` + author + `" w:date="` + date + `"><w:r><w:t>added</w:t></w:r></w:ins>` +
+		`<w:del w:id="2" w:author="` + author + `" w:date="` + date + `"><w:r><w:delText>removed</w:delText></w:r></w:del>`
+	data := zipBytes(t, testDocx(`<w:p>`+change+`</w:p>`,
+		"word/comments.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:comments `+testWordNS+`>`+
+			`<w:comment w:id="0" w:author="`+author+`" w:initials="BR" w:date="`+date+`"><w:p>`+change+`</w:p></w:comment></w:comments>`,
+		"word/footnotes.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:footnotes `+testWordNS+`>`+
+			`<w:footnote w:id="1"><w:p>`+change+`</w:p></w:footnote></w:footnotes>`,
+	)...)
+	p := writeTestFile(t, t.TempDir(), "a.docx", data)
+
+	s := newTestScrubber()
+	s.DeepOffice = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readZip(t, p)
+	for _, name := range []string{"word/document.xml", "word/comments.xml", "word/footnotes.xml"} {
+		if !strings.Contains(parts[name], `w:author="`+anonAuthor+`"`) {
+			t.Errorf("%s 中的修订应保留并改为匿名作者", name)
+		}
+	}
+	for name, content := range parts {
+		for _, leak := range []string{author, `"BR"`, date, "Alice Secret"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+	if !strings.Contains(parts["word/document.xml"], "<w:delText>removed</w:delText>") {
+		t.Error("修订内容本身应保持不变")
+	}
+}
+
+func TestRewriteZipDropsComments(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a.xml", "b.xml"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Comment: "entry by Alice Secret"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, "<x/>")
+	}
+	if err := zw.SetComment("exported by BuildBot-1234"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	p := writeTestFile(t, dir, "a.zip", buf.Bytes())
+	dst := filepath.Join(dir, "out.zip")
+
+	s := newTestScrubber()
+	if err := s.rewriteZip(p, dst, func(string) bool { return true }, nil); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if zr.Comment != "" {
+		t.Errorf("归档注释 %q, 期望为空", zr.Comment)
+	}
+	if len(zr.File) != 2 {
+		t.Fatalf("条目数 %d, 期望 2", len(zr.File))
+	}
+	for _, f := range zr.File {
+		if f.Comment != "" {
+			t.Errorf("%s 的条目注释 %q, 期望为空", f.Name, f.Comment)
+		}
+	}
+	if out, _ := os.ReadFile(dst); bytes.Contains(out, []byte("Alice Secret")) || bytes.Contains(out, []byte("BuildBot")) {
+		t.Error("输出仍含有注释内容")
+	}
+}
+
+func TestVisioPackageStaysValid(t *testing.T) {
+	const (
+		relNS = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+		relT  = "http://schemas.microsoft.com/visio/2010/relationships/"
+	)
+	data := zipBytes(t,
+		"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/>`+
+			`<Override PartName="/visio/document.xml" ContentType="application/vnd.ms-visio.drawing.main+xml"/>`+
+			`<Override PartName="/visio/comments.xml" ContentType="application/vnd.ms-visio.comments+xml"/>`+
+			`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`+
+			`<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/></Types>`,
+		"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships `+relNS+`>`+
+			`<Relationship Id="rId1" Type="`+relT+`document" Target="visio/document.xml"/>`+
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>`+
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/></Relationships>`,
+		"docProps/core.xml", testCore,
+		"docProps/app.xml", `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Company>ACME Corp</Company></Properties>`,
+		"visio/document.xml", `<VisioDocument xmlns="http://schemas.microsoft.com/office/visio/2012/main"/>`,
+		"visio/_rels/document.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships `+relNS+`>`+
+			`<Relationship Id="rId1" Type="`+relT+`comments" Target="comments.xml"/></Relationships>`,
+		"visio/comments.xml", `<Comments xmlns="http://schemas.microsoft.com/office/visio/2012/main"><AuthorList>`+
+			`<AuthorEntry ID="0" Name="Bob Reviewer" Initials="BR"/></AuthorList></Comments>`,
+	)
+	p := writeTestFile(t, t.TempDir(), "diagram.vsdx", data)
+	if ext, _ := newTestScrubber().effectiveExt(p); ext != ".vsdx" {
+		t.Fatalf("识别为 %s, 期望 .vsdx", ext)
+	}
+
+	s := newTestScrubber()
+	s.Verify = true
+	s.DeepOffice = true
+	s.DerefContentTypes = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOPCRefs(p); err != nil {
+		t.Errorf("输出中有悬空的引用: %v", err)
+	}
+	names, parts := readZip(t, p)
+	for _, name := range names {
+		if strings.HasPrefix(name, "docProps/") || name == "visio/comments.xml" {
+			t.Errorf("%s 未被删除", name)
+		}
+	}
+	if _, ok := parts["visio/document.xml"]; !ok {
+		t.Error("visio/document.xml 应保留")
+	}
+	if !strings.Contains(parts["_rels/.rels"], `Target="visio/document.xml"`) {
+		t.Error("指向主文档的关系应保留")
+	}
+	for name, content := range parts {
+		for _, leak := range []string{"Alice Secret", "ACME", "Bob Reviewer", "comments.xml"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+}
+
+func TestKeepMinimalProps(t *testing.T) {
+	app := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+		`<Application>Microsoft Office Word</Application><Company>ACME Corp</Company><Manager>Carol Boss</Manager><TotalTime>42</TotalTime></Properties>`
+	data := zipBytes(t, testDocx(`<w:p/>`, "docProps/app.xml", app, "docProps/custom.xml", `<Properties><property name="Client">Secret Client</property></Properties>`)...)
+	p := writeTestFile(t, t.TempDir(), "a.docx", data)
+
+	s := newTestScrubber()
+	s.KeepMinimalProps = true
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readZip(t, p)
+	for _, name := range []string{"docProps/core.xml", "docProps/app.xml"} {
+		content, ok := parts[name]
+		if !ok {
+			t.Fatalf("%s 应保留", name)
+		}
+		if content != minimalProps[strings.ToLower(name)] {
+			t.Errorf("%s 应替换为空属性，实际: %s", name, content)
+		}
+		// 根元素与命名空间必须符合规范，严格的查看器才会接受
+		var root struct{ XMLName xml.Name }
+		if err := xml.Unmarshal([]byte(content), &root); err != nil {
+			t.Errorf("%s 不是合法的 XML: %v", name, err)
+		}
+		want := map[string]xml.Name{
+			"docProps/core.xml": {Space: "http://schemas.openxmlformats.org/package/2006/metadata/core-properties", Local: "coreProperties"},
+			"docProps/app.xml":  {Space: "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties", Local: "Properties"},
+		}[name]
+		if root.XMLName != want {
+			t.Errorf("%s 的根元素为 %v, 期望 %v", name, root.XMLName, want)
+		}
+	}
+	if _, ok := parts["docProps/custom.xml"]; ok {
+		t.Error("docProps/custom.xml 仍应删除")
+	}
+	for name, content := range parts {
+		for _, leak := range []string{"Alice Secret", "ACME", "Carol", "Secret Client", "Microsoft Office", "TotalTime"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+	if !strings.Contains(parts["_rels/.rels"], `Target="docProps/core.xml"`) {
+		t.Error("指向保留部件的关系应保留")
+	}
+}
+
+// zipEntry 为 zipHeaders 写出的一个条目
+type zipEntry struct {
+	h    zip.FileHeader
+	data string
+}
+
+// zipHeaders 按给定的头部（压缩方式、时间、扩展字段等）写出归档
+func zipHeaders(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := e.h
+		w, err := zw.CreateHeader(&h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testDocxEntries 返回 testDocx 的条目，压缩方式均为 method
+func testDocxEntries(method uint16, body string, extra ...string) []zipEntry {
+	parts := testDocx(body, extra...)
+	var entries []zipEntry
+	for i := 0; i+1 < len(parts); i += 2 {
+		entries = append(entries, zipEntry{zip.FileHeader{Name: parts[i], Method: method}, parts[i+1]})
+	}
+	return entries
+}
+
+// uidExtra 为 Linux 打包工具写出的 0x7875 扩展字段（UID/GID 各 4 字节）
+var uidExtra = []byte{0x75, 0x78, 11, 0, 1, 4, 0xe8, 3, 0, 0, 4, 0xe8, 3, 0, 0}
+
+func TestDeterministicOutput(t *testing.T) {
+	entries := testDocxEntries(zip.Deflate, `<w:p><w:r><w:t>hello</w:t></w:r></w:p>`)
+	// 同样的内容由另一次保存写出：条目顺序相反，时间与扩展字段不同
+	other := make([]zipEntry, len(entries))
+	for i, e := range entries {
+		e.h.Modified = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC).Add(time.Duration(i) * time.Hour)
+		e.h.Extra = uidExtra
+		other[len(entries)-1-i] = e
+	}
+	for i := range entries {
+		entries[i].h.Modified = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	dir := t.TempDir()
+	s := newTestScrubber()
+	s.Deterministic = true
+	var outs [][]byte
+	for i, data := range [][]byte{zipHeaders(t, entries...), zipHeaders(t, entries...), zipHeaders(t, other...)} {
+		p := writeTestFile(t, dir, fmt.Sprintf("%d.docx", i), data)
+		if err := s.ScrubFile(p); err != nil {
+			t.Fatal(err)
+		}
+		out, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outs = append(outs, out)
+	}
+	if !bytes.Equal(outs[0], outs[1]) {
+		t.Error("同一输入处理两次，输出不同")
+	}
+	if !bytes.Equal(outs[0], outs[2]) {
+		t.Error("内容相同、条目顺序与时间不同的输入，输出不同")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(outs[0]), int64(len(outs[0])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range zr.File {
+		if i > 0 && zr.File[i-1].Name >= f.Name {
+			t.Errorf("条目未按名称排序: %s 在 %s 之后", f.Name, zr.File[i-1].Name)
+		}
+		if !f.Modified.Equal(zipEpoch) {
+			t.Errorf("%s 的修改时间 %v, 期望 %v", f.Name, f.Modified, zipEpoch)
+		}
+	}
+}
+
+func TestCompressionMethod(t *testing.T) {
+	// 导出工具对 XML 部件也使用 Store 的文档
+	body := strings.Repeat(`<w:p><w:r><w:t>repeated paragraph text</w:t></w:r></w:p>`, 500)
+	png := string(bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 256))
+	data := zipHeaders(t, append(testDocxEntries(zip.Store, body),
+		zipEntry{zip.FileHeader{Name: "word/media/image1.png", Method: zip.Store}, png},
+		zipEntry{zip.FileHeader{Name: "word/media/image2.jpeg", Method: zip.Deflate}, png},
+	)...)
+
+	dir := t.TempDir()
+	sizes := map[string]int64{}
+	for _, method := range []string{"keep", "deflate", "store"} {
+		p := writeTestFile(t, dir, method+".docx", data)
+		s := newTestScrubber()
+		s.CompressionMethod = method
+		if err := s.ScrubFile(p); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[method] = info.Size()
+
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range zr.File {
+			want := map[string]uint16{"keep": zip.Store, "deflate": zip.Deflate, "store": zip.Store}[method]
+			switch {
+			case f.Name == "word/media/image2.jpeg" && method != "store":
+				want = zip.Deflate // 已经压缩过的媒体沿用源方式
+			case f.Name == "word/media/image1.png":
+				want = zip.Store
+			}
+			if f.Method != want {
+				t.Errorf("%s: %s 的压缩方式 %d, 期望 %d", method, f.Name, f.Method, want)
+			}
+		}
+		zr.Close()
+	}
+	if sizes["deflate"] >= sizes["store"] {
+		t.Errorf("deflate 输出 %d 字节，不小于 store 输出的 %d 字节", sizes["deflate"], sizes["store"])
+	}
+	if sizes["deflate"]*4 > int64(len(data)) {
+		t.Errorf("deflate 输出 %d 字节，相对输入的 %d 字节几乎没有缩小", sizes["deflate"], len(data))
+	}
+}
+
+func TestStripZipExtra(t *testing.T) {
+	// Linux 上打包的条目带 UID/GID，Windows 工具写出的条目带 NTFS 时间戳
+	ntfsExtra := append([]byte{0x0a, 0, 32, 0, 0, 0, 0, 0, 1, 0, 24, 0}, make([]byte, 24)...)
+	entries := testDocxEntries(zip.Deflate, `<w:p/>`)
+	for i := range entries {
+		entries[i].h.Modified = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+		entries[i].h.Extra = uidExtra
+		if i%2 == 1 {
+			entries[i].h.Extra = ntfsExtra
+		}
+	}
+	data := zipHeaders(t, entries...)
+
+	for _, strip := range []bool{false, true} {
+		p := writeTestFile(t, t.TempDir(), "a.docx", data)
+		s := newTestScrubber()
+		s.StripZipExtra = strip
+		s.Verify = true
+		if err := s.ScrubFile(p); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range zr.File {
+			ids := zipExtraIDs(f.Extra)
+			for _, id := range ids {
+				if name, ok := zipExtraNames[id]; ok {
+					t.Errorf("%s 仍带有扩展字段 %s", f.Name, name)
+				}
+			}
+			if strip && len(f.Extra) != 0 {
+				t.Errorf("StripZipExtra 时 %s 的扩展字段应为空，实际: % x", f.Name, f.Extra)
+			}
+			if want := time.Date(2024, 5, 6, 7, 8, 8, 0, time.UTC); strip && !f.Modified.Equal(want) {
+				t.Errorf("%s 的修改时间 %v, 期望保留为 DOS 时间 %v", f.Name, f.Modified, want)
+			}
+		}
+		zr.Close()
+	}
+}
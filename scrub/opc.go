@@ -0,0 +1,118 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// —— OPC 关系与内容类型：删除部件后去掉对它的引用 ——
+// Office 文档内部的部件（批注、备注页、计算链等）由 *.rels 中的 Relationship 引用，
+// 并在 [Content_Types].xml 中以 Override 声明类型。只删部件不删引用时，Office 会提示“发现无法读取的内容”并要求修复，
+// 因此删除这类部件时同步去掉指向它的关系与类型声明。docProps/ 的引用只在 DerefContentTypes 时清理（见 droppedPart）。
+
+// relsBase 返回 .rels 中相对 Target 的解析基准目录：
+// ppt/slides/_rels/slide1.xml.rels -> ppt/slides，_rels/.rels -> 包根目录
+func relsBase(name string) string {
+	dir := path.Dir(name) // .../_rels
+	return strings.TrimSuffix(path.Dir(dir), ".")
+}
+
+// resolveTarget 将 Relationship 的 Target 解析为包内部件路径（以 / 开头的为包内绝对路径）
+func resolveTarget(base, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(target[1:])
+	}
+	return path.Clean(path.Join(base, target))
+}
+
+// refsEdit 返回改写函数：*.rels 中删除 Target 指向 dropped 部件的 Relationship，
+// [Content_Types].xml 中删除 PartName 为 dropped 部件的 Override；其余条目返回 nil
+func refsEdit(dropped func(part string) bool) zipEdit {
+	return func(name string) func(r io.Reader, w io.Writer) error {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "[content_types].xml":
+			return xmlDropper(func(el xml.StartElement) bool {
+				return el.Name.Local == "Override" && dropped(strings.TrimPrefix(xmlAttr(el, "PartName"), "/"))
+			})
+		case strings.HasSuffix(lower, ".rels"):
+			base := relsBase(name)
+			return xmlDropper(func(el xml.StartElement) bool {
+				return el.Name.Local == "Relationship" && xmlAttr(el, "TargetMode") != "External" &&
+					dropped(resolveTarget(base, xmlAttr(el, "Target")))
+			})
+		}
+		return nil
+	}
+}
+
+// verifyOPCRefs 检查 *.rels 中的内部关系与 [Content_Types].xml 中的 Override 都指向包内存在的部件
+func verifyOPCRefs(p string) error {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	parts := map[string]bool{}
+	for _, zf := range zr.File {
+		parts[strings.ToLower(zf.Name)] = true
+	}
+	exists := func(part string) bool {
+		if u, err := url.PathUnescape(part); err == nil {
+			part = u
+		}
+		return parts[strings.ToLower(part)]
+	}
+
+	for _, zf := range zr.File {
+		lower := strings.ToLower(zf.Name)
+		var check func(el *xml.StartElement) string
+		switch {
+		case lower == "[content_types].xml":
+			check = func(el *xml.StartElement) string {
+				if pn := xmlAttr(*el, "PartName"); el.Name.Local == "Override" && !exists(strings.TrimPrefix(pn, "/")) {
+					return pn
+				}
+				return ""
+			}
+		case strings.HasSuffix(lower, ".rels"):
+			base := relsBase(zf.Name)
+			check = func(el *xml.StartElement) string {
+				if el.Name.Local != "Relationship" || xmlAttr(*el, "TargetMode") == "External" {
+					return ""
+				}
+				target, _, _ := strings.Cut(xmlAttr(*el, "Target"), "#")
+				if target != "" && !exists(resolveTarget(base, target)) {
+					return target
+				}
+				return ""
+			}
+		default:
+			continue
+		}
+
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		var dangling string
+		err = rewriteXML(r, io.Discard, func(el *xml.StartElement) {
+			if dangling == "" {
+				dangling = check(el)
+			}
+		})
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+		if dangling != "" {
+			return fmt.Errorf("%s 引用了不存在的部件 %s", zf.Name, dangling)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package scrub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveTarget(t *testing.T) {
+	for _, c := range []struct{ rels, target, want string }{
+		{"_rels/.rels", "word/document.xml", "word/document.xml"},
+		{"_rels/.rels", "/docProps/app.xml", "docProps/app.xml"},
+		{"word/_rels/document.xml.rels", "comments.xml", "word/comments.xml"},
+		{"word/_rels/document.xml.rels", "../customXml/item1.xml", "customXml/item1.xml"},
+		{"ppt/slides/_rels/slide1.xml.rels", "../comments/comment1.xml", "ppt/comments/comment1.xml"},
+	} {
+		if got := resolveTarget(relsBase(c.rels), c.target); got != c.want {
+			t.Errorf("%s 中的 %s 解析为 %s, 期望 %s", c.rels, c.target, got, c.want)
+		}
+	}
+}
+
+func TestDerefContentTypesLeavesNoDanglingTargets(t *testing.T) {
+	const relNS = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+	rel := func(id, target string, extra ...string) string {
+		return `<Relationship Id="` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/x" Target="` + target + `"` + strings.Join(extra, "") + `/>`
+	}
+	data := zipBytes(t,
+		"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/>`+
+			`<Override PartName="/word/document.xml" ContentType="a"/><Override PartName="/docProps/core.xml" ContentType="b"/>`+
+			`<Override PartName="/docProps/app.xml" ContentType="c"/><Override PartName="/docProps/custom.xml" ContentType="d"/>`+
+			`<Override PartName="/customXml/item1.xml" ContentType="e"/><Override PartName="/word/comments.xml" ContentType="f"/></Types>`,
+		"_rels/.rels", `<Relationships `+relNS+`>`+rel("rId1", "word/document.xml")+rel("rId2", "docProps/core.xml")+
+			rel("rId3", "/docProps/app.xml")+rel("rId4", "./docProps/custom.xml")+`</Relationships>`,
+		"docProps/core.xml", testCore,
+		"docProps/app.xml", `<Properties/>`,
+		"docProps/custom.xml", `<Properties/>`,
+		"customXml/item1.xml", `<data>tenant-secret</data>`,
+		"word/document.xml", `<w:document `+testWordNS+`><w:body/></w:document>`,
+		"word/comments.xml", `<w:comments `+testWordNS+`/>`,
+		"word/_rels/document.xml.rels", `<Relationships `+relNS+`>`+rel("rId1", "../customXml/item1.xml")+rel("rId2", "comments.xml")+
+			rel("rId3", "https://intranet.example/docs/", ` TargetMode="External"`)+`</Relationships>`,
+	)
+
+	// 默认只删除 docProps/ 部件，保留对它们的引用
+	p := writeTestFile(t, t.TempDir(), "a.docx", data)
+	s := newTestScrubber()
+	s.DeepOffice = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOPCRefs(p); err == nil || !strings.Contains(err.Error(), "docProps/") {
+		t.Errorf("未开启 DerefContentTypes 时应保留对 docProps/ 的引用, err = %v", err)
+	}
+
+	p = writeTestFile(t, t.TempDir(), "a.docx", data)
+	s.DerefContentTypes = true
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOPCRefs(p); err != nil {
+		t.Errorf("仍有悬空的引用: %v", err)
+	}
+	_, parts := readZip(t, p)
+	for _, leak := range []string{"docProps", "customXml"} {
+		for name, content := range parts {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍引用 %s", name, leak)
+			}
+		}
+	}
+	for name, want := range map[string]string{
+		"_rels/.rels":                  `Target="word/document.xml"`,
+		"word/_rels/document.xml.rels": `Target="comments.xml"`,
+		"[Content_Types].xml":          `PartName="/word/comments.xml"`,
+	} {
+		if !strings.Contains(parts[name], want) {
+			t.Errorf("%s 中缺少仍然有效的 %s", name, want)
+		}
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], "https://intranet.example/docs/") {
+		t.Error("外部关系不指向包内部件，应保留")
+	}
+}
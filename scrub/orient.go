@@ -0,0 +1,107 @@
+package scrub
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+)
+
+// —— EXIF 方向：重新编码前把旋转/翻转烘焙进像素 ——
+// 手机照片常以传感器方向存储像素，再用 EXIF Orientation 告诉查看器如何旋转。
+// 重新编码会丢弃 EXIF，输出就会横躺或镜像；因此先读取方向，按 8 种取值旋转/翻转解码后的图像，
+// 输出不带任何元数据也能正确显示。只用于整体重新编码的 JPEG 与 TIFF：
+// lossless、selective 与 gps 模式保留 Orientation 字段、像素不变，PNG/WebP 在块层处理、不解码。
+
+const tagOrientation = 0x0112
+
+// bakesOrientation 判断该类型处理时是否会把方向烘焙进像素
+func (s *Scrubber) bakesOrientation(ext string) bool {
+	switch ext {
+	case ".tif", ".tiff":
+		return s.StripMode != "gps"
+	case ".jpg", ".jpeg":
+		return s.jpegMode() == "full"
+	}
+	return false
+}
+
+// imageOrientation 返回 JPEG（APP1 中的 EXIF）或 TIFF（IFD0）的方向取值，读不到或取值非法时返回 1
+func imageOrientation(data []byte, ext string) int {
+	tiffData := data
+	if ext == ".jpg" || ext == ".jpeg" {
+		tiffData = nil
+		segs, _, err := splitJPEG(data)
+		if err != nil {
+			return 1
+		}
+		for _, s := range segs {
+			if s.marker == markerAPP1 && bytes.HasPrefix(s.data, exifHeader) {
+				tiffData = s.data[len(exifHeader):]
+				break
+			}
+		}
+	}
+	t, ifd0, err := newTIFFBlock(tiffData)
+	if err != nil {
+		return 1
+	}
+	p := t.find(ifd0, tagOrientation)
+	if p < 0 {
+		return 1
+	}
+	if o := int(t.bo.Uint16(t.b[p+8 : p+10])); o >= 1 && o <= 8 {
+		return o
+	}
+	return 1
+}
+
+// orient 按 EXIF 方向 o 旋转/翻转 img，返回按正确方向显示的新图像；o 为 1 或非法时原样返回。
+// 灰度图保持灰度，其余格式转为 RGBA
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	ow, oh := w, h
+	if o >= 5 {
+		ow, oh = h, w
+	}
+
+	var src, dst []byte
+	var sStride, dStride, bpp int
+	var out image.Image
+	if _, ok := img.(*image.Gray); ok {
+		s, d := image.NewGray(image.Rect(0, 0, w, h)), image.NewGray(image.Rect(0, 0, ow, oh))
+		draw.Draw(s, s.Bounds(), img, b.Min, draw.Src)
+		src, sStride, dst, dStride, bpp, out = s.Pix, s.Stride, d.Pix, d.Stride, 1, d
+	} else {
+		s, d := image.NewRGBA(image.Rect(0, 0, w, h)), image.NewRGBA(image.Rect(0, 0, ow, oh))
+		draw.Draw(s, s.Bounds(), img, b.Min, draw.Src)
+		src, sStride, dst, dStride, bpp, out = s.Pix, s.Stride, d.Pix, d.Stride, 4, d
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // 水平镜像
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直镜像
+				dx, dy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90°
+				dx, dy = h-1-y, x
+			case 7: // 沿副对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转 90°
+				dx, dy = y, w-1-x
+			}
+			copy(dst[dy*dStride+dx*bpp:dy*dStride+dx*bpp+bpp], src[y*sStride+x*bpp:y*sStride+x*bpp+bpp])
+		}
+	}
+	return out
+}
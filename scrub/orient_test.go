@@ -0,0 +1,60 @@
+package scrub
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"testing"
+)
+
+// exifSeg 返回以 ifds 为内容的 EXIF APP1 段
+func exifSeg(ifds ...[]tiffEntry) []byte {
+	return jpegSeg(markerAPP1, append(bytes.Clone(exifHeader), buildTIFF(nil, ifds...)...))
+}
+
+func TestOrientationBakedIntoPixels(t *testing.T) {
+	// 32×16：上半红、下半蓝；方向 6 表示显示时需顺时针旋转 90°
+	src := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := range 16 {
+		for x := range 32 {
+			c := color.RGBA{0, 0, 255, 255}
+			if y < 8 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	in := testJPEG(t, src, 90, exifSeg([]tiffEntry{tiffShort(tagOrientation, 6)}))
+	if o := imageOrientation(in, ".jpg"); o != 6 {
+		t.Fatalf("样本方向 %d, 期望 6", o)
+	}
+	p := writeTestFile(t, t.TempDir(), "a.jpg", in)
+	s := newTestScrubber()
+	s.StripMode = "full"
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Fatalf("尺寸 %d×%d, 期望宽高互换为 16×32", b.Dx(), b.Dy())
+	}
+	if o := imageOrientation(out, ".jpg"); o != 1 {
+		t.Errorf("输出方向 %d, 期望 1（无 EXIF）", o)
+	}
+	// 顺时针旋转后原来的上边位于右侧
+	if r, _, b, _ := img.At(13, 16).RGBA(); r < b {
+		t.Error("右侧应为原图上半部分的红色")
+	}
+	if r, _, b, _ := img.At(2, 16).RGBA(); r > b {
+		t.Error("左侧应为原图下半部分的蓝色")
+	}
+}
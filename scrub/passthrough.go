@@ -0,0 +1,56 @@
+package scrub
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// —— 原样复制不支持的文件（CopyUnsupported）——
+// 输出到独立目录时默认只写出处理过的文件。开启后，收集阶段遇到的不支持的文件在处理结束后
+// 按相同的相对路径原样复制到 OutputDir，输出目录即成为输入的完整镜像。
+// 只复制“类型不支持”的文件：被 include/exclude、路径过滤或 ExcludeDirs 排除的文件、超过大小上限的文件不复制；
+// 受支持但处理失败的文件也不复制（可用 QuarantineDir 集中复查），以免未脱敏的内容混入输出。
+// 复制同样先写临时文件再改名，沿用原文件的权限位；PreserveMtime 时保留修改时间。
+
+// passThrough 复制收集到的不支持的文件并计入 rep.Copied；dry-run 或已中断时不复制
+func (s *Scrubber) passThrough(ctx context.Context, root string, rep *Report) {
+	if s.DryRun || s.OutputDir == "" {
+		return
+	}
+	for _, p := range s.unsupp {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.copyThrough(p, underDir(s.OutputDir, p, root)); err != nil {
+			s.logger().Errorf("复制 %s 失败: %v", p, err)
+			continue
+		}
+		s.logger().Debugf("原样复制 %s", p)
+		rep.Copied++
+	}
+}
+
+// copyThrough 将 p 原样复制到 dst
+func (s *Scrubber) copyThrough(p, dst string) error {
+	in, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	err = s.writeThenReplace(p, dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if s.PreserveMtime {
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package scrub
+
+import "errors"
+
+// 加密 PDF 相关的错误，可用 errors.Is 判断，便于批量处理时统计需要密码的文件
+var (
+	ErrPDFPasswordRequired = errors.New("PDF 已加密，需要提供密码（--pdf-password）")
+	ErrPDFWrongPassword    = errors.New("PDF 密码错误")
+)
+
+func init() {
+	RegisterHandler(".pdf", handlerFuncs{
+		kind: "pdf",
+		scrub: func(s *Scrubber, p, dst, _ string) error {
+			if !s.WithPDF {
+				return errors.New("检测到 PDF，请使用 --with-pdf 以启用 PDF 脱敏（需要以 -tags withpdf 构建）")
+			}
+			return s.scrubPDF(p, dst)
+		},
+		inspect: func(s *Scrubber, p, _ string) ([]Finding, error) { return s.inspectPDF(p) },
+		verify:  func(s *Scrubber, out, _ string) error { return s.verifyPDF(out) },
+		info: HandlerInfo{
+			Method:  "经 pdfcpu 重写",
+			Removes: "Info 字典与 XMP（/Metadata）；可选附件与文档级 JavaScript、批注与表单填写值",
+			Flag:    "--with-pdf", BuildTag: "withpdf", Built: pdfBuilt,
+		},
+	})
+}
+
+// —— PDF：使用 pdfcpu 清除元数据 ——
+// 说明：
+//  1. pdfcpu 为可选依赖，需以 -tags withpdf 构建，实现见 pdf_pdfcpu.go；
+//     默认构建使用 pdf_stub.go，遇到 PDF 时返回明确的错误。
+//  2. 清除 Info 字典与 Catalog 中的 XMP（/Metadata），并经 pdfcpu 优化去除冗余对象。
+//  3. 某些加密/权限受限的 PDF 可能需要密码，本文未处理。
+func (s *Scrubber) scrubPDF(path, dst string) error {
+	return s.scrubPDFWithPDFCPU(path, dst)
+}
@@ -0,0 +1,194 @@
+//go:build withpdf
+
+package scrub
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// —— --pdf-strip-annotations：批注与表单填写值 ——
+// 批注、便笺、高亮等标记注释带有审阅者姓名（/T）、批注正文与修改时间；已填写的表单字段则保存着填写内容。
+// 开启后删除各页中除链接（Link）与表单控件（Widget）之外的全部注释，页面内容流保持不变；
+// 表单保留字段结构，只清除取值：删除字段的 /V、/RV，删除控件的外观流 /AP（其中画着填写后的文字），
+// 复选框与单选按钮的外观状态 /AS 置为 /Off，并设置 /NeedAppearances 让阅读器按空值重新生成外观。
+// 不做“压平”（把注释画进页面内容），那样批注内容仍会留在输出中。
+
+// keptPDFAnnots 为删除批注时保留的注释类型
+var keptPDFAnnots = map[string]bool{"Link": true, "Widget": true}
+
+// stripPDFAnnotations 在 ctx 中删除批注并清除表单字段的取值
+func stripPDFAnnotations(ctx *model.Context, root types.Dict) error {
+	if err := filterPDFAnnots(ctx, root, func(subtype string) bool { return !keptPDFAnnots[subtype] }); err != nil {
+		return err
+	}
+	form, err := ctx.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		return err
+	}
+	fields, err := ctx.DereferenceArray(form["Fields"])
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		clearPDFField(ctx, f, 0)
+	}
+	form["NeedAppearances"] = types.Boolean(true)
+	return nil
+}
+
+// clearPDFField 清除字段 o 及其子字段、控件的取值；depth 防止构造出的环
+func clearPDFField(ctx *model.Context, o types.Object, depth int) {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil || depth > 32 {
+		return
+	}
+	d.Delete("V")
+	d.Delete("RV")
+	if _, ok := d.Find("AS"); ok {
+		d["AS"] = types.Name("Off")
+	}
+	if st := d.Subtype(); st != nil && *st == "Widget" {
+		d.Delete("AP")
+	}
+	kids, _ := ctx.DereferenceArray(d["Kids"])
+	for _, kid := range kids {
+		clearPDFField(ctx, kid, depth+1)
+	}
+}
+
+// filterPDFAnnots 删除各页中 drop 返回 true 的注释
+func filterPDFAnnots(ctx *model.Context, root types.Dict, drop func(subtype string) bool) error {
+	for _, page := range pdfPages(ctx, root["Pages"], 0) {
+		annots, err := ctx.DereferenceArray(page["Annots"])
+		if err != nil || annots == nil {
+			continue
+		}
+		kept := types.Array{}
+		for _, a := range annots {
+			if d, err := ctx.DereferenceDict(a); err != nil || d == nil || !drop(pdfSubtype(d)) {
+				kept = append(kept, a)
+			}
+		}
+		switch {
+		case len(kept) == len(annots):
+		case len(kept) == 0:
+			page.Delete("Annots")
+		default:
+			page["Annots"] = kept
+		}
+	}
+	return nil
+}
+
+// eachPDFAnnot 依次以页码（从 1 开始）、类型与注释字典调用 fn
+func eachPDFAnnot(ctx *model.Context, root types.Dict, fn func(page int, subtype string, d types.Dict)) {
+	for i, page := range pdfPages(ctx, root["Pages"], 0) {
+		annots, _ := ctx.DereferenceArray(page["Annots"])
+		for _, a := range annots {
+			if d, err := ctx.DereferenceDict(a); err == nil && d != nil {
+				fn(i+1, pdfSubtype(d), d)
+			}
+		}
+	}
+}
+
+// pdfPages 按顺序返回页面树 o 下的全部页面字典。
+// 未经校验的 ctx（ReadContext 读取，供 verify 与 dry-run 使用）中 PageCount 为 0，
+// ctx.PageDict 无法使用，因此自行遍历；depth 防止构造出的环
+func pdfPages(ctx *model.Context, o types.Object, depth int) []types.Dict {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil || depth > 32 {
+		return nil
+	}
+	if t := d.Type(); t == nil || *t != "Pages" {
+		return []types.Dict{d}
+	}
+	var pages []types.Dict
+	kids, _ := ctx.DereferenceArray(d["Kids"])
+	for _, kid := range kids {
+		pages = append(pages, pdfPages(ctx, kid, depth+1)...)
+	}
+	return pages
+}
+
+func pdfSubtype(d types.Dict) string {
+	if st := d.Subtype(); st != nil {
+		return *st
+	}
+	return ""
+}
+
+// pdfAnnotationFindings 列出将被删除的批注（按页与类型计数，附作者）与已填写的表单字段，供 dry-run 使用
+func pdfAnnotationFindings(ctx *model.Context, root types.Dict) []Finding {
+	var fs []Finding
+	eachPDFAnnot(ctx, root, func(page int, subtype string, d types.Dict) {
+		if keptPDFAnnots[subtype] || subtype == "Popup" {
+			return
+		}
+		fd := Finding{Item: fmt.Sprintf("第 %d 页的 %s 批注", page, subtype)}
+		if o, err := ctx.Dereference(d["T"]); err == nil && o != nil {
+			if v, err := types.StringOrHexLiteral(o); err == nil && v != nil {
+				fd.Value = *v
+			}
+		}
+		fs = append(fs, fd)
+	})
+
+	form, err := ctx.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		return fs
+	}
+	fields, _ := ctx.DereferenceArray(form["Fields"])
+	filled := map[string]string{}
+	for _, f := range fields {
+		pdfFieldValues(ctx, f, "", filled, 0)
+	}
+	names := make([]string, 0, len(filled))
+	for n := range filled {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fs = append(fs, Finding{Item: "表单字段 " + n, Value: filled[n]})
+	}
+	return fs
+}
+
+// pdfFieldValues 收集字段 o 及其子字段中已填写的取值，键为以点连接的完整字段名
+func pdfFieldValues(ctx *model.Context, o types.Object, prefix string, out map[string]string, depth int) {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil || depth > 32 {
+		return
+	}
+	name := prefix
+	if t, err := ctx.Dereference(d["T"]); err == nil && t != nil {
+		if v, err := types.StringOrHexLiteral(t); err == nil && v != nil {
+			if name != "" {
+				name += "."
+			}
+			name += *v
+		}
+	}
+	if v, err := ctx.Dereference(d["V"]); err == nil && v != nil {
+		switch v := v.(type) {
+		case types.Name:
+			if v != "Off" {
+				out[name] = string(v)
+			}
+		default:
+			if s, err := types.StringOrHexLiteral(v); err == nil && s != nil && *s != "" {
+				out[name] = *s
+			} else if err != nil {
+				out[name] = v.String()
+			}
+		}
+	}
+	kids, _ := ctx.DereferenceArray(d["Kids"])
+	for _, kid := range kids {
+		pdfFieldValues(ctx, kid, name, out, depth+1)
+	}
+}
@@ -0,0 +1,397 @@
+//go:build withpdf
+
+package scrub
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const pdfBuilt = true
+
+func init() {
+	// 不读写用户目录下的 pdfcpu 配置，使用内置默认配置
+	api.DisableConfigDir()
+}
+
+func (s *Scrubber) scrubPDFWithPDFCPU(path, dst string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	ctx, err := s.readPDF(path, in, true)
+	in.Close()
+	if err != nil {
+		return err
+	}
+	if s.PDFDecrypt {
+		// 写出时丢弃加密密钥，输出为未加密的 PDF
+		ctx.Cmd = model.DECRYPT
+	}
+
+	// 1) Info 字典：整体丢弃（Title/Author/Subject/Keywords/Creator/Producer…），
+	//    pdfcpu 写出时只会补上自身的 Producer 与当前时间的 CreationDate/ModDate
+	ctx.Info = nil
+
+	// 2) XMP：删除 Catalog 中的 /Metadata 流
+	root, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("读取 PDF Catalog 失败: %w", err)
+	}
+	root.Delete("Metadata")
+
+	// 3) 可选：附件（EmbeddedFiles 与 FileAttachment 注释）与文档级 JavaScript
+	if s.PDFStripAttachments {
+		if err := stripPDFAttachments(ctx, root); err != nil {
+			return fmt.Errorf("删除 PDF 附件失败: %w", err)
+		}
+	}
+	// 4) 可选：批注与表单填写值
+	if s.PDFStripAnnotations {
+		if err := stripPDFAnnotations(ctx, root); err != nil {
+			return fmt.Errorf("删除 PDF 批注失败: %w", err)
+		}
+	}
+
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		if !s.Deterministic {
+			if err := api.WriteContext(ctx, w); err != nil {
+				return fmt.Errorf("写入 PDF 失败: %w", err)
+			}
+			return nil
+		}
+		// 先写入内存，固定日期与 /ID 后再落盘
+		var buf bytes.Buffer
+		if err := api.WriteContext(ctx, &buf); err != nil {
+			return fmt.Errorf("写入 PDF 失败: %w", err)
+		}
+		s.pinPDF(ctx, buf.Bytes(), path)
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// —— --pdf-strip-attachments：附件与文档级 JavaScript ——
+// 附件可能是整份未脱敏的原始文件（导出 PDF 时附带的 docx、表格），JavaScript 可能含内部地址或脚本作者信息。
+// 删除 Names 中的 EmbeddedFiles（连同文件集合视图 /Collection）与 JavaScript 名称树、
+// Catalog 中的 /AA 与 JavaScript 类型的 /OpenAction，以及各页中的 FileAttachment 注释；
+// 不再被引用的对象写出时由 pdfcpu 丢弃。
+
+// stripPDFAttachments 在 ctx 中删除附件与文档级 JavaScript
+func stripPDFAttachments(ctx *model.Context, root types.Dict) error {
+	if _, err := ctx.RemoveAttachments(nil); err != nil {
+		return err
+	}
+	if _, ok := root.Find("Names"); ok {
+		names, err := ctx.NamesDict()
+		if err != nil {
+			return err
+		}
+		if _, ok := names.Find("JavaScript"); ok {
+			delete(ctx.Names, "JavaScript")
+			if err := ctx.RemoveNameTree("JavaScript"); err != nil {
+				return err
+			}
+		}
+	}
+	if isPDFJavaScript(ctx, root["OpenAction"]) {
+		root.Delete("OpenAction")
+	}
+	root.Delete("AA")
+
+	return filterPDFAnnots(ctx, root, func(subtype string) bool { return subtype == "FileAttachment" })
+}
+
+// isPDFJavaScript 判断动作 o 是否为 JavaScript 动作
+func isPDFJavaScript(ctx *model.Context, o types.Object) bool {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return false
+	}
+	s := d.NameEntry("S")
+	return s != nil && *s == "JavaScript"
+}
+
+// pdfAttachmentFindings 列出附件与文档级 JavaScript，供 dry-run 使用
+func pdfAttachmentFindings(ctx *model.Context, root types.Dict) []Finding {
+	var fs []Finding
+	if names, ok := root.Find("Names"); ok {
+		if d, err := ctx.DereferenceDict(names); err == nil && d != nil {
+			for _, name := range pdfNameTreeKeys(ctx, d["EmbeddedFiles"], 0) {
+				fs = append(fs, Finding{Item: "附件", Value: name})
+			}
+			if _, ok := d.Find("JavaScript"); ok {
+				fs = append(fs, Finding{Item: "文档级 JavaScript（/Names /JavaScript）"})
+			}
+		}
+	}
+	if isPDFJavaScript(ctx, root["OpenAction"]) {
+		fs = append(fs, Finding{Item: "打开时执行的 JavaScript（/OpenAction）"})
+	}
+	if _, ok := root.Find("AA"); ok {
+		fs = append(fs, Finding{Item: "文档附加动作（/AA）"})
+	}
+	eachPDFAnnot(ctx, root, func(page int, subtype string, _ types.Dict) {
+		if subtype == "FileAttachment" {
+			fs = append(fs, Finding{Item: fmt.Sprintf("第 %d 页的附件注释", page)})
+		}
+	})
+	return fs
+}
+
+// pdfNameTreeKeys 返回名称树 o 中的全部键；depth 限制 /Kids 的嵌套层数，防止构造出的环
+func pdfNameTreeKeys(ctx *model.Context, o types.Object, depth int) []string {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil || depth > 32 {
+		return nil
+	}
+	var keys []string
+	if arr, err := ctx.DereferenceArray(d["Names"]); err == nil {
+		for i := 0; i+1 < len(arr); i += 2 {
+			if k, err := types.StringOrHexLiteral(arr[i]); err == nil && k != nil {
+				keys = append(keys, *k)
+			}
+		}
+	}
+	if kids, err := ctx.DereferenceArray(d["Kids"]); err == nil {
+		for _, kid := range kids {
+			keys = append(keys, pdfNameTreeKeys(ctx, kid, depth+1)...)
+		}
+	}
+	return keys
+}
+
+// —— --deterministic：固定 pdfcpu 写入的日期与文件标识 ——
+// pdfcpu 写出时总会把 Info 中的 CreationDate/ModDate 设为当前时间，并以当前时间的哈希作为 /ID 的第二项，
+// 没有选项可以关闭。这里在写出后原位改写：日期换成 pinnedPDFDate，变短的部分在右括号后以空格补齐；
+// /ID 的第二项换成对其余内容的哈希。所有字节偏移保持不变，交叉引用表仍然有效。
+// 加密输出中的日期是密文，无法改写，此时只固定 /ID 并输出警告。
+
+// pinnedPDFDate 与 zipEpoch 一致
+const pinnedPDFDate = "D:19800101000000Z"
+
+var pdfDateEntry = regexp.MustCompile(`/(?:CreationDate|ModDate)\s*\((D:[^)]*)\)`)
+
+// pinPDF 就地改写 pdfcpu 刚写出的 b，name 仅用于日志
+func (s *Scrubber) pinPDF(ctx *model.Context, b []byte, name string) {
+	if ctx.Info != nil && ctx.EncKey == nil {
+		if start, end := pdfObjectSpan(b, ctx.Info.ObjectNumber.Value()); start >= 0 {
+			obj := b[start:end]
+			for _, m := range pdfDateEntry.FindAllSubmatchIndex(obj, -1) {
+				date := obj[m[2]:m[3]]
+				n := copy(date, pinnedPDFDate)
+				// date 之后紧跟右括号：右括号前移，其后补空格
+				obj[m[2]+n] = ')'
+				for i := m[2] + n + 1; i <= m[3]; i++ {
+					obj[i] = ' '
+				}
+			}
+		}
+	} else if ctx.Info != nil {
+		s.logger().Warnf("%s: 加密 PDF 的 Info 日期无法固定，--deterministic 的输出不可复现", name)
+	}
+
+	if len(ctx.ID) != 2 {
+		return
+	}
+	fid, ok := ctx.ID[1].(types.HexLiteral)
+	if !ok || len(fid) != 2*md5.Size {
+		return
+	}
+	id := []byte("<" + string(fid) + ">")
+	// 先清零再对全文求哈希，结果只取决于其余内容
+	blank := bytes.Repeat([]byte("0"), len(fid))
+	for i := bytes.Index(b, id); i >= 0; i = bytes.Index(b, id) {
+		copy(b[i+1:], blank)
+	}
+	sum := md5.Sum(b)
+	pinned := []byte(strings.ToUpper(hex.EncodeToString(sum[:])))
+	zeroID := []byte("<" + string(blank) + ">")
+	for i := bytes.Index(b, zeroID); i >= 0; i = bytes.Index(b, zeroID) {
+		copy(b[i+1:], pinned)
+	}
+}
+
+// pdfObjectSpan 返回对象 num（第 0 代）在 b 中的范围，找不到时返回 -1, -1
+func pdfObjectSpan(b []byte, num int) (int, int) {
+	head := []byte(fmt.Sprintf("%d 0 obj", num))
+	for off := 0; ; {
+		i := bytes.Index(b[off:], head)
+		if i < 0 {
+			return -1, -1
+		}
+		i += off
+		// 确认是完整的对象号，而不是更长数字的后缀
+		if i == 0 || b[i-1] == '\n' || b[i-1] == '\r' || b[i-1] == ' ' {
+			end := bytes.Index(b[i:], []byte("endobj"))
+			if end < 0 {
+				return -1, -1
+			}
+			return i, i + end
+		}
+		off = i + len(head)
+	}
+}
+
+// verifyPDF 检查输出的 Info 字典只剩 pdfcpu 自动写入的字段，且 Catalog 中没有 XMP
+func (s *Scrubber) verifyPDF(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ctx, err := s.readPDF(path, f, false)
+	if err != nil {
+		return err
+	}
+	if ctx.Info != nil {
+		info, err := ctx.DereferenceDict(*ctx.Info)
+		if err != nil {
+			return fmt.Errorf("读取 PDF Info 失败: %w", err)
+		}
+		for k := range info {
+			switch k {
+			case "Producer", "CreationDate", "ModDate":
+			default:
+				return fmt.Errorf("Info 字典中仍包含 %s", k)
+			}
+		}
+	}
+	root, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("读取 PDF Catalog 失败: %w", err)
+	}
+	if _, ok := root.Find("Metadata"); ok {
+		return errors.New("Catalog 中仍包含 XMP 元数据")
+	}
+	if s.PDFStripAttachments {
+		if fs := pdfAttachmentFindings(ctx, root); len(fs) > 0 {
+			return fmt.Errorf("仍包含%s", fs[0].Item)
+		}
+	}
+	if s.PDFStripAnnotations {
+		if fs := pdfAnnotationFindings(ctx, root); len(fs) > 0 {
+			return fmt.Errorf("仍包含%s", fs[0].Item)
+		}
+	}
+	return nil
+}
+
+// inspectPDF 列出 Info 字典的各项取值以及是否存在 XMP
+func (s *Scrubber) inspectPDF(path string) ([]Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ctx, err := s.readPDF(path, f, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var fs []Finding
+	if ctx.Info != nil {
+		info, err := ctx.DereferenceDict(*ctx.Info)
+		if err != nil {
+			return nil, fmt.Errorf("读取 PDF Info 失败: %w", err)
+		}
+		keys := make([]string, 0, len(info))
+		for k := range info {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fd := Finding{Item: "Info " + k}
+			if o, err := ctx.Dereference(info[k]); err == nil {
+				if v, err := types.StringOrHexLiteral(o); err == nil && v != nil {
+					fd.Value = *v
+				}
+			}
+			fs = append(fs, fd)
+		}
+	}
+	root, err := ctx.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("读取 PDF Catalog 失败: %w", err)
+	}
+	if _, ok := root.Find("Metadata"); ok {
+		fs = append(fs, Finding{Item: "XMP（/Metadata）"})
+	}
+	if s.PDFStripAttachments {
+		fs = append(fs, pdfAttachmentFindings(ctx, root)...)
+	}
+	if s.PDFStripAnnotations {
+		fs = append(fs, pdfAnnotationFindings(ctx, root)...)
+	}
+	return fs, nil
+}
+
+// pdfReadError 将 pdfcpu 的密码错误转换为本包的哨兵错误（错误信息中不包含密码本身）
+func pdfReadError(err error, hasPassword bool) error {
+	if isPDFPasswordError(err) {
+		if hasPassword {
+			return ErrPDFWrongPassword
+		}
+		return ErrPDFPasswordRequired
+	}
+	return fmt.Errorf("读取 PDF 失败: %w", err)
+}
+
+func isPDFPasswordError(err error) bool {
+	return errors.Is(err, pdfcpu.ErrWrongPassword) || errors.Is(err, pdfcpu.ErrOwnerPasswordRequired)
+}
+
+// readPDF 读取 f：先用 PDFPassword，打不开时依次尝试 PDFPasswords 中的候选密码。
+// optimize 时读取并优化（处理用），否则只读取（检查与校验用）；返回的 ctx 记录了打开它的密码，
+// 写回加密 PDF 时沿用该密码。name 仅用于日志，日志中只出现候选密码的序号
+func (s *Scrubber) readPDF(name string, f io.ReadSeeker, optimize bool) (*model.Context, error) {
+	candidates := append([]string{s.PDFPassword}, s.PDFPasswords...)
+	hasPassword := false
+	var err error
+	for i, pw := range candidates {
+		if i > 0 && pw == "" {
+			continue
+		}
+		hasPassword = hasPassword || pw != ""
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		conf := model.NewDefaultConfiguration()
+		conf.UserPW = pw
+		conf.OwnerPW = pw
+		var ctx *model.Context
+		if optimize {
+			conf.Cmd = model.OPTIMIZE // 读取时顺带优化，去除冗余/未引用对象
+			ctx, err = api.ReadValidateAndOptimize(f, conf)
+		} else {
+			ctx, err = api.ReadContext(f, conf)
+		}
+		if err == nil {
+			if i > 0 {
+				s.logger().Debugf("%s: 已用密码列表中的第 %d 个密码打开", name, i)
+			}
+			return ctx, nil
+		}
+		if !isPDFPasswordError(err) {
+			break
+		}
+	}
+	err = pdfReadError(err, hasPassword)
+	if errors.Is(err, ErrPDFWrongPassword) && len(s.PDFPasswords) > 0 {
+		err = fmt.Errorf("%w（已尝试密码列表中的全部 %d 个密码）", err, len(s.PDFPasswords))
+	}
+	return nil, err
+}
@@ -0,0 +1,20 @@
+//go:build !withpdf
+
+package scrub
+
+import "errors"
+
+// pdfBuilt 表示当前构建是否包含 pdfcpu
+const pdfBuilt = false
+
+func (s *Scrubber) scrubPDFWithPDFCPU(path, dst string) error {
+	return errors.New("未编译 PDF 支持：请使用 go build -tags withpdf 重新构建，并加 --with-pdf 运行")
+}
+
+func (s *Scrubber) verifyPDF(path string) error {
+	return errors.New("未编译 PDF 支持，无法校验 PDF")
+}
+
+func (s *Scrubber) inspectPDF(path string) ([]Finding, error) {
+	return nil, errors.New("未编译 PDF 支持，无法检查 PDF")
+}
@@ -0,0 +1,215 @@
+//go:build withpdf
+
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// buildPDF 把 objs 依次写成对象 1、2、…，并生成交叉引用表；对象 1 为 Catalog，info 非 0 时作为 Info 对象号
+func buildPDF(info int, objs ...string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R", len(objs)+1)
+	if info > 0 {
+		fmt.Fprintf(&b, " /Info %d 0 R", info)
+	}
+	fmt.Fprintf(&b, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return []byte(b.String())
+}
+
+// pdfStream 写出不压缩的流对象
+func pdfStream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// 单页文档的页面树，对象 2、3
+const (
+	testPDFPages = "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"
+	testPDFPage  = "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] >>"
+)
+
+// scrubPDFBytes 处理 data 并返回读回的输出
+func scrubPDFBytes(t *testing.T, s *Scrubber, data []byte) *model.Context {
+	t.Helper()
+	p := writeTestFile(t, t.TempDir(), "a.pdf", data)
+	s.WithPDF = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.verifyPDF(p); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ctx, err := api.ReadContext(f, model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// assertPDFLacks 检查输出的所有对象（流解码后）都不含 secrets；输出可能使用压缩的对象流，不能直接搜索文件字节
+func assertPDFLacks(t *testing.T, ctx *model.Context, secrets ...string) {
+	t.Helper()
+	for nr, e := range ctx.Table {
+		if e == nil || e.Free || e.Object == nil {
+			continue
+		}
+		text := e.Object.String()
+		if sd, ok := e.Object.(types.StreamDict); ok {
+			if err := sd.Decode(); err == nil {
+				text += string(sd.Content)
+			}
+		}
+		for _, s := range secrets {
+			if strings.Contains(text, s) {
+				t.Errorf("对象 %d 仍含有 %q", nr, s)
+			}
+		}
+	}
+}
+
+func TestPDFInfoAndXMPRemoved(t *testing.T) {
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><dc:creator>Alice Secret</dc:creator></x:xmpmeta>`
+	data := buildPDF(5,
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 4 0 R >>",
+		testPDFPages,
+		testPDFPage,
+		pdfStream("/Type /Metadata /Subtype /XML", xmp),
+		"<< /Author (Alice Secret) /Title (Quarterly Plan) /Creator (SecretWriter 9) /Producer (SecretPDF 1.0) >>",
+	)
+	ctx := scrubPDFBytes(t, newTestScrubber(), data)
+
+	if ctx.Info != nil {
+		info, err := ctx.DereferenceDict(*ctx.Info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k := range info {
+			if k != "Producer" && k != "CreationDate" && k != "ModDate" {
+				t.Errorf("Info 中仍有 %s", k)
+			}
+		}
+	}
+	root, err := ctx.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := root.Find("Metadata"); ok {
+		t.Error("Catalog 中仍有 /Metadata")
+	}
+	assertPDFLacks(t, ctx, "Alice Secret", "Quarterly Plan", "SecretWriter", "SecretPDF", "xmpmeta")
+}
+
+func TestPDFStripAttachments(t *testing.T) {
+	data := buildPDF(0,
+		"<< /Type /Catalog /Pages 2 0 R /Names << /EmbeddedFiles 4 0 R /JavaScript 7 0 R >> /OpenAction 8 0 R >>",
+		testPDFPages,
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] /Annots [9 0 R] >>",
+		"<< /Names [(secret.docx) 5 0 R] >>",
+		"<< /Type /Filespec /F (secret.docx) /UF (secret.docx) /EF << /F 6 0 R >> >>",
+		pdfStream("/Type /EmbeddedFile", "ATTACHED-SECRET"),
+		"<< /Names [(init) 8 0 R] >>",
+		"<< /S /JavaScript /JS (app.launchURL\\('http://intranet.example'\\)) >>",
+		"<< /Type /Annot /Subtype /FileAttachment /Rect [0 0 10 10] /FS 10 0 R >>",
+		"<< /Type /Filespec /F (notes.txt) /EF << /F 11 0 R >> >>",
+		pdfStream("/Type /EmbeddedFile", "ANNOT-ATTACHMENT"),
+	)
+	secrets := []string{"ATTACHED-SECRET", "secret.docx", "ANNOT-ATTACHMENT", "notes.txt", "intranet.example"}
+
+	// 未开启时附件原样保留
+	kept := scrubPDFBytes(t, newTestScrubber(), data)
+	if root, _ := kept.Catalog(); len(pdfAttachmentFindings(kept, root)) == 0 {
+		t.Error("未开启 PDFStripAttachments 时附件应保留")
+	}
+
+	s := newTestScrubber()
+	s.PDFStripAttachments = true
+	ctx := scrubPDFBytes(t, s, data)
+	root, err := ctx.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs := pdfAttachmentFindings(ctx, root); len(fs) > 0 {
+		t.Errorf("仍有附件: %v", fs)
+	}
+	if _, ok := root.Find("OpenAction"); ok {
+		t.Error("JavaScript 类型的 /OpenAction 应删除")
+	}
+	assertPDFLacks(t, ctx, secrets...)
+	if pages := pdfPages(ctx, root["Pages"], 0); len(pages) != 1 {
+		t.Errorf("页数 %d, 期望 1", len(pages))
+	}
+}
+
+func TestPDFStripAnnotations(t *testing.T) {
+	data := buildPDF(0,
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [5 0 R 7 0 R] >> >>",
+		testPDFPages,
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] /Annots [4 0 R 5 0 R 6 0 R 7 0 R] >>",
+		"<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /T (Dr Reviewer) /Contents (Confidential remark) /M (D:20240102030405Z) >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (name) /DA (/Helv 8 Tf 0 g) /V (John Filled) /Rect [10 10 50 20] /P 3 0 R /AP << /N 8 0 R >> >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 50 10 60] /A << /S /URI /URI (http://example.com/) >> >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Btn /T (agree) /V /Yes /AS /Yes /Rect [10 30 20 40] /P 3 0 R >>",
+		pdfStream("/Type /XObject /Subtype /Form /BBox [0 0 40 10]", "BT /F1 8 Tf (John Filled) Tj ET"),
+	)
+	s := newTestScrubber()
+	s.PDFStripAnnotations = true
+	ctx := scrubPDFBytes(t, s, data)
+	root, err := ctx.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subtypes []string
+	eachPDFAnnot(ctx, root, func(_ int, subtype string, d types.Dict) {
+		subtypes = append(subtypes, subtype)
+		if _, ok := d.Find("V"); ok {
+			t.Errorf("%s 注释的字段值未清除", subtype)
+		}
+		if _, ok := d.Find("AP"); ok && subtype == "Widget" {
+			t.Error("表单控件的外观流未删除")
+		}
+		if as := d.NameEntry("AS"); as != nil && *as != "Off" {
+			t.Errorf("复选框外观状态为 %s, 期望 Off", *as)
+		}
+	})
+	if strings.Join(subtypes, ",") != "Widget,Link,Widget" {
+		t.Errorf("保留的注释为 %v, 期望只剩链接与两个表单控件", subtypes)
+	}
+	form, err := ctx.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		t.Fatal("AcroForm 应保留")
+	}
+	if fields, _ := ctx.DereferenceArray(form["Fields"]); len(fields) != 2 {
+		t.Errorf("表单字段数 %d, 期望 2", len(fields))
+	}
+	if na := form.BooleanEntry("NeedAppearances"); na == nil || !*na {
+		t.Error("应设置 /NeedAppearances")
+	}
+	if fs := pdfAnnotationFindings(ctx, root); len(fs) > 0 {
+		t.Errorf("仍有批注或填写值: %v", fs)
+	}
+	assertPDFLacks(t, ctx, "Dr Reviewer", "Confidential remark", "John Filled")
+}
@@ -0,0 +1,45 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// —— PNG：按块删除文本/时间/EXIF，不重新编码 ——
+// 元数据都在独立的辅助块里（tEXt/zTXt/iTXt 中的 Software、Author、XMP，tIME，eXIf），
+// 跳过这些块即可；IHDR/PLTE/IDAT/IEND 等原样拷贝，像素数据逐字节不变，位深也不会被改动。
+
+// pngDropChunks 为要删除的块类型
+var pngDropChunks = map[string]bool{
+	"tEXt": true, "zTXt": true, "iTXt": true, "tIME": true, "eXIf": true,
+}
+
+// stripPNGChunks 删除元数据块；stripICC 时一并删除 iCCP
+func stripPNGChunks(b []byte, stripICC bool) ([]byte, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, errors.New("不是合法的 PNG 文件")
+	}
+	out := make([]byte, 0, len(b))
+	out = append(out, pngSignature...)
+	for p := len(pngSignature); ; {
+		if p+12 > len(b) {
+			return nil, errors.New("PNG 在 IEND 之前结束")
+		}
+		n := int(binary.BigEndian.Uint32(b[p : p+4]))
+		typ := string(b[p+4 : p+8])
+		end := p + 12 + n
+		if end > len(b) {
+			return nil, fmt.Errorf("PNG 块 %q 长度越界", typ)
+		}
+		if !pngDropChunks[typ] && !(stripICC && typ == "iCCP") {
+			out = append(out, b[p:end]...)
+		}
+		if typ == "IEND" {
+			// IEND 之后的附加数据（部分工具在此追加信息）一并丢弃
+			return out, nil
+		}
+		p = end
+	}
+}
@@ -0,0 +1,67 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// pngChunk 写出一个 PNG 块（含长度与 CRC）
+func pngChunk(typ string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(append(b, typ...), data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+// pngChunks 按顺序返回 PNG 的全部块（类型与数据）
+func pngChunks(t *testing.T, b []byte) (types []string, data map[string][]byte) {
+	t.Helper()
+	data = map[string][]byte{}
+	for p := len(pngSignature); p+12 <= len(b); {
+		n := int(binary.BigEndian.Uint32(b[p:]))
+		typ := string(b[p+4 : p+8])
+		if p+12+n > len(b) {
+			t.Fatalf("块 %q 长度越界", typ)
+		}
+		types = append(types, typ)
+		data[typ] = append(data[typ], b[p+8:p+8+n]...)
+		p += 12 + n
+	}
+	return types, data
+}
+
+func TestPNGTextChunkRemovedIDATUnchanged(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(32, 32)); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+	in := append(append(bytes.Clone(b[:ihdrEnd]), pngChunk("tEXt", []byte("Comment\x00scanned by Alice Secret"))...), b[ihdrEnd:]...)
+	_, before := pngChunks(t, in)
+	if before["tEXt"] == nil {
+		t.Fatal("样本应带有 tEXt 块")
+	}
+
+	p := writeTestFile(t, t.TempDir(), "a.png", in)
+	if err := newTestScrubber().ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, after := pngChunks(t, out)
+	if _, ok := after["tEXt"]; ok || bytes.Contains(out, []byte("Alice Secret")) {
+		t.Errorf("输出仍含有 tEXt 块: %v", types)
+	}
+	if !bytes.Equal(after["IDAT"], before["IDAT"]) {
+		t.Error("IDAT 数据应逐字节不变")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("输出无法解码: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package scrub
+
+import "runtime"
+
+// —— 按类型分池调度（WorkersAuto）——
+// 图片重新编码、PDF 重写主要消耗 CPU，Office/OpenDocument 等 zip 重写则以磁盘读写为主。
+// 统一的并发数要么让 CPU 闲置，要么在机械硬盘上让大量 zip 重写同时寻道。
+// 开启后按类型分为两个池：CPU 密集型按核数（或 Workers）并发，I/O 密集型最多 maxIOWorkers 个，
+// 两个池同时运行，各自从自己的队列取文件；内存预算仍为两池共享。
+
+// maxIOWorkers 为 I/O 密集型文件的并发上限
+const maxIOWorkers = 4
+
+// workerPool 是一组从同一队列取文件的 worker；队列容量与 worker 数相同，
+// 文件边发现边分发，队列满时分发方等待，不会一次把全部文件放进内存
+type workerPool struct {
+	n    int
+	jobs chan job
+}
+
+// workerPools 返回本次处理使用的池：未开启 WorkersAuto 时只有一个池；
+// 开启时第 0 个为 I/O 池，第 1 个为 CPU 池
+func (s *Scrubber) workerPools() []workerPool {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = max(2, runtime.NumCPU())
+	}
+	pool := func(n int) workerPool { return workerPool{n: n, jobs: make(chan job, n)} }
+	if !s.WorkersAuto {
+		return []workerPool{pool(workers)}
+	}
+	return []workerPool{pool(min(workers, maxIOWorkers)), pool(workers)}
+}
+
+// poolFor 返回文件 p 应进入的池
+func (s *Scrubber) poolFor(pools []workerPool, p string) workerPool {
+	if len(pools) == 1 || !s.cpuBound(p) {
+		return pools[0]
+	}
+	return pools[1]
+}
+
+// cpuBound 判断文件是否按 CPU 密集型调度：需要解码像素或重写 PDF 对象的类型
+func (s *Scrubber) cpuBound(p string) bool {
+	ext, _ := s.effectiveExt(p)
+	switch kindOf(ext) {
+	case "image", "heic", "pdf":
+		return true
+	}
+	return false
+}
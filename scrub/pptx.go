@@ -0,0 +1,90 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// —— PowerPoint 批注与备注 ——
+// 对外分享的演示文稿常因批注泄露审阅人身份：ppt/comments/ 下的批注整体删除，
+// ppt/commentAuthors.xml（旧式批注）与 ppt/authors.xml（新式批注）中的作者名与账号替换为 Author。
+// 新式批注还在幻灯片的 extLst 中以 <p188:commentRel r:id> 引用批注部件，删除部件时一并去掉。
+// --strip-notes 时另外删除 ppt/notesSlides/ 下的演讲者备注。关系与内容类型的清理见 opc.go。
+
+func (s *Scrubber) keepPPTXEntry(lower string) bool {
+	if strings.HasPrefix(lower, "ppt/comments/") {
+		return false
+	}
+	if s.StripNotes && strings.HasPrefix(lower, "ppt/notesslides/") {
+		return false
+	}
+	return true
+}
+
+func pptxEdit(name string) func(r io.Reader, w io.Writer) error {
+	lower := strings.ToLower(name)
+	switch {
+	case lower == "ppt/commentauthors.xml" || lower == "ppt/authors.xml":
+		return xmlEditor(anonymizePPTAuthor)
+	case strings.HasPrefix(lower, "ppt/slides/slide") && strings.HasSuffix(lower, ".xml"):
+		return xmlDropper(func(el xml.StartElement) bool {
+			return el.Name.Local == "commentRel"
+		})
+	}
+	return nil
+}
+
+// anonymizePPTAuthor 处理 p:cmAuthor 与 p188:author
+func anonymizePPTAuthor(el *xml.StartElement) {
+	if el.Name.Local != "cmAuthor" && el.Name.Local != "author" {
+		return
+	}
+	for i, a := range el.Attr {
+		switch a.Name.Local {
+		case "name", "userId":
+			el.Attr[i].Value = anonAuthor
+		case "initials":
+			el.Attr[i].Value = anonAuthor[:1]
+		case "providerId":
+			el.Attr[i].Value = "None"
+		}
+	}
+}
+
+// pptxAuthors 收集批注作者名（去重，按出现顺序）
+func pptxAuthors(path string) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	seen := map[string]bool{}
+	var authors []string
+	for _, zf := range zr.File {
+		lower := strings.ToLower(zf.Name)
+		if lower != "ppt/commentauthors.xml" && lower != "ppt/authors.xml" {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = rewriteXML(r, io.Discard, func(el *xml.StartElement) {
+			if el.Name.Local != "cmAuthor" && el.Name.Local != "author" {
+				return
+			}
+			if n := xmlAttr(*el, "name"); n != "" && !seen[n] {
+				seen[n] = true
+				authors = append(authors, n)
+			}
+		})
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+	}
+	return authors, nil
+}
@@ -0,0 +1,54 @@
+package scrub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPPTXCommentAuthorsRemoved(t *testing.T) {
+	const (
+		pNS   = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+		relNS = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+	)
+	data := zipBytes(t,
+		"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/>`+
+			`<Override PartName="/ppt/comments/comment1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.comments+xml"/></Types>`,
+		"_rels/.rels", `<Relationships `+relNS+`><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/></Relationships>`,
+		"docProps/core.xml", testCore,
+		"ppt/presentation.xml", `<p:presentation `+pNS+`/>`,
+		"ppt/commentAuthors.xml", `<p:cmAuthorLst `+pNS+`><p:cmAuthor id="1" name="Bob Reviewer" initials="BR" lastIdx="1" clrIdx="0"/></p:cmAuthorLst>`,
+		"ppt/authors.xml", `<p188:authorLst xmlns:p188="http://schemas.microsoft.com/office/powerpoint/2018/8/main">`+
+			`<p188:author id="{A}" name="Carol Editor" initials="CE" userId="carol@corp.example" providerId="AD"/></p188:authorLst>`,
+		"ppt/comments/comment1.xml", `<p:cmLst `+pNS+`><p:cm authorId="1"><p:text>Bob thinks this slide is wrong</p:text></p:cm></p:cmLst>`,
+		"ppt/slides/slide1.xml", `<p:sld `+pNS+` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:cSld/>`+
+			`<p:extLst><p:ext uri="{6950BFC3}"><p188:commentRel xmlns:p188="http://schemas.microsoft.com/office/powerpoint/2018/8/main" r:id="rId2"/></p:ext></p:extLst></p:sld>`,
+		"ppt/slides/_rels/slide1.xml.rels", `<Relationships `+relNS+`>`+
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="../comments/comment1.xml"/></Relationships>`,
+	)
+	p := writeTestFile(t, t.TempDir(), "deck.pptx", data)
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	names, parts := readZip(t, p)
+	for _, name := range names {
+		if strings.HasPrefix(name, "ppt/comments/") {
+			t.Errorf("批注部件 %s 未被删除", name)
+		}
+	}
+	for name, content := range parts {
+		for _, leak := range []string{"Bob", "Carol", "carol@corp.example", `"BR"`, "comment1.xml", "commentRel"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+	if !strings.Contains(parts["ppt/commentAuthors.xml"], `name="`+anonAuthor+`"`) ||
+		!strings.Contains(parts["ppt/authors.xml"], `name="`+anonAuthor+`"`) {
+		t.Error("作者部件应保留并改为匿名作者")
+	}
+	if err := verifyOPCRefs(p); err != nil {
+		t.Errorf("删除批注后仍有悬空的引用: %v", err)
+	}
+}
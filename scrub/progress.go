@@ -0,0 +1,61 @@
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// —— 进度与预计剩余时间 ——
+// 每秒读取一次 Report 中的原子计数，在 stderr 上原地刷新一行进度；
+// 剩余时间按已用时间与已完成数的比例估算。
+
+// startProgress 启动进度输出，total 返回当前已知的文件总数（边遍历边处理时随发现而增长）；
+// 返回的 stop 会等待输出协程结束并换行
+func (s *Scrubber) startProgress(rep *Report, total func() int) (stop func()) {
+	if !s.Progress {
+		return func() {}
+	}
+	start := time.Now()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	printed := false
+
+	show := func() {
+		ok, failed := atomic.LoadInt64(&rep.OK), atomic.LoadInt64(&rep.Failed)
+		n := ok + failed + atomic.LoadInt64(&rep.Unchanged) + atomic.LoadInt64(&rep.NoMetadata) + atomic.LoadInt64(&rep.Declined)
+		all := total()
+		line := fmt.Sprintf("已处理 %d/%d，失败 %d", n, all, failed)
+		if n > 0 && int(n) < all {
+			eta := time.Duration(float64(time.Since(start)) / float64(n) * float64(int64(all)-n))
+			line += fmt.Sprintf("，预计剩余 %s", eta.Round(time.Second))
+		}
+		// \x1b[K 清除行尾残留的旧内容
+		fmt.Fprintf(os.Stderr, "\r%s\x1b[K", line)
+		printed = true
+	}
+
+	go func() {
+		defer close(finished)
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				show()
+			case <-done:
+				if printed {
+					show()
+					fmt.Fprintln(os.Stderr)
+				}
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,31 @@
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// —— 隔离处理失败的文件（--quarantine-dir）——
+// 大批量处理时，损坏、加密或无法解码的文件只记在日志里，需要人工翻日志才能找出来。
+// 设置 QuarantineDir 后，处理失败的文件按相对输入根目录的路径复制到该目录，便于集中复查；
+// QuarantineMove 时改为移动，原位置不再保留（跨文件系统时复制后删除原文件）。
+// 隔离目录中已有同名文件时覆盖，重复运行只保留最近一次失败的版本。中断而未处理的文件不隔离。
+
+// quarantine 将处理失败的 p 复制或移动到隔离目录，返回隔离后的路径
+func (s *Scrubber) quarantine(p, root string) (string, error) {
+	dst := underDir(s.QuarantineDir, p, root)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", fmt.Errorf("创建隔离目录失败: %w", err)
+	}
+	if s.QuarantineMove {
+		if err := os.Rename(p, dst); err == nil {
+			return dst, nil
+		}
+		if err := copyFile(p, dst); err != nil {
+			return "", err
+		}
+		return dst, os.Remove(p)
+	}
+	return dst, copyFile(p, dst)
+}
@@ -0,0 +1,182 @@
+package scrub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// —— 格式注册表 ——
+// 每种格式以 Handler 注册到扩展名上，处理、dry-run 检查、--verify 与报告中的类别都按扩展名查表。
+// 内置格式在各自文件的 init 中注册；嵌入本包的程序可以用 RegisterHandler 接入自定义格式，
+// 或覆盖某个扩展名的内置处理，无需修改分发逻辑。
+
+// Handler 处理一类文件。ext 为小写、带点的扩展名（内容与扩展名不符时为按内容识别出的扩展名），
+// dst 为输出路径（原地处理时与 path 相同），写出时应使用 Scrubber.WriteOutput 以获得原子替换与备份。
+type Handler interface {
+	Kind() string // 报告中的处理类别，如 openxml、image
+	Scrub(s *Scrubber, path, dst, ext string) error
+	Inspect(s *Scrubber, path, ext string) ([]Finding, error) // 只读检查，列出将被删除的元数据
+}
+
+// Verifier 为可选接口：Handler 同时实现时，--verify 用它复查输出文件
+type Verifier interface {
+	Verify(s *Scrubber, out, ext string) error
+}
+
+// Describer 为可选接口：Handler 同时实现时，--list-handlers 用它说明该扩展名的处理方式
+type Describer interface {
+	Describe(ext string) HandlerInfo
+}
+
+// HandlerInfo 说明一种格式如何处理
+type HandlerInfo struct {
+	Method   string // 处理方式，如“删除 zip 部件”“解码后重新编码”
+	Removes  string // 删除的元数据
+	Flag     string // 需要开启的选项，如 --with-pdf；空表示默认处理
+	BuildTag string // 需要的构建标签，如 withpdf；空表示默认构建即可
+	Built    bool   // 当前构建是否包含 BuildTag 对应的依赖
+}
+
+// HandlerEntry 是注册表中的一项
+type HandlerEntry struct {
+	Ext  string
+	Kind string
+	Info HandlerInfo // Handler 未实现 Describer 时只有 Built 为 true
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+)
+
+// RegisterHandler 将 h 注册到扩展名 ext（不区分大小写，可带或不带点），已注册的扩展名会被覆盖。
+// 应在开始处理之前调用；ext 为空或 h 为 nil 时 panic。
+func RegisterHandler(ext string, h Handler) {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if trimDot(ext) == "" || h == nil {
+		panic("scrub: RegisterHandler 需要扩展名与非 nil 的 Handler")
+	}
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers["."+trimDot(ext)] = h
+}
+
+// RegisteredExts 返回已注册的扩展名（带点，已排序）
+func RegisteredExts() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	exts := make([]string, 0, len(handlers))
+	for ext := range handlers {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// Handlers 按扩展名列出已注册的格式及其说明，供 --list-handlers 使用
+func Handlers() []HandlerEntry {
+	var entries []HandlerEntry
+	for _, ext := range RegisteredExts() {
+		h, _ := handlerFor(ext)
+		e := HandlerEntry{Ext: ext, Kind: h.Kind(), Info: HandlerInfo{Built: true}}
+		if d, ok := h.(Describer); ok {
+			e.Info = d.Describe(ext)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// flagEnabled 返回 HandlerInfo.Flag 对应的选项是否已开启，Flag 为空时为 true
+func (s *Scrubber) flagEnabled(flag string) bool {
+	switch flag {
+	case "":
+		return true
+	case "--with-pdf":
+		return s.WithPDF
+	case "--with-heic":
+		return s.WithHEIC
+	case "--with-video":
+		return s.WithVideo
+	}
+	return false
+}
+
+// handlerUsable 返回 ext 是否有可用的处理器：已注册、所需选项已开启且当前构建包含所需依赖
+func (s *Scrubber) handlerUsable(ext string) bool {
+	h, ok := handlerFor(ext)
+	if !ok {
+		return false
+	}
+	d, ok := h.(Describer)
+	if !ok {
+		return true
+	}
+	info := d.Describe(ext)
+	return info.Built && s.flagEnabled(info.Flag)
+}
+
+func handlerFor(ext string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[ext]
+	return h, ok
+}
+
+// registerSet 将同一个 Handler 注册到一组扩展名
+func registerSet(set map[string]bool, h Handler) {
+	for ext := range set {
+		RegisterHandler(ext, h)
+	}
+}
+
+// handlerFuncs 以函数字段实现 Handler、Verifier 与 Describer，供内置格式注册使用；
+// verify 可为 nil；info 为各扩展名共用的说明，describe 非 nil 时按扩展名给出说明
+type handlerFuncs struct {
+	kind     string
+	scrub    func(s *Scrubber, path, dst, ext string) error
+	inspect  func(s *Scrubber, path, ext string) ([]Finding, error)
+	verify   func(s *Scrubber, out, ext string) error
+	info     HandlerInfo
+	describe func(ext string) HandlerInfo
+}
+
+func (h handlerFuncs) Kind() string { return h.kind }
+
+func (h handlerFuncs) Scrub(s *Scrubber, path, dst, ext string) error {
+	return h.scrub(s, path, dst, ext)
+}
+
+func (h handlerFuncs) Inspect(s *Scrubber, path, ext string) ([]Finding, error) {
+	return h.inspect(s, path, ext)
+}
+
+func (h handlerFuncs) Verify(s *Scrubber, out, ext string) error {
+	if h.verify == nil {
+		return nil
+	}
+	return h.verify(s, out, ext)
+}
+
+func (h handlerFuncs) Describe(ext string) HandlerInfo {
+	info := h.info
+	if h.describe != nil {
+		info = h.describe(ext)
+	}
+	if info.BuildTag == "" {
+		info.Built = true
+	}
+	return info
+}
+
+// WriteOutput 将 data 写入 dst：先写同目录的临时文件再原子替换，原地处理且开启 Backup 时先备份原文件。
+// 供自定义 Handler 使用
+func (s *Scrubber) WriteOutput(orig, dst string, data []byte) error {
+	return s.writeReplace(orig, dst, data)
+}
+
+func errUnsupported(ext string) error {
+	return fmt.Errorf("不支持的扩展名: %s", ext)
+}
@@ -0,0 +1,252 @@
+package scrub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// —— 输出路径：未设置 OutputDir 与 Suffix 时即原文件本身 ——
+// root 为空表示单文件处理，直接写到 OutputDir/<文件名>；
+// 否则去掉输入根目录前缀后拼接到 OutputDir 下，保持原目录结构。
+// 设置 Suffix 时在扩展名之前插入后缀：report.docx -> report_clean.docx。清单行指定了输出路径时直接使用它。
+func (s *Scrubber) destPath(orig, root string) string {
+	if s.output != "" {
+		return s.output
+	}
+	dst := orig
+	if s.OutputDir != "" {
+		dst = underDir(s.OutputDir, orig, root)
+	}
+	if s.Suffix != "" {
+		ext := nameExt(dst)
+		dst = strings.TrimSuffix(dst, ext) + s.Suffix + ext
+	}
+	return dst
+}
+
+// underDir 返回 orig 在 dir 下保持相对 root 的目录结构时的路径；root 为空时只取文件名
+func underDir(dir, orig, root string) string {
+	if root != "" {
+		rel, err := filepath.Rel(root, orig)
+		// 不在 root 之下（如标准输入给出的 ../x）：只保留文件名，避免写到 dir 之外
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(dir, rel)
+		}
+	}
+	return filepath.Join(dir, filepath.Base(orig))
+}
+
+// —— 临时文件：与 dst 同目录（同一文件系统，rename 才是原子的），设置 OutputDir 时绝不落在原文件旁边 ——
+// 文件名随机生成（.<文件名>.<随机>.tmp），上次崩溃残留的临时文件或同名输入都不会被覆盖，
+// 列表中重复出现的同一文件也不会争用同一个临时路径。调用方应 defer removeTemp(f.Name())：
+// 替换成功后临时文件已不存在，删除只是空操作。
+func (s *Scrubber) createTemp(dst string) (*os.File, error) {
+	if err := s.deadline.check(); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(dst)
+	if s.OutputDir != "" || s.output != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建输出目录失败: %w", err)
+		}
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".*.tmp")
+	if err == nil {
+		liveTemps.Store(f.Name(), struct{}{})
+	}
+	return f, err
+}
+
+// liveTemps 登记已创建、尚未清理的临时文件，强制退出前由 CleanupTemps 兜底删除
+var liveTemps sync.Map
+
+// removeTemp 删除临时文件并取消登记
+func removeTemp(name string) {
+	os.Remove(name)
+	liveTemps.Delete(name)
+}
+
+// CleanupTemps 删除所有仍在登记中的临时文件，返回删除的个数。
+// 正常处理结束时每个临时文件都已被替换或删除；仅在不等待进行中的文件、直接退出进程前需要调用
+func CleanupTemps() int {
+	n := 0
+	liveTemps.Range(func(k, _ any) bool {
+		if os.Remove(k.(string)) == nil {
+			n++
+		}
+		liveTemps.Delete(k)
+		return true
+	})
+	return n
+}
+
+// —— 两阶段写出：先完整写入临时文件并落盘，再替换 ——
+// 所有处理器都经由 writeThenReplace 写出：write 出错、刷新或 fsync 失败时只删除临时文件，
+// 原文件与已有的输出保持不变，不会留下写了一半的文件；替换（含备份）统一由 replaceOriginal 完成。
+// write 打开的源文件应在返回前关闭，否则 Windows 上无法覆盖仍被打开的原文件。
+func (s *Scrubber) writeThenReplace(orig, dst string, write func(w io.Writer) error) error {
+	f, err := s.createTemp(dst)
+	if err != nil {
+		return err
+	}
+	defer removeTemp(f.Name())
+	bw := bufio.NewWriter(f)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		// 内容落盘后才改名，断电或崩溃后不会看到已被替换、内容却不完整的文件
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return s.replaceOriginal(orig, dst, f.Name())
+}
+
+// writeReplace 将内存中的结果写出并替换
+func (s *Scrubber) writeReplace(orig, dst string, data []byte) error {
+	return s.writeThenReplace(orig, dst, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// —— 原子替换并保留备份 ——
+func (s *Scrubber) replaceOriginal(orig, dst, tmp string) error {
+	if err := s.deadline.commit(); err != nil {
+		// 已超时放弃的文件：调用方不再等待结果，不能再替换输出，临时文件由调用方的 defer 删除
+		return err
+	}
+	// CreateTemp 创建的文件权限为 0600，沿用原文件的权限位
+	if info, err := os.Stat(orig); err == nil {
+		if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if dst != orig {
+		// 输出到独立目录：原文件保持不动，无需备份
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+		if err := s.renameRetry(tmp, dst); err != nil {
+			// 临时文件由 createTemp 建在 dst 所在目录，不会跨文件系统；
+			// 这里只兜底少数不支持改名覆盖的网络文件系统，退回复制
+			if err := copyFile(tmp, dst); err != nil {
+				return fmt.Errorf("写入输出文件失败: %w", err)
+			}
+			os.Remove(tmp)
+		}
+		return nil
+	}
+
+	if s.Backup {
+		if err := s.makeBackup(orig); err != nil {
+			return err
+		}
+	}
+
+	// 原子替换失败时，尝试直接覆盖写入
+	if err := s.renameRetry(tmp, orig); err != nil {
+		// fallback: 用 copy 覆盖
+		if err := copyFile(tmp, orig); err != nil {
+			return fmt.Errorf("替换原文件失败（可能被占用）: %w", err)
+		}
+		os.Remove(tmp)
+	}
+	return nil
+}
+
+// —— 重命名重试：文件被短暂占用时按指数退避重试 ——
+// 只对占用类错误重试（见 isLockError），权限不足等无法自愈的错误立即返回。
+
+const (
+	defaultReplaceRetries = 5
+	defaultReplaceDelay   = 200 * time.Millisecond
+)
+
+func (s *Scrubber) renameRetry(src, dst string) error {
+	retries, delay := s.ReplaceRetries, s.ReplaceDelay
+	if retries == 0 {
+		retries = defaultReplaceRetries
+	}
+	if delay <= 0 {
+		delay = defaultReplaceDelay
+	}
+	for i := 0; ; i++ {
+		err := os.Rename(src, dst)
+		if err == nil || i >= retries || !isLockError(err) {
+			return err
+		}
+		s.logger().Debugf("%s 被占用，%s 后重试（%d/%d）", dst, delay, i+1, retries)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// —— 备份：orig.bak 已存在时改用 orig.<unix>.bak ——
+// 设置 BackupDir 时改为 BackupDir 下按相对路径还原的 <文件名>.bak，同样按时间戳避让
+func (s *Scrubber) makeBackup(orig string) error {
+	base := s.backupBase(orig)
+	if base != orig {
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			return fmt.Errorf("创建备份目录失败: %w", err)
+		}
+	}
+	bak := base + ".bak"
+	if _, err := os.Stat(bak); err == nil {
+		bak = fmt.Sprintf("%s.%d.bak", base, time.Now().Unix())
+	}
+	if err := copyFile(orig, bak); err != nil {
+		return fmt.Errorf("创建备份失败: %w", err)
+	}
+	return nil
+}
+
+// backupBase 返回 orig 的备份路径去掉 .bak 后的部分：默认即 orig 本身，设置 BackupDir 时位于其下
+func (s *Scrubber) backupBase(orig string) string {
+	if s.backupAt != "" {
+		return s.backupAt
+	}
+	return orig
+}
+
+// copyFile 复制内容并沿用源文件的权限位；关闭前 fsync，避免崩溃后留下截断的文件
+func copyFile(src, dst string) error {
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	info, err := s.Stat()
+	if err != nil {
+		return err
+	}
+	d, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(d, s); err != nil {
+		d.Close()
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	if err := d.Close(); err != nil {
+		return err
+	}
+	// 目标已存在时 Create 不会改变其权限，这里显式设置（含只读、可执行位）
+	return os.Chmod(dst, info.Mode().Perm())
+}
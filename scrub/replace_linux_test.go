@@ -0,0 +1,104 @@
+package scrub
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// statT 返回文件的设备号与 inode
+func statT(t *testing.T, p string) (dev, ino uint64) {
+	t.Helper()
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	return uint64(st.Dev), st.Ino
+}
+
+// 输出目录与输入位于不同的文件系统时，临时文件应建在输出目录中，
+// 替换是同一文件系统内的 rename（inode 不变），不会因 EXDEV 退回复制
+func TestOutputDirOnOtherFilesystem(t *testing.T) {
+	var fsStat syscall.Statfs_t
+	if err := syscall.Statfs("/dev/shm", &fsStat); err != nil || fsStat.Type != 0x01021994 { // TMPFS_MAGIC
+		t.Skip("没有可用的 tmpfs（/dev/shm）")
+	}
+	in := t.TempDir()
+	out, err := os.MkdirTemp("/dev/shm", "goscrub-test-*")
+	if err != nil {
+		t.Skipf("无法在 tmpfs 上创建目录: %v", err)
+	}
+	defer os.RemoveAll(out)
+	p := writeTestFile(t, in, "a.docx", []byte("original"))
+	inDev, _ := statT(t, in)
+	if outDev, _ := statT(t, out); outDev == inDev {
+		t.Skip("临时目录与 /dev/shm 位于同一文件系统")
+	}
+
+	s := newTestScrubber()
+	s.OutputDir = out
+	dst := s.destPath(p, in)
+	var tmpIno uint64
+	err = s.writeThenReplace(p, dst, func(w io.Writer) error {
+		tmps, _ := filepath.Glob(filepath.Join(out, ".a.docx.*.tmp"))
+		if len(tmps) != 1 {
+			t.Errorf("输出目录中的临时文件: %v, 期望 1 个", tmps)
+		} else {
+			_, tmpIno = statT(t, tmps[0])
+		}
+		if near, _ := filepath.Glob(filepath.Join(in, "*.tmp")); len(near) > 0 {
+			t.Errorf("临时文件不应建在原文件旁边: %v", near)
+		}
+		_, err := io.WriteString(w, "scrubbed")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ino := statT(t, dst); ino != tmpIno {
+		t.Error("输出的 inode 与临时文件不同：替换退回了复制")
+	}
+	if b, _ := os.ReadFile(dst); string(b) != "scrubbed" {
+		t.Errorf("输出内容 %q", b)
+	}
+	if b, _ := os.ReadFile(p); string(b) != "original" {
+		t.Error("原文件被改动")
+	}
+	assertOnly(t, out, "a.docx")
+}
+
+func TestCopyFileKeepsMode(t *testing.T) {
+	for _, mode := range []os.FileMode{0o600, 0o640, 0o755, 0o444} {
+		t.Run(mode.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			src := writeTestFile(t, dir, "src", []byte("content"))
+			if err := os.Chmod(src, mode); err != nil {
+				t.Fatal(err)
+			}
+			// 新建的目标与已存在（权限不同）的目标都应改为源文件的权限
+			fresh := filepath.Join(dir, "fresh")
+			existing := writeTestFile(t, dir, "existing", []byte("old content that is longer"))
+			if err := os.Chmod(existing, 0o666); err != nil {
+				t.Fatal(err)
+			}
+			for _, dst := range []string{fresh, existing} {
+				if err := copyFile(src, dst); err != nil {
+					t.Fatal(err)
+				}
+				info, err := os.Stat(dst)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != mode {
+					t.Errorf("%s 的权限为 %v, 期望 %v", filepath.Base(dst), info.Mode().Perm(), mode)
+				}
+				if b, _ := os.ReadFile(dst); string(b) != "content" {
+					t.Errorf("%s 的内容为 %q", filepath.Base(dst), b)
+				}
+			}
+		})
+	}
+}
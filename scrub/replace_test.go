@@ -0,0 +1,145 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dirNames 返回目录下的全部文件名
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// assertOnly 检查 dir 下只有 names 这些文件（没有残留的临时文件与备份）
+func assertOnly(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	got := dirNames(t, dir)
+	if len(got) != len(names) {
+		t.Fatalf("%s 下的文件为 %v, 期望只有 %v", dir, got, names)
+	}
+	for i := range got {
+		if got[i] != names[i] {
+			t.Fatalf("%s 下的文件为 %v, 期望只有 %v", dir, got, names)
+		}
+	}
+}
+
+func TestWriteThenReplaceFailureKeepsOriginal(t *testing.T) {
+	errInjected := errors.New("注入的写出错误")
+	orig := []byte("original content that must survive")
+
+	for _, c := range []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{"写出前失败", func(io.Writer) error { return errInjected }},
+		{"写出一半后失败", func(w io.Writer) error {
+			// 超过 bufio 的缓冲区，确保部分内容已经落到临时文件
+			if _, err := w.Write(bytes.Repeat([]byte("x"), 64<<10)); err != nil {
+				return err
+			}
+			return errInjected
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := writeTestFile(t, dir, "a.docx", orig)
+			s := newTestScrubber()
+			s.Backup = true
+
+			var sawTemp bool
+			err := s.writeThenReplace(p, p, func(w io.Writer) error {
+				matches, _ := filepath.Glob(filepath.Join(dir, ".a.docx.*.tmp"))
+				sawTemp = len(matches) == 1
+				return c.write(w)
+			})
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("err = %v, 期望注入的错误", err)
+			}
+			if !sawTemp {
+				t.Error("写出时应已在目标目录创建临时文件")
+			}
+			got, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, orig) {
+				t.Error("原文件被改动")
+			}
+			assertOnly(t, dir, "a.docx")
+		})
+	}
+}
+
+func TestWriteThenReplaceFailureWithOutputDir(t *testing.T) {
+	dir, out := t.TempDir(), t.TempDir()
+	p := writeTestFile(t, dir, "a.docx", []byte("original"))
+	s := newTestScrubber()
+	s.OutputDir = out
+	dst := s.destPath(p, dir)
+
+	err := s.writeThenReplace(p, dst, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("注入的写出错误")
+	})
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+	assertOnly(t, out)
+	assertOnly(t, dir, "a.docx")
+}
+
+func TestHandlerFailureLeavesNoTraces(t *testing.T) {
+	dir := t.TempDir()
+	// 以不压缩方式存放的条目数据被改动一个字节：读到该条目末尾校验 CRC 时才出错，此时临时文件已经写了一部分
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(testContentTypes)},
+		{"docProps/core.xml", []byte(testCore)},
+		{"word/media/big.bin", bytes.Repeat([]byte("y"), 1<<16)},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(e.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := buf.Bytes()
+	corrupt[bytes.Index(corrupt, []byte("yyyy"))+100] = 'z'
+	p := writeTestFile(t, dir, "a.docx", corrupt)
+
+	s := newTestScrubber()
+	s.Backup = true
+	if err := s.ScrubFile(p); !errors.Is(err, zip.ErrChecksum) {
+		t.Fatalf("err = %v, 期望 CRC 校验错误", err)
+	}
+	got, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, corrupt) {
+		t.Error("处理失败后原文件被改动")
+	}
+	assertOnly(t, dir, "a.docx")
+}
@@ -0,0 +1,265 @@
+package scrub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 单个文件的处理状态
+const (
+	StatusOK         = "ok"
+	StatusFailed     = "failed"
+	StatusDryRun     = "dry-run"     // 演示模式：仅列出，未做修改
+	StatusCanceled   = "canceled"    // 处理被中断时尚未开始的文件
+	StatusUnchanged  = "unchanged"   // 上次已处理且此后未改动，跳过
+	StatusNoMetadata = "no-metadata" // 检查未发现可删除的元数据，跳过（OnlyMetadataPresent）
+	StatusDeclined   = "declined"    // 用户在逐个确认时拒绝（Confirm）
+)
+
+// dry-run 时计划对文件执行的操作（FileResult.Action）
+const (
+	ActionOverwrite = "overwrite" // 原地覆盖
+	ActionWrite     = "write"     // 写出到 Output，不修改原文件
+	ActionSkip      = "skip"      // 不处理：上次已处理且未改动，或开启 OnlyMetadataPresent 时未发现元数据
+)
+
+// FileResult 记录单个文件的处理结果
+type FileResult struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"` // openxml/opendoc/image/pdf/heic/video/audio
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	BytesBefore int64  `json:"bytes_before"`
+	BytesAfter  int64  `json:"bytes_after,omitempty"`
+	Backup      bool   `json:"backup"`                    // 是否生成了 .bak 备份
+	BackupPath  string `json:"backup_path,omitempty"`     // 本次生成的备份路径
+	Output      string `json:"output,omitempty"`          // 成功处理时的输出路径（原地处理时与 Path 相同）；dry-run 时为计划写出的路径
+	Action      string `json:"action,omitempty"`          // dry-run 时计划的操作：overwrite/write/skip
+	SHA256      string `json:"sha256,omitempty"`          // 输出文件的 SHA-256（HashOutputs）
+	OrigSHA256  string `json:"original_sha256,omitempty"` // 处理前原文件的 SHA-256（HashOutputs 且开启 Backup）
+	Quarantined string `json:"quarantined,omitempty"`     // 失败后被隔离到的路径（QuarantineDir）
+
+	Findings []Finding `json:"findings,omitempty"` // dry-run 时发现的、将被删除的元数据
+
+	Err error `json:"-"` // 原始错误，可用 errors.Is 判断（如 ErrPDFPasswordRequired）
+}
+
+// process 处理单个文件并填写结果；DryRun 时只读检查将被删除的元数据
+func (s *Scrubber) process(ctx context.Context, p, root string) FileResult {
+	ext, _ := s.effectiveExt(p)
+	r := FileResult{Path: p, Type: kindOf(ext)}
+	if info, err := os.Stat(p); err == nil {
+		r.BytesBefore = info.Size()
+	}
+	dst := s.destPath(p, root)
+	if s.State != nil && !s.Force && s.State.unchanged(p, dst, s.optionsFingerprint()) {
+		r.Status = StatusUnchanged
+		if s.DryRun {
+			r.Action = ActionSkip
+		}
+		return r
+	}
+	if s.DryRun {
+		r.Status = StatusDryRun
+		r.Output, r.Action = dst, ActionWrite
+		if dst == p {
+			r.Action = ActionOverwrite
+		}
+		if heicSet[ext] {
+			r.Output = heicOutput(dst)
+		}
+		fs, err := s.Inspect(p)
+		if err != nil {
+			r.Error = err.Error()
+			r.Err = err
+		} else if s.OnlyMetadataPresent && len(fs) == 0 {
+			r.Action = ActionSkip
+		}
+		r.Findings = fs
+		return r
+	}
+
+	if s.OnlyMetadataPresent {
+		// 检查失败时照常处理，由处理过程报告具体错误
+		if fs, err := s.Inspect(p); err == nil && len(fs) == 0 {
+			r.Status = StatusNoMetadata
+			return r
+		}
+	}
+
+	if s.Confirm != nil && !s.Confirm(p, dst) {
+		r.Status = StatusDeclined
+		return r
+	}
+
+	if s.ExtractMetadata {
+		// 副本写不出来时不处理，以免元数据既没有留档又被删除
+		if err := s.extractMetadata(p, dst); err != nil {
+			r.Status = StatusFailed
+			r.Error = err.Error()
+			r.Err = err
+			return r
+		}
+	}
+
+	if s.HashOutputs && s.Backup {
+		sum, err := fileSHA256(p)
+		if err != nil {
+			s.logger().Warnf("%s: 计算原文件哈希失败: %v", p, err)
+		}
+		r.OrigSHA256 = sum
+	}
+
+	if err := s.scrubFileRetry(ctx, p, root); err != nil {
+		if errors.Is(err, context.Canceled) {
+			r.Status = StatusCanceled
+			return r
+		}
+		r.Status = StatusFailed
+		r.Error = err.Error()
+		r.Err = err
+		if s.QuarantineDir != "" {
+			q, err := s.quarantine(p, root)
+			if err != nil {
+				s.logger().Errorf("%s: 隔离失败: %v", p, err)
+			} else {
+				r.Quarantined = q
+			}
+		}
+		return r
+	}
+	r.Status = StatusOK
+	if s.State != nil {
+		s.State.record(p, dst, s.optionsFingerprint())
+	}
+	r.Output = dst
+	if heicSet[ext] {
+		r.Output = heicOutput(dst) // HEIC 转码为 JPEG，扩展名随之改变
+	}
+	if info, err := os.Stat(r.Output); err == nil {
+		r.BytesAfter = info.Size()
+	}
+	if s.HashOutputs {
+		sum, err := fileSHA256(r.Output)
+		if err != nil {
+			s.logger().Warnf("%s: 计算输出哈希失败: %v", r.Output, err)
+		}
+		r.SHA256 = sum
+	}
+	// 写到独立输出目录时原文件未被替换，不会生成备份
+	r.Backup = s.Backup && dst == p
+	if r.Backup {
+		base := p
+		if s.BackupDir != "" {
+			base = underDir(s.BackupDir, p, root)
+		}
+		r.BackupPath = s.latestBackup(p, base)
+	}
+	return r
+}
+
+// kindOf 返回扩展名对应的处理类别
+func kindOf(ext string) string {
+	if h, ok := handlerFor(ext); ok {
+		return h.Kind()
+	}
+	return "unknown"
+}
+
+// ExtStat 是按扩展名汇总的处理结果
+type ExtStat struct {
+	Ext    string `json:"ext"`
+	OK     int    `json:"ok"`
+	Failed int    `json:"failed"`
+}
+
+// ByExt 按小写扩展名（没有扩展名时为空串）汇总结果，按扩展名排序
+func (r Report) ByExt() []ExtStat {
+	idx := map[string]int{}
+	var stats []ExtStat
+	for _, res := range r.Results {
+		ext := strings.ToLower(filepath.Ext(res.Path))
+		i, ok := idx[ext]
+		if !ok {
+			i = len(stats)
+			idx[ext] = i
+			stats = append(stats, ExtStat{Ext: ext})
+		}
+		switch res.Status {
+		case StatusOK:
+			stats[i].OK++
+		case StatusFailed:
+			stats[i].Failed++
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Ext < stats[j].Ext })
+	return stats
+}
+
+// SizeChange 汇总成功处理的文件在处理前后的总字节数（处理后为输出文件的大小）。
+// 重新编码的 JPEG 可能比原图更大，删除部件的 Office 文档通常会变小
+func (r Report) SizeChange() (before, after int64) {
+	for _, res := range r.Results {
+		if res.Status == StatusOK {
+			before += res.BytesBefore
+			after += res.BytesAfter
+		}
+	}
+	return before, after
+}
+
+// WriteJSON 将报告以 JSON 写入 path，包含运行信息（如已设置）、逐文件结果与汇总计数
+func (r Report) WriteJSON(path string) error {
+	doc := struct {
+		Run     *RunInfo `json:"run,omitempty"`
+		Summary struct {
+			Total      int   `json:"total"`
+			OK         int64 `json:"ok"`
+			Failed     int64 `json:"failed"`
+			Skipped    int64 `json:"skipped"`
+			TooOld     int64 `json:"too_old,omitempty"`
+			Canceled   int64 `json:"canceled,omitempty"`
+			Unchanged  int64 `json:"unchanged,omitempty"`
+			NoMetadata int64 `json:"no_metadata,omitempty"`
+			Declined   int64 `json:"declined,omitempty"`
+			Copied     int64 `json:"copied,omitempty"`
+			MacRemoved int64 `json:"mac_removed,omitempty"`
+			DryRun     bool  `json:"dry_run"`
+
+			BytesBefore int64 `json:"bytes_before"` // 成功处理的文件处理前的总字节数
+			BytesAfter  int64 `json:"bytes_after"`
+			BytesDelta  int64 `json:"bytes_delta"` // 处理后减处理前，负数表示变小
+
+			ByExt []ExtStat `json:"by_ext,omitempty"`
+		} `json:"summary"`
+		Files []FileResult `json:"files"`
+	}{Run: r.Run, Files: r.Results}
+	doc.Summary.Total = len(r.Files)
+	doc.Summary.OK = r.OK
+	doc.Summary.Failed = r.Failed
+	doc.Summary.Skipped = r.Skipped
+	doc.Summary.TooOld = r.TooOld
+	doc.Summary.Canceled = r.Canceled
+	doc.Summary.Unchanged = r.Unchanged
+	doc.Summary.NoMetadata = r.NoMetadata
+	doc.Summary.Declined = r.Declined
+	doc.Summary.Copied = r.Copied
+	doc.Summary.MacRemoved = r.MacRemoved
+	doc.Summary.DryRun = r.DryRun
+	if !r.DryRun {
+		doc.Summary.ByExt = r.ByExt()
+		doc.Summary.BytesBefore, doc.Summary.BytesAfter = r.SizeChange()
+		doc.Summary.BytesDelta = doc.Summary.BytesAfter - doc.Summary.BytesBefore
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
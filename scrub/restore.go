@@ -0,0 +1,180 @@
+package scrub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// —— 从 .bak 备份回滚 ——
+// replaceOriginal 先写 orig.bak，已存在时改写 orig.<unix>.bak，
+// 因此同一个原文件可能对应多个备份，恢复时取时间最新的一个。
+// 设置 BackupDir 时备份位于该目录下，按相对输入根目录的路径还原出原文件。
+// 只处理原文件是受支持格式的备份（见 ownBackup），目录中其他程序留下的 .bak 不受影响。
+
+type backupFile struct {
+	path string
+	ts   int64 // orig.bak 视为 0，即最早
+}
+
+// parseBackupName 从备份文件名解析出原文件路径与时间戳
+func parseBackupName(p string) (orig string, ts int64, ok bool) {
+	if !strings.HasSuffix(strings.ToLower(p), ".bak") {
+		return "", 0, false
+	}
+	base := p[:len(p)-len(".bak")]
+	if ext := filepath.Ext(base); len(ext) > 9 {
+		// orig.<unix>.bak：秒级时间戳至少 9 位
+		if n, err := strconv.ParseInt(ext[1:], 10, 64); err == nil {
+			return strings.TrimSuffix(base, ext), n, true
+		}
+	}
+	return base, 0, true
+}
+
+// Restore 在 path 下查找 .bak 备份并恢复到原文件，成功后删除所用的备份。
+// path 为目录时递归查找；为普通文件时视为原文件，只恢复它自己的备份。
+func (s *Scrubber) Restore(path string) (Report, error) {
+	groups := map[string][]backupFile{}
+	collect := func(p string) {
+		if orig, ts, ok := parseBackupName(p); ok && s.ownBackup(orig, p) {
+			groups[orig] = append(groups[orig], backupFile{path: p, ts: ts})
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Report{}, err
+	}
+	if info.IsDir() && s.BackupDir != "" {
+		// 备份在 BackupDir 下按相对 path 的路径存放，换算回原文件路径
+		err = filepath.WalkDir(s.BackupDir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if base, ts, ok := parseBackupName(p); ok && !d.IsDir() {
+				rel, err := filepath.Rel(s.BackupDir, base)
+				if err != nil {
+					return err
+				}
+				orig := filepath.Join(path, rel)
+				if !s.ownBackup(orig, p) {
+					return nil
+				}
+				groups[orig] = append(groups[orig], backupFile{path: p, ts: ts})
+			}
+			return nil
+		})
+		if err != nil {
+			return Report{}, fmt.Errorf("遍历备份目录失败: %w", err)
+		}
+	} else if info.IsDir() {
+		err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				collect(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return Report{}, fmt.Errorf("遍历目录失败: %w", err)
+		}
+	} else {
+		base := path
+		if s.BackupDir != "" {
+			// 单个文件处理时备份直接放在 BackupDir 下
+			base = underDir(s.BackupDir, path, "")
+		}
+		if baks := s.findBackups(path, base); len(baks) > 0 {
+			groups[path] = baks
+		}
+	}
+
+	origs := make([]string, 0, len(groups))
+	for orig := range groups {
+		origs = append(origs, orig)
+	}
+	sort.Strings(origs)
+
+	rep := Report{Files: origs, DryRun: s.DryRun}
+	if s.DryRun {
+		return rep, nil
+	}
+	for _, orig := range origs {
+		if err := s.restoreFile(orig, groups[orig]); err != nil {
+			s.logger().Errorf("%s: %v", orig, err)
+			rep.Failed++
+			continue
+		}
+		s.logger().Infof("已恢复 %s", orig)
+		rep.OK++
+	}
+	return rep, nil
+}
+
+// restoreFile 用最新的备份覆盖原文件；存在多个备份时给出警告，其余备份保留
+func (s *Scrubber) restoreFile(orig string, baks []backupFile) error {
+	sort.Slice(baks, func(i, j int) bool { return baks[i].ts > baks[j].ts })
+	latest := baks[0]
+	if len(baks) > 1 {
+		s.logger().Warnf("%s: 存在 %d 个备份，使用最新的 %s，其余保留", orig, len(baks), filepath.Base(latest.path))
+	}
+
+	if err := os.Rename(latest.path, orig); err != nil {
+		// 原文件被占用等情况：退回复制后再删除备份
+		if err := copyFile(latest.path, orig); err != nil {
+			return fmt.Errorf("恢复失败: %w", err)
+		}
+		os.Remove(latest.path)
+	}
+	return nil
+}
+
+// findBackups 返回原文件 orig 的全部备份；base 为备份路径去掉 .bak 后的部分（见 backupBase）
+func (s *Scrubber) findBackups(orig, base string) []backupFile {
+	var baks []backupFile
+	matches, _ := filepath.Glob(escapeGlob(base) + ".*bak")
+	for _, m := range matches {
+		if o, ts, ok := parseBackupName(m); ok && o == base && s.ownBackup(orig, m) {
+			baks = append(baks, backupFile{path: m, ts: ts})
+		}
+	}
+	return baks
+}
+
+// latestBackup 返回 orig 最新的备份路径，没有备份时返回空串
+func (s *Scrubber) latestBackup(orig, base string) string {
+	var latest backupFile
+	for _, b := range s.findBackups(orig, base) {
+		if latest.path == "" || b.ts > latest.ts {
+			latest = b
+		}
+	}
+	return latest.path
+}
+
+// ownBackup 判断 bak 是否可能由本工具生成：只有原文件 orig 的扩展名有对应的处理器时才会备份，
+// 其余 .bak（如 settings.ini.bak、prod.bak）属于用户或其他程序，恢复时不能覆盖到“原文件”上。
+// 没有扩展名的文件按内容识别类型（见 effectiveExt），这里同样识别备份的内容
+func (s *Scrubber) ownBackup(orig, bak string) bool {
+	ext := fileExt(orig)
+	if ext == "" {
+		ext = sniffExt(bak)
+	}
+	if _, ok := handlerFor(ext); ok {
+		return true
+	}
+	s.logger().Debugf("跳过非本工具生成的备份: %s", bak)
+	return false
+}
+
+// escapeGlob 转义路径中的通配符，避免文件名中的 [ ] 等被当作模式
+func escapeGlob(p string) string {
+	r := strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`)
+	return r.Replace(p)
+}
@@ -0,0 +1,84 @@
+package scrub
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreLeavesForeignBackups(t *testing.T) {
+	orig := zipBytes(t, testDocx(`<w:p/>`)...)
+	for _, c := range []struct {
+		name      string
+		backupDir bool
+	}{
+		{"备份在原文件旁", false},
+		{"备份在 BackupDir", true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir, bakDir := t.TempDir(), t.TempDir()
+			// 其他程序或用户自己的 .bak：本工具从不处理 .ini 与无扩展名的文本文件
+			foreign := map[string]string{
+				"settings.ini":     "key=new",
+				"settings.ini.bak": "key=old",
+				"prod.bak":         "plain text backup",
+			}
+			for name, data := range foreign {
+				writeTestFile(t, dir, name, []byte(data))
+				writeTestFile(t, bakDir, name, []byte(data))
+			}
+			p := writeTestFile(t, dir, "a.docx", orig)
+			// 没有扩展名、按内容识别为 docx 的文件，其备份同样应恢复
+			noExt := writeTestFile(t, dir, "report", orig)
+
+			files := []string{p, noExt}
+			s := newTestScrubber()
+			bakAt := dir
+			if c.backupDir {
+				// 与处理时一致：BackupDir 下按相对输入目录的路径存放
+				s.BackupDir = bakDir
+				bakAt = bakDir
+			}
+			for _, f := range files {
+				writeTestFile(t, bakAt, filepath.Base(f)+".bak", orig)
+				writeTestFile(t, dir, filepath.Base(f), []byte("scrubbed"))
+			}
+
+			rep, err := s.Restore(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rep.OK != 2 || rep.Failed != 0 {
+				t.Errorf("OK = %d, Failed = %d, 期望只恢复 2 个文件", rep.OK, rep.Failed)
+			}
+			for _, f := range files {
+				if got, _ := os.ReadFile(f); !bytes.Equal(got, orig) {
+					t.Errorf("%s 未从备份恢复", filepath.Base(f))
+				}
+			}
+			for _, d := range []string{dir, bakDir} {
+				for name, data := range foreign {
+					if got, err := os.ReadFile(filepath.Join(d, name)); err != nil || string(got) != data {
+						t.Errorf("%s 被改动: %q, %v", filepath.Join(d, name), got, err)
+					}
+				}
+				if _, err := os.Stat(filepath.Join(d, "prod")); err == nil {
+					t.Errorf("prod.bak 被当作备份恢复为 %s", filepath.Join(d, "prod"))
+				}
+			}
+
+			// 单个文件同样不恢复其他程序的备份
+			rep, err = s.Restore(filepath.Join(dir, "settings.ini"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rep.OK != 0 || len(rep.Files) != 0 {
+				t.Errorf("settings.ini 的备份被恢复: %+v", rep.Files)
+			}
+			if got, _ := os.ReadFile(filepath.Join(dir, "settings.ini")); string(got) != "key=new" {
+				t.Errorf("settings.ini 被改为 %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package scrub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// —— 暂时性失败重试（FileRetries）——
+// 同步客户端、扫描仪等仍在写入的文件，第一次读取时可能只有一半，解码失败，片刻之后却能正常处理。
+// 开启后，单个文件处理失败且属于暂时性错误时，等待 FileRetryDelay（之后每次翻倍）整体重新处理，
+// 重试用尽后才记为失败（返回的错误包装 ErrTransient）。错误分为三类：
+//   - 永久：类型不支持、超过大小上限、超时、缺少 PDF 密码或密码错误、输出写出之后的失败，立即返回；
+//   - 暂时：占用冲突（见 isLockError）与底层 I/O 错误（EIO），等待后重试；
+//   - 其余（多为解码、解析失败）：等待后比较源文件的大小与修改时间，有变化说明文件仍在被写入，重试；
+//     没有变化则视为文件确实损坏，不再重试。因此开启后损坏的文件会多等待一次 FileRetryDelay。
+//
+// 每次尝试在写出之前失败时不会留下输出或备份，重试是安全的。
+
+const defaultFileRetryDelay = time.Second
+
+// ErrTransient 表示处理失败的原因可能是暂时的（文件正被写入或占用），重试可能成功
+var ErrTransient = errors.New("暂时性错误")
+
+// scrubFileRetry 在 scrubFileTimeout 外按 FileRetries 重试暂时性失败
+func (s *Scrubber) scrubFileRetry(ctx context.Context, p, root string) error {
+	delay := s.FileRetryDelay
+	if delay <= 0 {
+		delay = defaultFileRetryDelay
+	}
+	for i := 0; ; i++ {
+		before, _ := os.Stat(p)
+		err := s.scrubFileTimeout(ctx, p, root)
+		if err == nil {
+			return nil
+		}
+		if isPermanent(err) {
+			return err
+		}
+		known := isTransient(err)
+		if known {
+			err = fmt.Errorf("%w: %w", ErrTransient, err)
+		}
+		if i >= s.FileRetries {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		if after, _ := os.Stat(p); !known && !fileChanged(before, after) {
+			return err // 文件没有变化：确实损坏
+		}
+		s.logger().Warnf("%s: %v，重试（%d/%d）", p, err, i+1, s.FileRetries)
+		delay *= 2
+	}
+}
+
+// isPermanent 判断 err 是否为重试也无法改变的错误
+func isPermanent(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrUnsupportedType) ||
+		errors.Is(err, ErrTooLarge) ||
+		errors.Is(err, ErrFileTimeout) ||
+		errors.Is(err, ErrPDFPasswordRequired) ||
+		errors.Is(err, ErrPDFWrongPassword) ||
+		errors.As(err, new(permanentError))
+}
+
+// isTransient 判断 err 是否为与文件内容无关的暂时性错误
+func isTransient(err error) bool {
+	return errors.Is(err, ErrTransient) || isLockError(err) || errors.Is(err, syscall.EIO)
+}
+
+// permanentError 标记输出已经写出之后的失败（校验未通过、恢复修改时间失败）：
+// 此时源文件的变化来自本次写出，不能据此重试，否则会把输出再处理一遍
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// fileChanged 比较两次 Stat 的结果：任一次失败，或大小、修改时间不同时返回 true
+func fileChanged(before, after os.FileInfo) bool {
+	if before == nil || after == nil {
+		return before != after
+	}
+	return before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime())
+}
@@ -0,0 +1,147 @@
+package scrub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+)
+
+// —— RTF：删除 {\info …} 与 {\*\userprops …} 组 ——
+// \info 中只有文档属性（\title、\author、\operator、\company、\creatim、\revtim 等），
+// 与 Office 删除整个 docProps/ 一致，整组删除；\userprops 是自定义属性。
+// 正文不做任何改动。扫描时需要正确跳过转义的花括号（\{ \}）和 \binN 后的原始二进制，
+// 否则会把其中的字节误当作组边界。
+
+// rtfDropGroups 列出要整组删除的目标（组开头紧跟的控制字）
+var rtfDropGroups = [][]byte{[]byte(`{\info`), []byte(`{\*\userprops`)}
+
+func init() {
+	RegisterHandler(".rtf", handlerFuncs{
+		kind:    "rtf",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubRTF(p, dst) },
+		inspect: func(_ *Scrubber, p, _ string) ([]Finding, error) { return inspectRTF(p) },
+		verify:  func(_ *Scrubber, out, _ string) error { return verifyRTF(out) },
+		info:    HandlerInfo{Method: "删除控制字组", Removes: `{\info}` + " 文档属性（作者、公司、时间）与 " + `{\*\userprops}` + " 自定义属性"},
+	})
+}
+
+func (s *Scrubber) scrubRTF(path, dst string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cleaned, err := stripRTFInfo(data)
+	if err != nil {
+		return err
+	}
+	return s.writeReplace(path, dst, cleaned)
+}
+
+// stripRTFInfo 返回删除属性组后的 RTF
+func stripRTFInfo(b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, []byte(`{\rtf`)) {
+		return nil, errors.New("缺少 {\\rtf 头，不是 RTF 文件")
+	}
+	out := make([]byte, 0, len(b))
+	last := 0
+	for i := 0; i < len(b); {
+		switch b[i] {
+		case '\\':
+			i = rtfSkipControl(b, i)
+		case '{':
+			if !rtfIsDropGroup(b[i:]) {
+				i++
+				continue
+			}
+			end, err := rtfGroupEnd(b, i)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b[last:i]...)
+			// 组后的换行只是排版用，一并去掉以免留下空行
+			for end < len(b) && (b[end] == '\r' || b[end] == '\n') {
+				end++
+			}
+			last, i = end, end
+		default:
+			i++
+		}
+	}
+	return append(out, b[last:]...), nil
+}
+
+func rtfIsDropGroup(b []byte) bool {
+	for _, g := range rtfDropGroups {
+		if !bytes.HasPrefix(b, g) {
+			continue
+		}
+		// 控制字须完整匹配：{\infoX 不算
+		if n := len(g); n == len(b) || !isASCIILetter(b[n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// rtfGroupEnd 返回从 b[start]（'{'）开始的组结束之后的位置
+func rtfGroupEnd(b []byte, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(b); {
+		switch b[i] {
+		case '\\':
+			i = rtfSkipControl(b, i)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+		i++
+	}
+	return 0, errors.New("RTF 组未闭合")
+}
+
+// rtfSkipControl 跳过从 b[i]（'\'）开始的控制字或控制符号，返回其后的位置。
+// \binN 之后的 N 个字节是原始二进制，整体跳过。
+func rtfSkipControl(b []byte, i int) int {
+	j := i + 1
+	if j >= len(b) {
+		return j
+	}
+	if !isASCIILetter(b[j]) {
+		// 控制符号：\{ \} \\ \~ 等；\'hh 额外带两位十六进制
+		if b[j] == '\'' {
+			return min(j+3, len(b))
+		}
+		return j + 1
+	}
+	for j < len(b) && isASCIILetter(b[j]) {
+		j++
+	}
+	word := string(b[i+1 : j])
+	p := j
+	if p < len(b) && b[p] == '-' {
+		p++
+	}
+	for p < len(b) && b[p] >= '0' && b[p] <= '9' {
+		p++
+	}
+	param := string(b[j:p])
+	if p < len(b) && b[p] == ' ' {
+		p++ // 控制字后的单个空格是分隔符
+	}
+	if word == "bin" {
+		if n, err := strconv.Atoi(param); err == nil && n > 0 {
+			p = min(p+n, len(b))
+		}
+	}
+	return p
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,37 @@
+package scrub
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRTFInfoRemovedBodyKept(t *testing.T) {
+	const (
+		head = `{\rtf1\ansi\deff0{\fonttbl{\f0 Arial;}}` + "\n"
+		info = `{\info{\title Plan}{\author Alice Secret}{\company ACME Corp}{\creatim\yr2024\mo1\dy2}}` + "\n"
+		// 转义的花括号与 \bin 后的原始二进制（含花括号与看似控制字的字节）都不是组边界
+		body = `\pard Set \{braces\} and \\ backslash.` +
+			`{\pict\pngblip\picw2\pich2\bin12 }{\info}x{` + "\x00\xff" + `}` +
+			`{\*\userprops{\propname Reviewer}\proptype30{\staticval Bob}}` + "\n" +
+			`\par}`
+	)
+	p := writeTestFile(t, t.TempDir(), "a.rtf", []byte(head+info+body))
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := head + `\pard Set \{braces\} and \\ backslash.` +
+		`{\pict\pngblip\picw2\pich2\bin12 }{\info}x{` + "\x00\xff" + `}` +
+		`\par}`
+	if string(out) != want {
+		t.Errorf("输出:\n%q\n期望:\n%q", out, want)
+	}
+	if err := verifyRTF(writeTestFile(t, t.TempDir(), "b.rtf", []byte(head+info+body))); err == nil {
+		t.Error("verifyRTF 应发现未删除的 \\info 组")
+	}
+}
@@ -0,0 +1,111 @@
+package scrub
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// —— 运行信息：版本、主要选项与可选格式 ——
+// 排查问题时常常说不清当时用了哪些选项、二进制是否带有 withpdf 等构建标签。
+// RunInfo 汇总这些信息：命令行在开始处理前输出一次，JSON 报告也以它开头（见 Report.Run）。
+
+// OptionalFormat 说明一种需要选项开启的格式（PDF、HEIC、视频）在本次运行中的状态
+type OptionalFormat struct {
+	Kind     string   `json:"kind"`
+	Exts     []string `json:"exts"`
+	Flag     string   `json:"flag"`                // 开启所需的选项，如 --with-pdf
+	BuildTag string   `json:"build_tag,omitempty"` // 需要的构建标签，空表示默认构建即可
+	Built    bool     `json:"built"`               // 当前构建是否包含所需依赖
+	Enabled  bool     `json:"enabled"`             // 本次是否开启了 Flag
+}
+
+// RunInfo 描述一次运行：版本、构建环境、主要的生效选项、可选格式与本次会处理的扩展名
+type RunInfo struct {
+	Version   string            `json:"version"`
+	GoVersion string            `json:"go_version"`
+	Platform  string            `json:"platform"`
+	Options   map[string]string `json:"options"`
+	Optional  []OptionalFormat  `json:"optional_formats"`
+	Exts      []string          `json:"exts"` // 受 Include/Exclude 与可选格式开关影响后的扩展名
+}
+
+// RunInfo 按当前选项与注册表汇总运行信息；version 由调用方给出
+func (s *Scrubber) RunInfo(version string) RunInfo {
+	info := RunInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Options:   s.effectiveOptions(),
+	}
+	inc, exc := toSet(s.Include), toSet(s.Exclude)
+	at := map[string]int{}
+	for _, e := range Handlers() {
+		usable := true
+		if f := e.Info.Flag; f != "" {
+			i, ok := at[f]
+			if !ok {
+				i = len(info.Optional)
+				at[f] = i
+				info.Optional = append(info.Optional, OptionalFormat{
+					Kind: e.Kind, Flag: f, BuildTag: e.Info.BuildTag, Built: e.Info.Built, Enabled: s.flagEnabled(f),
+				})
+			}
+			info.Optional[i].Exts = append(info.Optional[i].Exts, e.Ext)
+			usable = s.flagEnabled(f) && e.Info.Built
+		}
+		ext := trimDot(e.Ext)
+		if usable && (len(inc) == 0 || inc[ext]) && !exc[ext] {
+			info.Exts = append(info.Exts, e.Ext)
+		}
+	}
+	return info
+}
+
+// effectiveOptions 返回影响处理结果的主要选项（按默认值补全后的取值）
+func (s *Scrubber) effectiveOptions() map[string]string {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = max(2, runtime.NumCPU())
+	}
+	opts := map[string]string{
+		"workers":   strconv.Itoa(workers),
+		"backup":    strconv.FormatBool(s.Backup),
+		"dry-run":   strconv.FormatBool(s.DryRun),
+		"verify":    strconv.FormatBool(s.Verify),
+		"jpeg-mode": s.jpegMode(),
+		"strip-icc": strconv.FormatBool(s.StripICC),
+	}
+	var deep []string
+	for _, d := range []struct {
+		name string
+		on   bool
+	}{{"office", s.DeepOffice}, {"xlsx", s.DeepXLSX}, {"odf", s.DeepODF}} {
+		if d.on {
+			deep = append(deep, d.name)
+		}
+	}
+	if len(deep) > 0 {
+		opts["deep"] = strings.Join(deep, ",")
+	}
+	if s.WorkersAuto {
+		opts["workers"] += "（按类型分池）"
+	}
+	if s.WalkWorkers > 1 {
+		opts["walk-workers"] = strconv.Itoa(s.WalkWorkers)
+	}
+	if !s.Since.IsZero() {
+		opts["since"] = s.Since.Format(time.RFC3339)
+	}
+	if s.OutputDir != "" {
+		opts["output-dir"] = s.OutputDir
+	}
+	if s.Suffix != "" {
+		opts["suffix"] = s.Suffix
+	}
+	if s.BackupDir != "" {
+		opts["backup-dir"] = s.BackupDir
+	}
+	return opts
+}
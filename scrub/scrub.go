@@ -0,0 +1,735 @@
+// Package scrub 提供文档/图片元数据脱敏的核心逻辑，可被命令行或其他 Go 程序直接调用。
+//
+//	s := &scrub.Scrubber{Backup: true, Workers: 4}
+//	rep, err := s.ScrubDir("D:\\资料")
+package scrub
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// 支持的文件类型由各格式文件在 init 中注册（见 registry.go）：
+// Office OpenXML 与 OpenDocument 见 office.go；图片见 image.go；epub 见 epub.go；rtf 见 rtf.go；svg 见 svg.go；
+// mp3/flac 见 audio.go；mp4/mov 见 WithVideo 与 video.go；pdf 需要可选依赖（pdfcpu），见 WithPDF 与 pdf.go；heic/heif 见 WithHEIC 与 heic.go
+
+// Scrubber 保存一次脱敏任务的全部选项，零值即可使用（Workers<=0 时按 CPU 核数）
+type Scrubber struct {
+	Backup      bool   // 是否保留 .bak 备份
+	BackupDir   string // 设置后备份按相对路径写入该目录，而不是原文件旁边
+	DryRun      bool   // 只读检查并列出将被删除的元数据，不做任何修改
+	Workers     int    // 并发处理的工作协程数
+	WorkersAuto bool   // 按类型分池：图片/PDF 按核数（或 Workers）并发，Office 等 zip 重写最多 4 个并发
+	WalkWorkers int    // 并发遍历目录的协程数，大于 1 时启用（见 walk.go），适合网络文件系统上的大目录
+	WithPDF     bool   // 启用 PDF 脱敏（需要 pdfcpu）
+	WithHEIC    bool   // 启用 HEIC/HEIF 脱敏（需要以 -tags withheic 构建），输出会转为 JPEG
+	WithVideo   bool   // 启用 MP4/MOV 脱敏（删除 udta/meta 与 XMP 盒子）
+
+	PDFPassword string  // 加密 PDF 的密码
+	PDFDecrypt  bool    // 输出时去除 PDF 加密（默认按原加密方式写回）
+	Verbose     bool    // 未设置 Logger 时输出 debug 级别日志
+	Logger      *Logger // 日志输出，nil 时写到 stderr（默认 warn 级别）
+	Progress    bool    // 每秒在 stderr 刷新一行进度与预计剩余时间
+	MaxMemory   int64   // 所有 worker 同时占用的内存预算（字节），0 表示 1GB
+
+	ReplaceRetries int           // 替换文件遇到占用时的重试次数，0 表示默认 5 次，负数表示不重试
+	ReplaceDelay   time.Duration // 首次重试前的等待时间，之后每次翻倍，0 表示默认 200ms
+	FileRetries    int           // 单个文件因暂时性错误（ErrTransient）失败时整体重新处理的次数，0 表示不重试，见 retry.go
+	FileRetryDelay time.Duration // 首次整体重试前的等待时间，之后每次翻倍，0 表示默认 1s
+
+	Include     []string // 仅处理这些扩展名（不区分大小写，可带或不带点）
+	Exclude     []string // 排除这些扩展名
+	ExcludeDirs []string // 遍历时跳过的目录：目录名或通配符（含 / 时按相对输入根目录的路径匹配）
+
+	IncludeGlobs []string  // 仅处理相对路径匹配这些通配符的文件（不含 / 时匹配文件名，支持 **）
+	ExcludeGlobs []string  // 排除相对路径匹配这些通配符的文件
+	MaxFileSize  int64     // 超过该大小（字节）的文件在收集时跳过，0 表示不限制
+	Since        time.Time // 遍历时只收集修改时间晚于该时刻的文件，零值表示不限制
+	StrictExt    bool      // 只按扩展名判断类型，不读取文件头
+
+	FollowSymlinks     bool // 遍历时跟随符号链接（默认跳过）
+	AllowExternalLinks bool // 允许跟随指向输入目录之外的链接
+
+	OutputDir     string // 设置后按原目录结构写入该目录，不修改原文件
+	Suffix        string // 设置后输出为同目录下的 <文件名><Suffix><扩展名>，不修改原文件、不生成备份
+	StripMode     string // JPEG 脱敏方式：lossless、full、selective，或只删除位置信息的 gps（也适用于 TIFF）；空表示自动（见 jpegMode）
+	KeepThumbnail bool   // selective 模式下保留 EXIF 内嵌缩略图
+	JPEGQuality   int    // JPEG 重编码质量，0 表示按源文件估算
+	StripICC      bool   // 删除图片中的 ICC 色彩配置（默认保留）
+
+	ZeroTimestamps      bool          // 将 zip 条目的修改时间统一置为 1980-01-01
+	CompressionMethod   string        // 重写 zip 时的压缩方式：keep（默认，沿用源条目）、deflate 或 store
+	Deterministic       bool          // 输出可逐字节复现：zip 条目按名称排序并清零时间，固定 PDF 的日期与 /ID
+	DeepOffice          bool          // 额外删除 customXml/ 等部件，并匿名化 Word 修订/批注作者、删除修订时间
+	DeepXLSX            bool          // 匿名化 Excel 批注作者与线程批注人员，删除 xl/calcChain.xml
+	StripNotes          bool          // 删除 PowerPoint 演讲者备注（ppt/notesSlides/）
+	StripMacros         bool          // 删除 docm/xlsm/pptm/vsdm 中的 VBA 宏工程（vbaProject.bin）及其引用
+	StripODFExtras      bool          // 删除 OpenDocument 的数字签名（META-INF/*signatures.xml）与缩略图（Thumbnails/）
+	NormalizeZipNames   bool          // 重写 zip 时把条目名中的反斜杠换成 /、去掉多余的 ./ 与重复分隔符
+	AllowUnsafeNames    bool          // 照常处理含绝对路径、.. 或 NUL 条目名的 zip/tar（默认报 ErrUnsafeEntryName）
+	StripZipExtra       bool          // 重写 zip 时不写出扩展时间戳字段，条目只带 DOS 时间（源条目的扩展字段始终不复制）
+	KeepMinimalProps    bool          // 不删除 docProps/core.xml 与 app.xml，改为替换成空属性（兼容要求这两个部件存在的下游系统）
+	DeepODF             bool          // 匿名化 OpenDocument 修订与批注的作者（dc:creator），时间（dc:date）改为固定值
+	PDFStripAttachments bool          // 删除 PDF 附件（EmbeddedFiles、FileAttachment 注释）与文档级 JavaScript
+	PDFStripAnnotations bool          // 删除 PDF 批注（保留链接与表单控件），清除表单字段的填写值
+	PDFPasswords        []string      // PDFPassword 打不开时依次尝试的候选密码
+	DerefContentTypes   bool          // 删除 docProps 等部件时同步去掉 _rels/.rels 与 [Content_Types].xml 中的引用
+	RecursiveZip        bool          // 递归脱敏嵌入的 Office 文件与嵌套 zip（深度与总大小有上限）
+	PreserveMtime       bool          // 处理后恢复原文件的修改时间
+	Verify              bool          // 处理后重新读取输出，确认元数据已删除，否则记为失败
+	VerifyRollback      bool          // 校验未通过时用备份恢复原文件（需要 Backup）
+	ChecksumContent     bool          // 比较处理前后的像素/正文文本，不一致时记录警告
+	FileTimeout         time.Duration // 单个文件的处理时限，超时记为失败（ErrFileTimeout）并继续处理其余文件，0 表示不限制
+	State               *State        // 非 nil 时跳过上次已处理且未改动的文件（StatusUnchanged），并记录本次成功处理的文件
+	Audit               *AuditLog     // 非 nil 时每个文件处理后向审计日志追加一行，见 audit.go
+	Force               bool          // 忽略 State 中的记录，全部重新处理（仍会更新记录）
+	OnlyMetadataPresent bool          // 处理前先检查，未发现可删除元数据的文件跳过（StatusNoMetadata），不重新编码、不改动修改时间
+	QuarantineDir       string        // 处理失败的文件按相对路径复制到该目录，便于集中复查
+	QuarantineMove      bool          // 隔离时移动而不是复制（需要 QuarantineDir）
+	ExtractMetadata     bool          // 处理前把将被删除的元数据另存为输出旁的 <文件名>.metadata.json
+	HashOutputs         bool          // 成功处理后计算输出文件的 SHA-256，开启 Backup 时另算原文件的，见 hashes.go
+	CopyUnsupported     bool          // 配合 OutputDir：收集时遇到的不支持的文件原样复制到输出目录，见 passthrough.go
+	StripMacFiles       bool          // 删除遍历到的 ._* 与 .DS_Store，重写 zip 时丢弃 __MACOSX/ 等条目，见 macos.go
+
+	// Confirm 非 nil 时在写出每个文件前调用，返回 false 则跳过该文件（StatusDeclined）。
+	// 多个 worker 可能同时调用，交互式提示需自行加锁
+	Confirm func(path, dst string) bool
+
+	// OnResult 非 nil 时在每个文件得出结果（含 dry-run 的检查结果）后调用，可用于边处理边输出；
+	// 多个 worker 可能同时调用
+	OnResult func(r FileResult)
+
+	skipped  int64         // 收集阶段因超过大小上限跳过的文件数，须使用 atomic 操作
+	tooOld   int64         // 收集阶段因修改时间早于 Since 跳过的文件数，须使用 atomic 操作
+	unsupp   []string      // 收集阶段遇到的不支持的文件（CopyUnsupported 时记录）
+	macJunk  []string      // 收集阶段遇到的 macOS 附带文件（StripMacFiles 时记录）
+	output   string        // 清单行指定的输出路径，仅 forEntry 生成的副本使用
+	deadline *fileDeadline // 单个文件的超时状态，仅 scrubFileTimeout 生成的副本使用
+	tar      *tarState     // tar 成员处理时的嵌套状态，仅 memberScrubber 生成的副本使用
+	backupAt string        // BackupDir 下该文件的备份路径（不含 .bak），仅 scrubFile 生成的副本使用
+}
+
+// Report 汇总一次批量处理的结果
+type Report struct {
+	Files      []string     // 匹配到的文件
+	Results    []FileResult // 与 Files 一一对应的处理结果
+	OK         int64        // 多个 worker 并发累加，须使用 atomic 操作
+	Failed     int64
+	Skipped    int64 // 收集阶段跳过的文件（超过 MaxFileSize），不在 Files 中
+	TooOld     int64 // 收集阶段因修改时间不晚于 Since 跳过的文件，不在 Files 中
+	Canceled   int64 // 因中断而未处理的文件
+	Unchanged  int64 // 上次已处理且未改动而跳过的文件（见 State）
+	NoMetadata int64 // 未发现元数据而跳过的文件（见 OnlyMetadataPresent）
+	Declined   int64 // 逐个确认时被拒绝的文件（见 Confirm）
+	Copied     int64 // 原样复制到输出目录的不支持的文件（见 CopyUnsupported），不在 Files 中
+	MacRemoved int64 // 删除的 macOS 附带文件（见 StripMacFiles），不在 Files 中
+	DryRun     bool
+	Run        *RunInfo // 运行信息，设置后写在 JSON 报告开头（见 Scrubber.RunInfo）
+}
+
+// Validate 检查选项取值是否合法
+func (s *Scrubber) Validate() error {
+	switch s.StripMode {
+	case "", "lossless", "full", "selective", "gps":
+	default:
+		return fmt.Errorf("未知的 strip-mode: %s（可选 lossless/full/selective/gps）", s.StripMode)
+	}
+	switch s.CompressionMethod {
+	case "", "keep", "deflate", "store":
+	default:
+		return fmt.Errorf("未知的 compression-method: %s（可选 keep/deflate/store）", s.CompressionMethod)
+	}
+	if s.JPEGQuality < 0 || s.JPEGQuality > 100 {
+		return fmt.Errorf("jpeg-quality 超出范围: %d（可选 1-100，0 为自动）", s.JPEGQuality)
+	}
+	if strings.ContainsAny(s.Suffix, `/\`) {
+		return fmt.Errorf("suffix 不能包含路径分隔符: %s", s.Suffix)
+	}
+	if s.FileRetries < 0 {
+		return fmt.Errorf("num-retries 不能为负数: %d", s.FileRetries)
+	}
+	if s.BackupDir != "" && !s.Backup {
+		return errors.New("backup-dir 需要开启 backup")
+	}
+	if s.CopyUnsupported && s.OutputDir == "" {
+		return errors.New("copy-unsupported 需要同时指定 output-dir")
+	}
+	if s.QuarantineMove && s.QuarantineDir == "" {
+		return errors.New("quarantine-move 需要同时指定 quarantine-dir")
+	}
+	for _, pat := range s.ExcludeDirs {
+		if _, err := path.Match(filepath.ToSlash(strings.TrimSpace(pat)), ""); err != nil {
+			return fmt.Errorf("exclude-dir 模式非法: %s", pat)
+		}
+	}
+	for _, pat := range append(append([]string(nil), s.IncludeGlobs...), s.ExcludeGlobs...) {
+		for _, seg := range strings.Split(filepath.ToSlash(strings.TrimSpace(pat)), "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("通配符模式非法: %s", pat)
+			}
+		}
+	}
+	return nil
+}
+
+// Check 判断单个文件是否会被处理，不会处理时返回原因
+func (s *Scrubber) Check(path string) error {
+	ext, _ := s.effectiveExt(path)
+	inc, exc := toSet(s.Include), toSet(s.Exclude)
+	if len(inc) > 0 && !inc[trimDot(ext)] {
+		return fmt.Errorf("不在 include 列表: %s", path)
+	}
+	if exc[trimDot(ext)] {
+		return fmt.Errorf("在 exclude 列表中: %s", path)
+	}
+	if !isSupportedExt(ext) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, ext)
+	}
+	if kindOf(ext) == "iwork" && sniffExt(path) != ".pages" {
+		// .key 同时是常见的私钥文件扩展名，只处理确为 iWork 包的文件
+		return fmt.Errorf("%w: %s 不是 iWork 文档", ErrUnsupportedType, path)
+	}
+	if s.Suffix != "" && strings.HasSuffix(strings.TrimSuffix(path, nameExt(path)), s.Suffix) {
+		// 上一次以同样后缀运行的输出，再处理会得到 report_clean_clean.docx
+		return fmt.Errorf("文件名已带后缀 %s，视为之前的输出: %s", s.Suffix, path)
+	}
+	if s.MaxFileSize > 0 {
+		if info, err := os.Stat(path); err == nil && info.Size() > s.MaxFileSize {
+			return fmt.Errorf("%w: %s（%d 字节）", ErrTooLarge, path, info.Size())
+		}
+	}
+	return nil
+}
+
+// ErrUnsupportedType 表示文件类型不受支持；CopyUnsupported 时这类文件原样复制到输出目录
+var ErrUnsupportedType = errors.New("暂不支持的文件类型")
+
+// ErrTooLarge 表示文件超过 MaxFileSize，收集时跳过并计入 Report.Skipped
+var ErrTooLarge = errors.New("文件超过大小上限")
+
+// ErrFileTimeout 表示单个文件的处理超过 FileTimeout
+var ErrFileTimeout = errors.New("处理超时")
+
+// accept 供各收集函数使用：通过路径过滤（rel 为相对输入根目录的路径）且受支持时返回 true；因超过大小上限被跳过时记录警告并计数
+func (s *Scrubber) accept(p, rel string, d os.DirEntry) bool {
+	if err := s.checkPathGlobs(rel); err != nil {
+		s.logger().Debugf("跳过 %v", err)
+		return false
+	}
+	if s.skipMacJunk(p) {
+		return false
+	}
+	if !s.Since.IsZero() {
+		// 读取失败（如遍历中途被删除）时不在这里跳过，交给后续处理报告错误
+		if info, err := d.Info(); err == nil && s.olderThanSince(p, info) {
+			return false
+		}
+	}
+	err := s.Check(p)
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		s.logger().Warnf("跳过 %v", err)
+		atomic.AddInt64(&s.skipped, 1)
+	case errors.Is(err, ErrUnsupportedType) && s.CopyUnsupported:
+		collectMu.Lock()
+		s.unsupp = append(s.unsupp, p)
+		collectMu.Unlock()
+	}
+	return err == nil
+}
+
+// olderThanSince 判断文件的修改时间是否不晚于 Since，是则记录并计数
+func (s *Scrubber) olderThanSince(p string, info os.FileInfo) bool {
+	if s.Since.IsZero() || info.ModTime().After(s.Since) {
+		return false
+	}
+	s.logger().Debugf("跳过修改时间早于 --since 的文件: %s（%s）", p, info.ModTime().Format(time.RFC3339))
+	atomic.AddInt64(&s.tooOld, 1)
+	return true
+}
+
+// collectMu 保护并发遍历时 accept 对 unsupp、macJunk 的追加
+var collectMu sync.Mutex
+
+// Collect 递归遍历 root，返回符合 include/exclude 且受支持的文件
+func (s *Scrubber) Collect(root string) ([]string, error) {
+	var (
+		mu    sync.Mutex
+		files []string
+	)
+	err := s.collectEach(context.Background(), root, func(p string) {
+		mu.Lock()
+		files = append(files, p)
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.WalkWorkers > 1 {
+		// 并发遍历的发现顺序不固定，排序后与顺序遍历的结果一致
+		sort.Strings(files)
+		sort.Strings(s.unsupp)
+		sort.Strings(s.macJunk)
+	}
+	return files, nil
+}
+
+// collectEach 遍历 root，对每个符合条件的文件调用 found；WalkWorkers 大于 1 时 found 会被并发调用
+func (s *Scrubber) collectEach(ctx context.Context, root string, found func(p string)) error {
+	visit := func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+		rel, _ := filepath.Rel(root, p)
+		if s.accept(p, rel, d) {
+			found(p)
+		}
+		return nil
+	}
+	var err error
+	if s.WalkWorkers > 1 {
+		var w *walker
+		if w, err = s.newWalker(root); err == nil {
+			err = w.walkParallel(ctx, root, s.WalkWorkers, visit)
+		}
+	} else {
+		err = s.walkFiles(root, visit)
+	}
+	if err != nil {
+		return fmt.Errorf("遍历目录失败: %w", err)
+	}
+	return nil
+}
+
+// CollectList 从 r 读取逐行的文件路径（如 find 的输出），跳过 WalkDir，
+// 同样按 include/exclude 与支持的类型过滤；路径通配符按给出的路径匹配
+func (s *Scrubber) CollectList(r io.Reader) ([]string, error) {
+	var files []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		p := strings.TrimSpace(sc.Text())
+		if p == "" {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			s.logger().Warnf("跳过无法访问的路径: %v", err)
+			continue
+		}
+		if info.IsDir() {
+			s.logger().Warnf("跳过目录: %s", p)
+			continue
+		}
+		if err := s.checkPathGlobs(p); err != nil {
+			s.logger().Debugf("跳过 %v", err)
+			continue
+		}
+		if s.olderThanSince(p, info) {
+			continue
+		}
+		if err := s.Check(p); err != nil {
+			if errors.Is(err, ErrTooLarge) {
+				s.logger().Warnf("跳过 %v", err)
+				atomic.AddInt64(&s.skipped, 1)
+			} else {
+				s.logger().Debugf("跳过 %v", err)
+			}
+			continue
+		}
+		files = append(files, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件列表失败: %w", err)
+	}
+	return files, nil
+}
+
+// ScrubDir 遍历 root 并并发处理其中的文件
+func (s *Scrubber) ScrubDir(root string) (Report, error) {
+	return s.ScrubDirContext(context.Background(), root)
+}
+
+// ScrubDirContext 遍历 root 的同时处理已经发现的文件，不等遍历结束就开始处理；
+// 文件按发现的先后处理，Report.Files 亦按此顺序。遍历中途出错时已发现的文件照常处理完，
+// 返回的 Report 包含它们的结果，错误另行返回。ctx 的作用同 ScrubFilesContext，取消后同时停止遍历
+func (s *Scrubber) ScrubDirContext(ctx context.Context, root string) (Report, error) {
+	src := make(chan string)
+	var werr error
+	go func() {
+		defer close(src)
+		werr = s.collectEach(ctx, root, func(p string) {
+			select {
+			case src <- p:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	rep := s.scrubFrom(ctx, root, src, 0, func(int) *Scrubber { return s })
+	if werr != nil && !errors.Is(werr, ctx.Err()) {
+		return rep, werr
+	}
+	return rep, nil
+}
+
+// ScrubFiles 并发处理 files；root 为输入根目录，用于在 OutputDir 下还原目录结构
+func (s *Scrubber) ScrubFiles(root string, files []string) Report {
+	return s.ScrubFilesContext(context.Background(), root, files)
+}
+
+// ScrubFilesContext 与 ScrubFiles 相同，但 ctx 取消后不再开始新文件：进行中的文件照常完成
+// （不会留下写了一半的输出），尚未开始的文件记为 StatusCanceled 并计入 Report.Canceled
+func (s *Scrubber) ScrubFilesContext(ctx context.Context, root string, files []string) Report {
+	return s.scrubEach(ctx, root, files, func(int) *Scrubber { return s })
+}
+
+// scrubEach 是 ScrubFilesContext 的实现；pick 返回处理第 i 个文件时使用的选项（清单模式下逐行不同），
+// 并发度与内存预算始终按 s 计算
+func (s *Scrubber) scrubEach(ctx context.Context, root string, files []string, pick func(i int) *Scrubber) Report {
+	src := make(chan string)
+	go func() {
+		// 中断后仍全部送出：未开始的文件由 scrubFrom 记为 StatusCanceled
+		defer close(src)
+		for _, f := range files {
+			src <- f
+		}
+	}()
+	return s.scrubFrom(ctx, root, src, len(files), pick)
+}
+
+// job 为分发给 worker 的一个文件：i 为它在 Report.Files 中的下标
+type job struct {
+	i int
+	p string
+}
+
+// scrubFrom 并发处理从 src 读到的文件，直到 src 关闭；total 为已知的文件总数（用于进度显示），未知时为 0。
+// 文件按读到的顺序编号，第 i 个文件使用 pick(i) 的选项
+func (s *Scrubber) scrubFrom(ctx context.Context, root string, src <-chan string, total int, pick func(i int) *Scrubber) Report {
+	rep := Report{DryRun: s.DryRun}
+
+	// 每个 worker 只写自己负责的 Results[i]；Results 随发现的文件增长，读写都在 mu 之下
+	var mu sync.Mutex
+	pools := s.workerPools()
+	wg := sync.WaitGroup{}
+	mem := semaphore.NewWeighted(s.memoryBudget())
+
+	work := func(jobs <-chan job) {
+		defer wg.Done()
+		for j := range jobs {
+			if ctx.Err() != nil {
+				continue // 已中断：排空队列，不再开始新文件
+			}
+			var weight int64
+			if !s.DryRun {
+				weight = s.memoryWeight(j.p)
+				if mem.Acquire(ctx, weight) != nil {
+					continue
+				}
+			}
+			r := pick(j.i).process(ctx, j.p, root)
+			mem.Release(weight)
+			mu.Lock()
+			rep.Results[j.i] = r
+			mu.Unlock()
+			s.audit(r)
+			if s.OnResult != nil {
+				s.OnResult(r)
+			}
+			switch r.Status {
+			case StatusFailed:
+				s.logger().Errorf("%s: %s", r.Path, r.Error)
+				atomic.AddInt64(&rep.Failed, 1)
+			case StatusOK:
+				s.logger().Infof("已处理 %s", r.Path)
+				atomic.AddInt64(&rep.OK, 1)
+			case StatusUnchanged:
+				s.logger().Debugf("未改动，跳过 %s", r.Path)
+				atomic.AddInt64(&rep.Unchanged, 1)
+			case StatusNoMetadata:
+				s.logger().Debugf("未发现元数据，跳过 %s", r.Path)
+				atomic.AddInt64(&rep.NoMetadata, 1)
+			case StatusDeclined:
+				s.logger().Debugf("未确认，跳过 %s", r.Path)
+				atomic.AddInt64(&rep.Declined, 1)
+			}
+		}
+	}
+	for _, pl := range pools {
+		for range pl.n {
+			wg.Add(1)
+			go work(pl.jobs)
+		}
+	}
+	var found atomic.Int64
+	stop := s.startProgress(&rep, func() int {
+		return max(total, int(found.Load()))
+	})
+	for p := range src {
+		mu.Lock()
+		i := len(rep.Files)
+		rep.Files = append(rep.Files, p)
+		rep.Results = append(rep.Results, FileResult{})
+		mu.Unlock()
+		found.Add(1)
+		if ctx.Err() != nil {
+			continue
+		}
+		select {
+		case s.poolFor(pools, p).jobs <- job{i, p}:
+		case <-ctx.Done():
+		}
+	}
+	for _, pl := range pools {
+		close(pl.jobs)
+	}
+	wg.Wait()
+	stop()
+
+	// 未被任何 worker 处理（或在开始前被取消）的文件
+	for i := range rep.Results {
+		if r := &rep.Results[i]; r.Status == "" || r.Status == StatusCanceled {
+			r.Path, r.Status = rep.Files[i], StatusCanceled
+			rep.Canceled++
+			s.audit(*r)
+		}
+	}
+	rep.Skipped = atomic.LoadInt64(&s.skipped)
+	rep.TooOld = atomic.LoadInt64(&s.tooOld)
+	s.passThrough(ctx, root, &rep)
+	s.removeMacFiles(ctx, root, &rep)
+	return rep
+}
+
+// audit 在设置了 Audit 时记录 r；写入失败只记录警告，不影响处理
+func (s *Scrubber) audit(r FileResult) {
+	if s.Audit == nil {
+		return
+	}
+	if err := s.Audit.record(r); err != nil {
+		s.logger().Warnf("写入审计日志失败: %v", err)
+	}
+}
+
+// ScrubFile 处理单个文件；设置了 OutputDir 时写入 OutputDir/<文件名>
+func (s *Scrubber) ScrubFile(path string) error {
+	if s.DryRun {
+		return nil
+	}
+	return s.scrubFile(context.Background(), path, "")
+}
+
+// —— 单文件超时（FileTimeout）——
+// 损坏或恶意构造的文件可能让解码、zip 解析耗时极长甚至卡住，拖住一个 worker。
+// Go 无法强行终止 goroutine，因此在独立 goroutine 中处理，超时即返回 ErrFileTimeout、继续下一个文件；
+// 被放弃的 goroutine 使用带超时状态的副本，createTemp 与 replaceOriginal 发现超时后不再写出，
+// 已写的临时文件由其 defer 删除，不会在超时之后悄悄替换原文件。
+// 反过来，已经开始替换（备份、重命名）的文件不再放弃，等它完成，结果以实际为准。
+// 超时与外层中断无关：中断时仍等待进行中的文件正常完成。
+
+// fileDeadline 协调超时与写出：expire 与 commit 只有先到的一方生效
+type fileDeadline struct {
+	mu        sync.Mutex
+	expired   bool
+	committed bool
+}
+
+// expire 标记超时；文件已开始写出时返回 false
+func (d *fileDeadline) expire() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.committed {
+		return false
+	}
+	d.expired = true
+	return true
+}
+
+// commit 标记开始写出；已超时时返回 ErrFileTimeout。d 为 nil（未设置超时）时总是成功
+func (d *fileDeadline) commit() error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.expired {
+		return ErrFileTimeout
+	}
+	d.committed = true
+	return nil
+}
+
+// check 在已超时时返回 ErrFileTimeout，不改变状态
+func (d *fileDeadline) check() error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.expired {
+		return ErrFileTimeout
+	}
+	return nil
+}
+
+func (s *Scrubber) scrubFileTimeout(ctx context.Context, p, root string) error {
+	if s.FileTimeout <= 0 {
+		return s.scrubFile(ctx, p, root)
+	}
+	c := *s
+	c.deadline = &fileDeadline{}
+	done := make(chan error, 1)
+	go func() { done <- c.scrubFile(ctx, p, root) }()
+
+	timer := time.NewTimer(s.FileTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		if !c.deadline.expire() {
+			return <-done
+		}
+		return fmt.Errorf("%w（超过 %v）", ErrFileTimeout, s.FileTimeout)
+	}
+}
+
+// scrubFile 处理单个文件。ctx 只在写出之前的步骤之间检查：一旦开始写输出就完成整个文件
+func (s *Scrubber) scrubFile(ctx context.Context, p, root string) error {
+	if s.BackupDir != "" {
+		// 备份路径取决于 root，由副本带给 replaceOriginal 与 undoFailedVerify
+		c := *s
+		c.backupAt = underDir(s.BackupDir, p, root)
+		s = &c
+	}
+	ext, mismatch := s.effectiveExt(p)
+	if mismatch {
+		s.logger().Warnf("%s: 扩展名与实际内容不符，按 %s 处理", p, ext)
+	}
+	dst := s.destPath(p, root)
+
+	var mtime time.Time
+	if s.PreserveMtime {
+		if info, err := os.Stat(p); err == nil {
+			mtime = info.ModTime()
+		}
+	}
+
+	var fp *contentPrint
+	if s.ChecksumContent {
+		var err error
+		if fp, err = s.contentPrint(p, ext); err != nil {
+			s.logger().Debugf("%s: 无法计算内容指纹，跳过内容比较: %v", p, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.dispatch(p, dst, ext); err != nil {
+		return err
+	}
+
+	out := dst
+	if heicSet[ext] {
+		out = heicOutput(dst)
+	}
+	if fp != nil {
+		if err := fp.compare(out); err != nil {
+			s.logger().Warnf("%s: 内容与处理前不一致: %v", p, err)
+		}
+	}
+	if s.Verify {
+		if err := s.verify(out, ext); err != nil {
+			s.undoFailedVerify(p, dst, out)
+			return permanentError{fmt.Errorf("校验未通过: %w", err)}
+		}
+	}
+
+	if !mtime.IsZero() {
+		// 访问时间传零值表示保持不变
+		if err := os.Chtimes(out, time.Time{}, mtime); err != nil {
+			return permanentError{fmt.Errorf("恢复修改时间失败: %w", err)}
+		}
+	}
+	return nil
+}
+
+// undoFailedVerify 处理校验未通过的输出：独立输出目录中直接删除；
+// 原地替换且开启 VerifyRollback 时用刚生成的备份恢复原文件
+func (s *Scrubber) undoFailedVerify(p, dst, out string) {
+	if dst != p {
+		os.Remove(out)
+		return
+	}
+	if !s.VerifyRollback || !s.Backup || out != p {
+		return
+	}
+	if baks := s.findBackups(p, s.backupBase(p)); len(baks) > 0 {
+		if err := s.restoreFile(p, baks); err != nil {
+			s.logger().Warnf("%s: 回滚失败: %v", p, err)
+		}
+	}
+}
+
+func (s *Scrubber) dispatch(p, dst, ext string) error {
+	// 为避免 “文件被占用” 问题：以只读打开探测，随后复制到临时文件再原子替换
+	// Windows 上如果目标被占用会报错，建议关闭占用应用或加重试
+	h, ok := handlerFor(ext)
+	if !ok {
+		return errUnsupported(ext)
+	}
+	return h.Scrub(s, p, dst, ext)
+}
+
+// —— 小工具函数 ——
+func isSupportedExt(ext string) bool {
+	_, ok := handlerFor(ext)
+	return ok
+}
+
+func toSet(exts []string) map[string]bool {
+	res := map[string]bool{}
+	for _, v := range exts {
+		v = strings.TrimSpace(strings.ToLower(v))
+		v = trimDot(v)
+		if v != "" {
+			res[v] = true
+		}
+	}
+	return res
+}
+
+func trimDot(ext string) string {
+	return strings.TrimPrefix(ext, ".")
+}
+
+// Skipped 返回收集阶段因超过 MaxFileSize 跳过的文件数
+func (s *Scrubber) Skipped() int64 {
+	return atomic.LoadInt64(&s.skipped)
+}
+
+// TooOld 返回收集阶段因修改时间早于 Since 跳过的文件数
+func (s *Scrubber) TooOld() int64 {
+	return atomic.LoadInt64(&s.tooOld)
+}
+
+// Unsupported 返回收集阶段记录的不支持的文件（仅 CopyUnsupported 时记录）
+func (s *Scrubber) Unsupported() []string {
+	return s.unsupp
+}
@@ -0,0 +1,189 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// —— 测试辅助 ——
+// 各格式的测试样本都在测试中现场构造，不在仓库中放二进制样本文件。
+
+// newTestScrubber 返回不输出日志、不备份的 Scrubber
+func newTestScrubber() *Scrubber {
+	return &Scrubber{Workers: 2, Logger: NewLogger(io.Discard, LevelError, false)}
+}
+
+// zipBytes 按给出的顺序把 name、content 交替排列的条目写成 zip
+func zipBytes(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		w, err := zw.Create(entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, entries[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeTestFile 在 dir 下写入 name，返回完整路径
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// readZip 读取 zip 文件，返回按条目顺序的名字与各条目内容
+func readZip(t *testing.T, path string) ([]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		parts[f.Name] = string(b)
+	}
+	return names, parts
+}
+
+const (
+	testContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`</Types>`
+	testRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`</Relationships>`
+	testCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:creator>Alice Secret</dc:creator></cp:coreProperties>`
+	testWordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+)
+
+// testDocx 返回最小 docx 的条目（name、content 交替），body 为 word/document.xml 中 w:body 的内容，extra 追加在后
+func testDocx(body string, extra ...string) []string {
+	entries := []string{
+		"[Content_Types].xml", testContentTypes,
+		"_rels/.rels", testRootRels,
+		"docProps/core.xml", testCore,
+		"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:document ` + testWordNS + `><w:body>` + body + `</w:body></w:document>`,
+	}
+	return append(entries, extra...)
+}
+
+// 16 个 worker 并发处理时各计数之和应等于文件数（配合 go test -race 检查数据竞争）
+func TestConcurrentCounters(t *testing.T) {
+	const n = 200
+	dir := t.TempDir()
+	good := zipBytes(t, testDocx(`<w:p/>`)...)
+	var files []string
+	wantFailed := 0
+	for i := range n {
+		data := good
+		if i%3 == 0 {
+			data = []byte("not a zip")
+			wantFailed++
+		}
+		files = append(files, writeTestFile(t, dir, fmt.Sprintf("f%03d.docx", i), data))
+	}
+
+	s := newTestScrubber()
+	s.Workers = 16
+	var seen atomic.Int64
+	s.OnResult = func(FileResult) { seen.Add(1) }
+	rep := s.ScrubFiles(dir, files)
+	if got := rep.OK + rep.Failed; got != n {
+		t.Errorf("OK(%d) + Failed(%d) = %d, 期望 %d", rep.OK, rep.Failed, got, n)
+	}
+	if rep.Failed != int64(wantFailed) {
+		t.Errorf("Failed = %d, 期望 %d", rep.Failed, wantFailed)
+	}
+	if seen.Load() != n {
+		t.Errorf("OnResult 调用 %d 次, 期望 %d", seen.Load(), n)
+	}
+	for i, r := range rep.Results {
+		if r.Path != files[i] {
+			t.Fatalf("Results[%d] 为 %s, 期望 %s", i, r.Path, files[i])
+		}
+	}
+}
+
+func TestPreserveMtime(t *testing.T) {
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, c := range []struct {
+		name     string
+		preserve bool
+		outDir   bool
+	}{
+		{"原地处理", true, false},
+		{"写到输出目录", true, true},
+		{"未开启", false, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := writeTestFile(t, dir, "a.docx", zipBytes(t, testDocx(`<w:p/>`)...))
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+			s := newTestScrubber()
+			s.PreserveMtime = c.preserve
+			out := p
+			if c.outDir {
+				s.OutputDir = t.TempDir()
+				out = s.destPath(p, dir)
+			}
+			if err := s.ScrubFile(p); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diff := info.ModTime().Sub(old).Abs()
+			if c.preserve && diff > time.Second {
+				t.Errorf("修改时间为 %v, 期望 %v", info.ModTime(), old)
+			}
+			if !c.preserve && diff <= time.Second {
+				t.Error("未开启 PreserveMtime 时修改时间应为处理时间")
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// —— 删除前另存元数据（ExtractMetadata）——
+// 脱敏是不可逆的；摄影师等用户有时希望把原始信息单独留档。开启后在处理每个文件之前，
+// 把将被删除的元数据写到输出文件旁的 <文件名>.metadata.json：
+//   - 与 dry-run 相同的检查结果（findings）；
+//   - JPEG：EXIF 中的文本字段与 GPS 坐标，以及 XMP 包原文；
+//   - Office/OpenDocument：docProps/*.xml、meta.xml 中的全部字段。
+// 未发现元数据时不写；写入先落到同目录的临时文件再改名，中途失败不会留下半份副本。
+// 副本本身就是被删除的敏感信息，权限为 0600，分发输出时注意不要把它一并带上。
+
+const sidecarSuffix = ".metadata.json"
+
+// metadataSidecar 是元数据副本的内容
+type metadataSidecar struct {
+	Source     string    `json:"source"`
+	Type       string    `json:"type"`
+	Findings   []Finding `json:"findings"`             // 与 dry-run 列出的内容相同
+	EXIF       []Finding `json:"exif,omitempty"`       // JPEG EXIF 的文本字段与 GPS 坐标
+	XMP        string    `json:"xmp,omitempty"`        // JPEG 中 XMP 包的原文
+	Properties []Finding `json:"properties,omitempty"` // 文档属性部件中的全部字段
+}
+
+// extractMetadata 在处理 p 之前把将被删除的元数据写到 dst 旁的副本，未发现元数据时什么也不做
+func (s *Scrubber) extractMetadata(p, dst string) error {
+	fs, err := s.Inspect(p)
+	if err != nil {
+		return fmt.Errorf("读取元数据失败: %w", err)
+	}
+	if len(fs) == 0 {
+		return nil
+	}
+	ext, _ := s.effectiveExt(p)
+	sc := metadataSidecar{Source: p, Type: kindOf(ext), Findings: fs}
+	switch sc.Type {
+	case "image":
+		if ext == ".jpg" || ext == ".jpeg" {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			sc.EXIF, sc.XMP = jpegMetadata(data)
+		}
+	case "openxml", "opendoc":
+		sc.Properties, err = zipPropertyValues(p, func(name string) bool {
+			name = strings.ToLower(name)
+			return name == "meta.xml" || strings.HasPrefix(name, "docprops/") && strings.HasSuffix(name, ".xml")
+		})
+		if err != nil {
+			return fmt.Errorf("读取文档属性失败: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.writeSidecar(dst+sidecarSuffix, data)
+}
+
+// writeSidecar 经同目录的临时文件原子写入 path
+func (s *Scrubber) writeSidecar(path string, data []byte) error {
+	f, err := s.createTemp(path)
+	if err != nil {
+		return err
+	}
+	defer removeTemp(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("写入元数据副本失败: %w", err)
+	}
+	return nil
+}
+
+// exifTagNames 为副本中按名称列出的 EXIF 文本字段，其余 ASCII 字段以十六进制标签号列出
+var exifTagNames = map[uint16]string{
+	0x010E: "ImageDescription", tagMake: "Make", tagModel: "Model", 0x0131: "Software",
+	0x0132: "DateTime", 0x013B: "Artist", 0x8298: "Copyright",
+	tagDateTimeOriginal: "DateTimeOriginal", 0x9004: "DateTimeDigitized",
+	tagCameraOwnerName: "CameraOwnerName", tagBodySerialNumber: "BodySerialNumber",
+	0xA434: "LensModel", tagLensSerialNumber: "LensSerialNumber",
+}
+
+// jpegMetadata 读出 JPEG 中 IFD0 与 Exif IFD 的全部 ASCII 字段、GPS 坐标，以及 XMP 包原文
+func jpegMetadata(b []byte) (exif []Finding, xmp string) {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return nil, ""
+	}
+	for _, seg := range segs {
+		if seg.marker != markerAPP1 {
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(seg.data, xmpHeader):
+			xmp = string(seg.data[len(xmpHeader):])
+		case bytes.HasPrefix(seg.data, exifHeader):
+			t, ifd0, err := newTIFFBlock(seg.data[len(exifHeader):])
+			if err != nil {
+				continue
+			}
+			exif = append(exif, t.asciiFields(ifd0)...)
+			if p := t.find(ifd0, tagExifIFD); p >= 0 {
+				exif = append(exif, t.asciiFields(t.value32(p))...)
+			}
+			if p := t.find(ifd0, tagGPSIFD); p >= 0 {
+				exif = append(exif, t.gpsFields(t.value32(p))...)
+			}
+		}
+	}
+	return exif, xmp
+}
+
+// asciiFields 列出 IFD 中全部非空的 ASCII 字段
+func (t *tiffBlock) asciiFields(off uint32) []Finding {
+	n, err := t.entries(off)
+	if err != nil {
+		return nil
+	}
+	var fs []Finding
+	for i := 0; i < n; i++ {
+		tag := t.tag(t.entryPos(off, i))
+		v := t.ascii(off, tag)
+		if v == "" {
+			continue
+		}
+		name, ok := exifTagNames[tag]
+		if !ok {
+			name = fmt.Sprintf("0x%04X", tag)
+		}
+		fs = append(fs, Finding{Item: name, Value: v})
+	}
+	return fs
+}
+
+// gpsFields 以十进制度数列出 GPS IFD 中的纬度与经度
+func (t *tiffBlock) gpsFields(off uint32) []Finding {
+	var fs []Finding
+	for _, c := range []struct {
+		name     string
+		ref, val uint16
+	}{{"GPSLatitude", 0x0001, 0x0002}, {"GPSLongitude", 0x0003, 0x0004}} {
+		dms := t.rationals(off, c.val)
+		if len(dms) != 3 {
+			continue
+		}
+		deg := dms[0] + dms[1]/60 + dms[2]/3600
+		if ref := t.ascii(off, c.ref); ref == "S" || ref == "W" {
+			deg = -deg
+		}
+		fs = append(fs, Finding{Item: c.name, Value: fmt.Sprintf("%.6f", deg)})
+	}
+	return fs
+}
+
+// rationals 读取 RATIONAL 类型字段的全部取值，类型不符、越界或分母为 0 时返回 nil
+func (t *tiffBlock) rationals(off uint32, tag uint16) []float64 {
+	p := t.find(off, tag)
+	if p < 0 || t.bo.Uint16(t.b[p+2:p+4]) != 5 {
+		return nil
+	}
+	n := int(t.bo.Uint32(t.b[p+4 : p+8]))
+	start := int(t.value32(p))
+	if n <= 0 || n > 16 || start < 0 || start+n*8 > len(t.b) {
+		return nil
+	}
+	vs := make([]float64, n)
+	for i := range vs {
+		num := t.bo.Uint32(t.b[start+i*8:])
+		den := t.bo.Uint32(t.b[start+i*8+4:])
+		if den == 0 {
+			return nil
+		}
+		vs[i] = float64(num) / float64(den)
+	}
+	return vs
+}
+
+// zipPropertyValues 读出归档中 want 所选部件内全部非空的文本字段。
+// 字段名取元素本地名；自定义属性（<property name="…"><vt:lpwstr>…）取 name 属性
+func zipPropertyValues(p string, want func(name string) bool) ([]Finding, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	var fs []Finding
+	for _, zf := range zr.File {
+		if !want(zf.Name) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		vals, err := xmlTextFields(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+		for _, v := range vals {
+			v.Item = filepath.ToSlash(zf.Name) + " " + v.Item
+			fs = append(fs, v)
+		}
+	}
+	return fs, nil
+}
+
+// xmlTextFields 列出文档中每个元素的非空文本，字段名取最近一个带 name 属性的祖先，没有时取元素本地名
+func xmlTextFields(r io.Reader) ([]Finding, error) {
+	var fs []Finding
+	var stack []string // 各层元素对应的字段名
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return fs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if len(stack) > 0 && strings.HasPrefix(stack[len(stack)-1], "@") {
+				name = stack[len(stack)-1] // 自定义属性的取值元素沿用属性名
+			}
+			for _, a := range t.Attr {
+				if a.Name.Local == "name" && a.Value != "" {
+					name = "@" + a.Value
+				}
+			}
+			stack = append(stack, name)
+		case xml.CharData:
+			if v := strings.TrimSpace(string(t)); v != "" && len(stack) > 0 {
+				fs = append(fs, Finding{Item: strings.TrimPrefix(stack[len(stack)-1], "@"), Value: v})
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// —— 按内容（魔数）识别文件类型 ——
+// 扩展名可能与实际内容不符（改了后缀的 PNG、去掉后缀的 docx），
+// 直接按扩展名重编码会损坏文件，因此分发前先读取文件头确认真实类型。
+
+// sniffExt 返回按内容识别出的规范扩展名，无法识别时返回空串
+func sniffExt(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	hdr := make([]byte, 262)
+	n, _ := io.ReadFull(f, hdr)
+	f.Close()
+	hdr = hdr[:n]
+
+	switch {
+	case bytes.HasPrefix(hdr, []byte("PK\x03\x04")):
+		return sniffZip(path)
+	case bytes.HasPrefix(hdr, []byte{0xFF, 0xD8, 0xFF}):
+		return ".jpg"
+	case bytes.HasPrefix(hdr, []byte("\x89PNG\r\n\x1a\n")):
+		return ".png"
+	case bytes.HasPrefix(hdr, []byte("GIF87a")), bytes.HasPrefix(hdr, []byte("GIF89a")):
+		return ".gif"
+	case bytes.HasPrefix(hdr, []byte(`{\rtf`)):
+		return ".rtf"
+	case bytes.HasPrefix(hdr, []byte("%PDF-")):
+		return ".pdf"
+	case bytes.HasPrefix(hdr, flacMagic):
+		return ".flac"
+	case bytes.HasPrefix(hdr, []byte("ID3")):
+		// FLAC 前也可能带 ID3v2，此时以扩展名为准
+		if strings.ToLower(filepath.Ext(path)) == ".flac" {
+			return ".flac"
+		}
+		return ".mp3"
+	case len(hdr) >= 12 && string(hdr[:4]) == "RIFF" && string(hdr[8:12]) == "WEBP":
+		return ".webp"
+	case len(hdr) >= 18 && string(hdr[:2]) == "BM" && bmpHeaderLens[binary.LittleEndian.Uint32(hdr[14:18])]:
+		// "BM" 只有两个字节，再核对信息头长度以免误判
+		return ".bmp"
+	case bytes.HasPrefix(hdr, []byte("II*\x00")), bytes.HasPrefix(hdr, []byte("MM\x00*")):
+		return ".tif"
+	case len(hdr) >= 12 && string(hdr[4:8]) == "ftyp":
+		switch string(hdr[8:12]) {
+		case "heic", "heix", "hevc", "heim", "heis", "mif1", "msf1":
+			return ".heic"
+		case "qt  ":
+			return ".mov"
+		case "isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "M4V ", "MSNV", "dash":
+			return ".mp4"
+		}
+	case bytes.HasPrefix(hdr, []byte{0x1F, 0x8B}):
+		// 不解压无法区分 tar.gz 与普通 gzip，以扩展名为准
+		if fileExt(path) == ".tgz" {
+			return ".tgz"
+		}
+	case len(hdr) >= 262 && string(hdr[257:262]) == "ustar":
+		return ".tar"
+	case len(hdr) >= 132 && string(hdr[128:132]) == "DICM":
+		return ".dcm"
+	}
+	return ""
+}
+
+// sniffZip 根据 zip 内的条目区分 OOXML 与 OpenDocument（只读取中央目录，不解压正文）
+func sniffZip(path string) string {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return ""
+	}
+	defer zr.Close()
+	return zipKind(&zr.Reader)
+}
+
+// zipKind 按条目判断归档对应的文档扩展名，普通 zip 返回空串
+func zipKind(zr *zip.Reader) string {
+	for _, zf := range zr.File {
+		switch zf.Name {
+		case "word/document.xml":
+			return ".docx"
+		case "xl/workbook.xml":
+			return ".xlsx"
+		case "ppt/presentation.xml":
+			return ".pptx"
+		case "visio/document.xml":
+			return ".vsdx"
+		case "Index/Document.iwa":
+			// Pages、Numbers、Keynote 的包结构相同，无法按条目区分，统一记为 .pages
+			return ".pages"
+		case "mimetype":
+			r, err := zf.Open()
+			if err != nil {
+				return ""
+			}
+			mt, _ := io.ReadAll(io.LimitReader(r, 128))
+			r.Close()
+			switch strings.TrimSpace(string(mt)) {
+			case "application/vnd.oasis.opendocument.text":
+				return ".odt"
+			case "application/vnd.oasis.opendocument.spreadsheet":
+				return ".ods"
+			case "application/vnd.oasis.opendocument.presentation":
+				return ".odp"
+			case "application/epub+zip":
+				return ".epub"
+			}
+		}
+	}
+	return ""
+}
+
+// formatKey 将同一格式的不同扩展名归一，用于判断扩展名与内容是否一致
+func formatKey(ext string) string {
+	switch ext {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".tif", ".tiff":
+		return "tiff"
+	case ".heic", ".heif":
+		return "heic"
+	}
+	if k := kindOf(ext); k == "openxml" || k == "opendoc" || k == "video" || k == "iwork" {
+		// 同一家族内的处理方式相同，docx/xlsx、mp4/mov 互相混用不算不符
+		return k
+	}
+	return ext
+}
+
+// effectiveExt 返回用于分发的扩展名：内容与扩展名不符时以内容为准。
+// 仅对受支持或没有扩展名的文件读取文件头，其余文件（如 .txt）直接跳过以免拖慢遍历。
+func (s *Scrubber) effectiveExt(path string) (ext string, mismatch bool) {
+	ext = fileExt(path)
+	if s.StrictExt || (ext != "" && !isSupportedExt(ext)) {
+		return ext, false
+	}
+	detected := sniffExt(path)
+	if detected == "" || formatKey(detected) == formatKey(ext) {
+		return ext, false
+	}
+	return detected, true
+}
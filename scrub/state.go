@@ -0,0 +1,161 @@
+package scrub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// —— 增量处理：跳过上次已处理且未改动的文件 ——
+// 对同一棵目录反复运行时，逐个重新处理既费时，JPEG 每重新编码一次还会再损失一点画质。
+// 状态文件（JSON）以输入文件的真实绝对路径为键，记录上次成功处理后该文件的大小、修改时间与输出路径；
+// 再次运行时三者一致且输出仍然存在的文件记为 unchanged 直接跳过，文件被改动或替换后会重新处理。
+// 不在输入目录中写旁路标记文件（如 .scrubbed），避免留下额外的文件。
+// 每条记录同时保存影响输出内容的选项指纹（见 optionsFingerprint）：改用其他选项（如加上 --deep-office）时
+// 指纹不同，文件按已改动重新处理，不会因为上次以较弱的选项处理过而留下本次要求删除的元数据。
+
+// State 是增量处理的状态，可被多个 worker 并发使用
+type State struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]stateEntry
+	dirty   bool
+}
+
+type stateEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // UnixNano
+	Output  string `json:"output"`
+	Options string `json:"options"` // 处理时的选项指纹，旧版状态文件中为空，视为不一致
+}
+
+// DefaultStatePath 返回默认的状态文件路径（用户缓存目录下），无法确定缓存目录时返回空串
+func DefaultStatePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "DataMasking", "state.json")
+}
+
+// LoadState 读取状态文件，文件不存在时返回空状态
+func LoadState(path string) (*State, error) {
+	st := &State{path: path, entries: map[string]stateEntry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &st.entries); err != nil {
+		return nil, fmt.Errorf("状态文件 %s 已损坏（可删除后重试）: %w", path, err)
+	}
+	return st, nil
+}
+
+// Save 将状态写回文件（先写临时文件再替换）；没有新记录时不写
+func (st *State) Save() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(st.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(st.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".state.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), st.path); err != nil {
+		return err
+	}
+	st.dirty = false
+	return nil
+}
+
+// stateOptionsVersion 随指纹包含的选项变化而递增，使旧记录全部失效
+const stateOptionsVersion = 1
+
+// optionsFingerprint 返回影响输出内容的选项的摘要；只影响流程（并发、校验、日志等）的选项不计入
+func (s *Scrubber) optionsFingerprint() string {
+	opts := struct {
+		Version                                  int
+		JPEGMode                                 string
+		KeepThumbnail                            bool
+		JPEGQuality                              int
+		StripICC                                 bool
+		ZeroTimestamps, Deterministic            bool
+		CompressionMethod                        string
+		DeepOffice, DeepXLSX, DeepODF            bool
+		StripNotes, StripMacros, StripODFExtras  bool
+		NormalizeZipNames, StripZipExtra         bool
+		KeepMinimalProps, DerefContentTypes      bool
+		RecursiveZip, StripMacFiles              bool
+		PDFDecrypt                               bool
+		PDFStripAttachments, PDFStripAnnotations bool
+		PreserveMtime                            bool
+	}{
+		stateOptionsVersion, s.jpegMode(), s.KeepThumbnail, s.JPEGQuality, s.StripICC,
+		s.ZeroTimestamps, s.Deterministic, s.CompressionMethod,
+		s.DeepOffice, s.DeepXLSX, s.DeepODF,
+		s.StripNotes, s.StripMacros, s.StripODFExtras,
+		s.NormalizeZipNames, s.StripZipExtra,
+		s.KeepMinimalProps, s.DerefContentTypes,
+		s.RecursiveZip, s.StripMacFiles,
+		s.PDFDecrypt,
+		s.PDFStripAttachments, s.PDFStripAnnotations,
+		s.PreserveMtime,
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%+v", opts))
+	return hex.EncodeToString(sum[:8])
+}
+
+// unchanged 判断 p 自上次以指纹为 opts 的选项处理到 dst 之后是否未被改动
+func (st *State) unchanged(p, dst, opts string) bool {
+	info, err := os.Stat(p)
+	if err != nil {
+		return false
+	}
+	st.mu.Lock()
+	e, ok := st.entries[realAbs(p)]
+	st.mu.Unlock()
+	if !ok || e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() || e.Output != realAbs(dst) || e.Options != opts {
+		return false
+	}
+	_, err = os.Stat(dst)
+	return err == nil
+}
+
+// record 记录 p 已以指纹为 opts 的选项成功处理到 dst；原地处理时记录的是处理后的文件
+func (st *State) record(p, dst, opts string) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return
+	}
+	e := stateEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Output: realAbs(dst), Options: opts}
+	st.mu.Lock()
+	st.entries[realAbs(p)] = e
+	st.dirty = true
+	st.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package scrub
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateReprocessesWhenOptionsChange(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.docx", zipBytes(t, testDocx(`<w:p><w:r><w:t>正文</w:t></w:r></w:p>`)...))
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	run := func(deep bool) Report {
+		t.Helper()
+		st, err := LoadState(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newTestScrubber()
+		s.State, s.DeepOffice = st, deep
+		rep, err := s.ScrubDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return rep
+	}
+
+	if rep := run(false); rep.OK != 1 {
+		t.Fatalf("首次处理: OK=%d, 期望 1", rep.OK)
+	}
+	if rep := run(false); rep.Unchanged != 1 || rep.OK != 0 {
+		t.Fatalf("相同选项再次处理: OK=%d Unchanged=%d, 期望跳过", rep.OK, rep.Unchanged)
+	}
+	if rep := run(true); rep.OK != 1 || rep.Unchanged != 0 {
+		t.Fatalf("加上 DeepOffice: OK=%d Unchanged=%d, 期望重新处理", rep.OK, rep.Unchanged)
+	}
+	if rep := run(true); rep.Unchanged != 1 {
+		t.Fatalf("DeepOffice 再次处理: Unchanged=%d, 期望跳过", rep.Unchanged)
+	}
+}
+
+func TestOptionsFingerprint(t *testing.T) {
+	a, b := newTestScrubber(), newTestScrubber()
+	b.Workers, b.Verify = 8, true
+	if a.optionsFingerprint() != b.optionsFingerprint() {
+		t.Error("只影响流程的选项不应改变指纹")
+	}
+	for name, set := range map[string]func(s *Scrubber){
+		"DeepOffice":          func(s *Scrubber) { s.DeepOffice = true },
+		"StripMode":           func(s *Scrubber) { s.StripMode = "full" },
+		"PDFStripAnnotations": func(s *Scrubber) { s.PDFStripAnnotations = true },
+	} {
+		c := newTestScrubber()
+		set(c)
+		if c.optionsFingerprint() == a.optionsFingerprint() {
+			t.Errorf("%s 应改变指纹", name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package scrub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// —— 流式接口：处理内存中或网络上传来的单个文件 ——
+// zip 类格式与图片的核心处理（scrubZipStream、scrubImageStream）不依赖路径，但其余格式、
+// --verify 与内容比较仍按文件处理，因此与 tar 成员相同：先把输入写入私有工作目录，按原有逻辑原地处理（不备份、不另行输出），
+// 再把结果复制到 w。超时、--verify、内容比较等按单个文件的选项照常生效；
+// 失败时不会向 w 写入任何内容。HEIC 输出为 JPEG，见 OutputExt。
+
+// ScrubStream 处理从 r 读取的单个文件并把结果写入 w。ext 为文件的扩展名（可带或不带点，
+// 决定处理方式与 include/exclude 过滤），为空时按内容识别
+func (s *Scrubber) ScrubStream(r io.Reader, ext string, w io.Writer) error {
+	return s.ScrubStreamContext(context.Background(), r, ext, w)
+}
+
+// ScrubStreamContext 与 ScrubStream 相同，但 ctx 取消后不再开始写出
+func (s *Scrubber) ScrubStreamContext(ctx context.Context, r io.Reader, ext string, w io.Writer) error {
+	work, err := os.MkdirTemp("", "goscrub-stream-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+
+	if ext = trimDot(strings.ToLower(strings.TrimSpace(ext))); ext != "" {
+		ext = "." + ext
+	}
+	tmp := filepath.Join(work, "upload"+ext)
+	if err := writeMember(tmp, r); err != nil {
+		return fmt.Errorf("读取输入失败: %w", err)
+	}
+	c := s.streamScrubber()
+	if err := c.Check(tmp); err != nil {
+		return err
+	}
+	eff, _ := c.effectiveExt(tmp)
+	if err := c.scrubFileTimeout(ctx, tmp, ""); err != nil {
+		return err
+	}
+
+	out := tmp
+	if heicSet[eff] {
+		out = heicOutput(tmp)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// streamScrubber 返回流式处理用的副本：在工作目录中原地处理，不备份、不另行输出、不记录状态
+func (s *Scrubber) streamScrubber() *Scrubber {
+	c := *s
+	c.Backup, c.BackupDir, c.OutputDir, c.Suffix, c.output = false, "", "", "", ""
+	c.PreserveMtime, c.DryRun = false, false
+	c.State = nil
+	return &c
+}
+
+// OutputExt 返回扩展名为 ext 的文件处理后的扩展名：HEIC/HEIF 转码为 .jpg，其余不变
+func OutputExt(ext string) string {
+	ext = "." + trimDot(strings.ToLower(ext))
+	if heicSet[ext] {
+		return ".jpg"
+	}
+	return ext
+}
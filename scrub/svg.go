@@ -0,0 +1,169 @@
+package scrub
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// —— SVG：删除 <metadata>、RDF 与编辑器私有命名空间 ——
+// Inkscape/Illustrator 保存的 SVG 在 <metadata> 中以 RDF 记录 dc:creator 等信息，
+// 并用 inkscape:/sodipodi:/i: 等私有命名空间保存画布设置、导出路径（常为绝对路径）与文档名。
+// 这些内容不参与渲染：整元素删除编辑器命名空间下的元素、删除此类属性与其 xmlns 声明，
+// viewBox 与全部绘图元素原样保留。编辑器命名空间按 URI 识别，不依赖具体前缀；
+// Illustrator 以 DOCTYPE 中的实体声明命名空间（xmlns:i="&ns_ai;"），按展开后的 URI 比较（见 declareEntities）。
+
+// svgEditorNS 为编辑器私有命名空间 URI（前缀匹配）
+var svgEditorNS = []string{
+	"http://www.inkscape.org/namespaces/inkscape",
+	"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd",
+	"http://ns.adobe.com/", // Illustrator 的 i:、x:、graph: 等
+	"http://www.bohemiancoding.com/sketch/ns",
+	"http://www.serif.com/",
+}
+
+func isSVGEditorNS(uri string) bool {
+	for _, ns := range svgEditorNS {
+		if strings.HasPrefix(uri, ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// svgScope 记录文档中绑定到编辑器命名空间的前缀
+type svgScope map[string]bool
+
+// declare 记录 el 上声明的编辑器前缀
+func (sc svgScope) declare(el xml.StartElement) {
+	for _, a := range el.Attr {
+		if a.Name.Space == "xmlns" && isSVGEditorNS(a.Value) {
+			sc[a.Name.Local] = true
+		}
+	}
+}
+
+// dropElement 判断元素是否整体删除：<metadata>、rdf:RDF 与编辑器命名空间下的元素
+func (sc svgScope) dropElement(el xml.StartElement) bool {
+	return el.Name.Local == "metadata" || (el.Name.Space == "rdf" && el.Name.Local == "RDF") || sc[el.Name.Space]
+}
+
+// dropAttr 判断属性是否删除：编辑器命名空间下的属性及其 xmlns 声明
+func (sc svgScope) dropAttr(a xml.Attr) bool {
+	return sc[a.Name.Space] || (a.Name.Space == "xmlns" && sc[a.Name.Local])
+}
+
+func init() {
+	RegisterHandler(".svg", handlerFuncs{
+		kind:    "svg",
+		scrub:   func(s *Scrubber, p, dst, _ string) error { return s.scrubSVG(p, dst) },
+		inspect: func(_ *Scrubber, p, _ string) ([]Finding, error) { return inspectSVG(p) },
+		verify:  func(_ *Scrubber, out, _ string) error { return verifySVG(out) },
+		info:    HandlerInfo{Method: "流式改写 XML", Removes: "<metadata>/RDF 与 Inkscape、Illustrator 等编辑器私有的元素和属性"},
+	})
+}
+
+func (s *Scrubber) scrubSVG(path, dst string) error {
+	sc := svgScope{}
+	drop := func(el xml.StartElement) bool {
+		// 声明通常在根元素上，先记录再判断，本元素的属性也能据此过滤
+		sc.declare(el)
+		return sc.dropElement(el)
+	}
+	edit := func(el *xml.StartElement) {
+		attrs := el.Attr[:0]
+		for _, a := range el.Attr {
+			if !sc.dropAttr(a) {
+				attrs = append(attrs, a)
+			}
+		}
+		el.Attr = attrs
+	}
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		return filterXML(in, w, drop, edit)
+	})
+}
+
+// scanSVG 逐个报告会被删除的元素与属性（已删除元素内部不再深入）
+func scanSVG(path string, visit func(f Finding)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := svgScope{}
+	d := xml.NewDecoder(f)
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解析 SVG 失败: %w", err)
+		}
+		if dir, ok := tok.(xml.Directive); ok {
+			// Illustrator 的命名空间声明引用 DOCTYPE 中的实体
+			declareEntities(d, dir)
+			continue
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		sc.declare(el)
+		if sc.dropElement(el) {
+			visit(Finding{Item: "<" + qname(el.Name) + ">"})
+			// RawToken 不做配对检查，不能混用 Decoder.Skip，按深度手工跳过
+			for depth := 1; depth > 0; {
+				tok, err := d.RawToken()
+				if err != nil {
+					return fmt.Errorf("解析 SVG 失败: %w", err)
+				}
+				switch tok.(type) {
+				case xml.StartElement:
+					depth++
+				case xml.EndElement:
+					depth--
+				}
+			}
+			continue
+		}
+		for _, a := range el.Attr {
+			if sc.dropAttr(a) && a.Name.Space != "xmlns" {
+				visit(Finding{Item: qname(a.Name), Value: a.Value})
+			}
+		}
+	}
+}
+
+func inspectSVG(path string) ([]Finding, error) {
+	var fs []Finding
+	err := scanSVG(path, func(f Finding) { fs = append(fs, f) })
+	return fs, err
+}
+
+// verifySVG 检查输出中不再有 <metadata>、RDF 与编辑器命名空间的内容
+func verifySVG(path string) error {
+	var first *Finding
+	err := scanSVG(path, func(f Finding) {
+		if first == nil {
+			first = &f
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if first != nil {
+		return fmt.Errorf("仍包含 %s", first.Item)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package scrub
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInkscapeSVGCleaned(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:svg="http://www.w3.org/2000/svg"
+  xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape"
+  xmlns:sodipodi="http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd"
+  xmlns:ink2="http://www.inkscape.org/namespaces/inkscape"
+  xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:dc="http://purl.org/dc/elements/1.1/"
+  width="10" height="10" viewBox="0 0 10 10" sodipodi:docname="secret-plan.svg"
+  inkscape:version="1.3" inkscape:export-filename="/home/alice/export.png">
+  <sodipodi:namedview id="nv" inkscape:window-width="1920"><inkscape:page x="0" y="0"/></sodipodi:namedview>
+  <metadata><rdf:RDF><dc:creator>Alice Secret</dc:creator></rdf:RDF></metadata>
+  <g id="layer1" inkscape:label="Layer 1" inkscape:groupmode="layer" ink2:collect="always">
+    <path d="M0 0L10 10" style="stroke:#000"/>
+  </g>
+</svg>
+`
+	p := writeTestFile(t, t.TempDir(), "a.svg", []byte(in))
+	s := newTestScrubber()
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, leak := range []string{"inkscape", "sodipodi", "ink2:", "namedview", "metadata", "Alice", "/home/alice", "secret-plan"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("输出仍含有 %q", leak)
+		}
+	}
+	for _, keep := range []string{`viewBox="0 0 10 10"`, `<path d="M0 0L10 10" style="stroke:#000"`, `id="layer1"`} {
+		if !strings.Contains(out, keep) {
+			t.Errorf("输出缺少应保留的 %q", keep)
+		}
+	}
+	if err := xml.Unmarshal(b, new(struct{})); err != nil {
+		t.Errorf("输出不是合法的 XML: %v", err)
+	}
+}
+
+func TestIllustratorSVGCleaned(t *testing.T) {
+	// Illustrator 以 DOCTYPE 中的实体声明命名空间，严格解析时必须先登记这些实体
+	in := `<?xml version="1.0" encoding="utf-8"?>
+<!-- Generator: Adobe Illustrator 27.0.0, SVG Export Plug-In . SVG Version: 6.00 Build 0)  -->
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd" [
+	<!ENTITY ns_extend "http://ns.adobe.com/Extensibility/1.0/">
+	<!ENTITY ns_ai "http://ns.adobe.com/AdobeIllustrator/10.0/">
+	<!ENTITY ns_graphs "http://ns.adobe.com/Graphs/1.0/">
+	<!ENTITY ns_vars "http://ns.adobe.com/Variables/1.0/">
+	<!ENTITY ns_sfw "http://ns.adobe.com/SaveForWeb/1.0/">
+	<!ENTITY ns_svg "http://www.w3.org/2000/svg">
+	<!ENTITY ns_xlink "http://www.w3.org/1999/xlink">
+]>
+<svg version="1.1" xmlns:x="&ns_extend;" xmlns:i="&ns_ai;" xmlns:graph="&ns_graphs;"
+	 xmlns="&ns_svg;" xmlns:xlink="&ns_xlink;" x="0px" y="0px" viewBox="0 0 10 10"
+	 xml:space="preserve" i:viewOrigin="-200 400" i:rulerOrigin="0 0" i:pageBounds="0 792 612 0">
+<metadata>
+	<sfw xmlns="&ns_sfw;"><slices></slices><sliceSourceBounds bottomLeftOrigin="true" height="10" width="10" x="0" y="0"></sliceSourceBounds></sfw>
+</metadata>
+<switch>
+	<foreignObject requiredExtensions="&ns_ai;" x="0" y="0" width="1" height="1">
+		<i:pgfRef xlink:href="#adobe_illustrator_pgf"></i:pgfRef>
+	</foreignObject>
+	<g i:extraneous="self">
+		<path d="M0 0L10 10" style="stroke:#000"/>
+	</g>
+</switch>
+<i:pgf id="adobe_illustrator_pgf"><![CDATA[eJzLSM3JyVcozy/KSVEEAB0JBF4= Alice Secret /Users/alice/Desktop/plan.ai]]></i:pgf>
+</svg>
+`
+	p := writeTestFile(t, t.TempDir(), "ai.svg", []byte(in))
+	s := newTestScrubber()
+	fs, err := s.Inspect(p)
+	if err != nil {
+		t.Fatalf("Inspect 失败: %v", err)
+	}
+	var items []string
+	for _, f := range fs {
+		items = append(items, f.Item)
+	}
+	for _, want := range []string{"<metadata>", "<i:pgf>", "i:viewOrigin"} {
+		if !strings.Contains(strings.Join(items, " "), want) {
+			t.Errorf("Inspect 结果 %v 中缺少 %s", items, want)
+		}
+	}
+
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, leak := range []string{"Alice", "/Users/alice", "i:", "xmlns:x=", "xmlns:graph", "<sfw", "pgf"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("输出仍含有 %q", leak)
+		}
+	}
+	for _, keep := range []string{`viewBox="0 0 10 10"`, `<path d="M0 0L10 10" style="stroke:#000"/>`, `xmlns="http://www.w3.org/2000/svg"`} {
+		if !strings.Contains(out, keep) {
+			t.Errorf("输出缺少应保留的 %q", keep)
+		}
+	}
+	// 实体引用在输出中已经展开，不认识这些实体的解析器也能读取
+	if err := xml.Unmarshal(b, new(struct{})); err != nil {
+		t.Errorf("输出不是合法的 XML: %v", err)
+	}
+}
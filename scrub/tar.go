@@ -0,0 +1,292 @@
+package scrub
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// —— tar / tar.gz：逐个处理归档内的成员 ——
+// 打包分发的文档不必先解压再重新打包：顺序读取 tar 成员，受支持的成员写入与输出同目录的私有工作目录，
+// 按原有的处理逻辑原地脱敏（不生成备份）后写回，其余成员（目录、链接、不支持的类型）原样流式复制。
+// --include/--exclude、大小上限等筛选只针对顶层文件：成员只看类型是否受支持、所需选项（如 --with-pdf）是否开启，
+// 无法处理的成员同样原样复制，不会让整个归档失败。
+// 成员头（路径、权限、属主、修改时间、PAX 扩展）保持不变，只更新长度；gzip 头同样沿用。
+// 任一成员处理失败时整个归档记为失败，不写出部分处理的结果。
+// 为防解压炸弹，限制嵌套深度，并限制单个顶层归档内所有成员展开后的总字节数。
+// .tar.gz 按 .tgz 处理（见 fileExt）；HEIC 成员转码后文件名会变化，在归档内不处理。
+
+var tarSet = map[string]bool{
+	".tar": true, ".tgz": true,
+}
+
+const (
+	maxTarDepth    = 3
+	maxTarExpanded = 8 << 30
+)
+
+var errTarTooLarge = errors.New("tar 成员展开后的总大小超过上限")
+
+func init() {
+	registerSet(tarSet, handlerFuncs{
+		kind:    "tar",
+		scrub:   (*Scrubber).scrubTar,
+		inspect: (*Scrubber).inspectTar,
+		verify:  (*Scrubber).verifyTar,
+		info: HandlerInfo{
+			Method:  "逐个处理受支持的成员",
+			Removes: "各成员按自身类型删除的元数据；成员头原样保留",
+		},
+	})
+}
+
+// fileExt 返回小写扩展名，.tar.gz 视为 .tgz
+func fileExt(p string) string {
+	lower := strings.ToLower(p)
+	if strings.HasSuffix(lower, ".tar.gz") {
+		return ".tgz"
+	}
+	return filepath.Ext(lower)
+}
+
+// nameExt 返回 p 的扩展名（保留大小写），.tar.gz 整体作为扩展名，插入后缀时得到 a_clean.tar.gz
+func nameExt(p string) string {
+	if strings.HasSuffix(strings.ToLower(p), ".tar.gz") {
+		return p[len(p)-len(".tar.gz"):]
+	}
+	return filepath.Ext(p)
+}
+
+// tarState 为同一顶层归档内各层嵌套共享的状态
+type tarState struct {
+	depth    int
+	budget   *zipBudget
+	deadline *fileDeadline // 顶层文件的超时：成员各自写出不算作整个归档开始写出，逐个成员检查
+}
+
+// memberScrubber 返回处理归档成员用的副本：成员在工作目录中原地处理，不备份、不另行输出
+func (s *Scrubber) memberScrubber() *Scrubber {
+	c := *s
+	c.Backup, c.OutputDir, c.Suffix, c.output = false, "", "", ""
+	c.PreserveMtime = false
+	st := tarState{budget: &zipBudget{left: maxTarExpanded}, deadline: s.deadline}
+	if s.tar != nil {
+		st = *s.tar
+		st.depth++
+	}
+	c.tar = &st
+	c.deadline = nil
+	return &c
+}
+
+// memberExt 返回成员的处理扩展名；不处理（不受支持、所需选项未开启、嵌套过深）时返回空串
+func (s *Scrubber) memberExt(tmp string) string {
+	ext, _ := s.effectiveExt(tmp)
+	if !s.handlerUsable(ext) || heicSet[ext] || (tarSet[ext] && s.tar.depth >= maxTarDepth) {
+		return ""
+	}
+	if kindOf(ext) == "iwork" && sniffExt(tmp) != ".pages" {
+		return "" // 与 Check 相同：.key 也是常见的私钥文件扩展名
+	}
+	return ext
+}
+
+// tarMembers 依次把 p 中的普通文件成员解出到 work 目录并调用 fn（ext 为处理扩展名，不处理的成员为空串）；
+// out 不为 nil 时按原顺序写出新的归档，普通文件成员的内容读取自 fn 处理后的 tmp
+func (s *Scrubber) tarMembers(p, work string, out io.Writer, fn func(hdr *tar.Header, tmp, ext string) error) error {
+	in, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader = bufio.NewReader(in)
+	var gw *gzip.Writer
+	if fileExt(p) == ".tgz" {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("打开 gzip 失败: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+		if out != nil {
+			gw = gzip.NewWriter(out)
+			gw.Header = gr.Header
+			out = gw
+		}
+	}
+	tr := tar.NewReader(r)
+	var tw *tar.Writer
+	if out != nil {
+		tw = tar.NewWriter(out)
+	}
+
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 失败: %w", err)
+		}
+		if err := s.tar.deadline.check(); err != nil {
+			return err
+		}
+		if err := s.checkEntryName(hdr.Name); err != nil {
+			return err
+		}
+		if err := s.tar.budget.take(int(hdr.Size)); err != nil {
+			return errTarTooLarge
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			if err := copyTarMember(tw, hdr, tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 成员以序号加原扩展名落盘，避免成员名中的路径影响工作目录
+		tmp := filepath.Join(work, fmt.Sprintf("%d%s", i, fileExt(path.Base(hdr.Name))))
+		if err := writeMember(tmp, tr); err != nil {
+			return err
+		}
+		ext := s.memberExt(tmp)
+		if fn != nil {
+			if err := fn(hdr, tmp, ext); err != nil {
+				return fmt.Errorf("%s: %w", hdr.Name, err)
+			}
+		}
+		if tw != nil {
+			if err := writeTarFile(tw, hdr, tmp); err != nil {
+				return err
+			}
+		}
+		os.Remove(tmp)
+	}
+	if tw != nil {
+		if err := tw.Close(); err != nil {
+			return err
+		}
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
+
+func writeMember(tmp string, r io.Reader) error {
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func copyTarMember(tw *tar.Writer, hdr *tar.Header, r io.Reader) error {
+	if tw == nil {
+		return nil
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// writeTarFile 以原成员头写出 tmp 的内容，长度按处理后的文件更新
+func writeTarFile(tw *tar.Writer, hdr *tar.Header, tmp string) error {
+	f, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	h := *hdr
+	h.Size = info.Size()
+	return copyTarMember(tw, &h, f)
+}
+
+// tarWorkDir 在 dir 下创建私有工作目录；调用方负责 os.RemoveAll
+func tarWorkDir(dir, base string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	return os.MkdirTemp(dir, "."+base+".*.d")
+}
+
+func (s *Scrubber) scrubTar(path, dst, _ string) error {
+	work, err := tarWorkDir(filepath.Dir(dst), filepath.Base(dst))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+
+	m := s.memberScrubber()
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		return m.tarMembers(path, work, w, func(_ *tar.Header, tmp, ext string) error {
+			if ext == "" {
+				return nil
+			}
+			return m.dispatch(tmp, tmp, ext)
+		})
+	})
+}
+
+// inspectTar 列出每个受支持成员中将被删除的元数据，条目前加成员路径
+func (s *Scrubber) inspectTar(path, _ string) ([]Finding, error) {
+	work, err := os.MkdirTemp("", "goscrub-tar-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	var fs []Finding
+	m := s.memberScrubber()
+	err = m.tarMembers(path, work, nil, func(hdr *tar.Header, tmp, ext string) error {
+		if ext == "" {
+			return nil
+		}
+		h, _ := handlerFor(ext)
+		found, err := h.Inspect(m, tmp, ext)
+		if err != nil {
+			return err
+		}
+		for _, f := range found {
+			f.Item = hdr.Name + ": " + f.Item
+			fs = append(fs, f)
+		}
+		return nil
+	})
+	return fs, err
+}
+
+// verifyTar 对每个受支持的成员按其类型校验
+func (s *Scrubber) verifyTar(out, _ string) error {
+	work, err := os.MkdirTemp("", "goscrub-tar-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+
+	m := s.memberScrubber()
+	return m.tarMembers(out, work, nil, func(_ *tar.Header, tmp, ext string) error {
+		if ext == "" {
+			return nil
+		}
+		return m.verify(tmp, ext)
+	})
+}
@@ -0,0 +1,135 @@
+package scrub
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarBytes 把 name、content 交替排列的普通文件成员写成 tar，gz 时再以 gzip 压缩
+func tarBytes(t *testing.T, gz bool, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gw *gzip.Writer
+	if gz {
+		gw = gzip.NewWriter(&buf)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	for i := 0; i+1 < len(entries); i += 2 {
+		hdr := &tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, entries[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// readTar 返回 tar（.tgz 时先解压）中各普通文件成员的内容
+func readTar(t *testing.T, p string) map[string][]byte {
+	t.Helper()
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if fileExt(p) == ".tgz" {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	members := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return members
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members[hdr.Name] = b
+	}
+}
+
+func TestTarMembersIgnoreTopLevelFilters(t *testing.T) {
+	docx := zipBytes(t, testDocx(`<w:p/>`)...)
+	pdf := "%PDF-1.4\n1 0 obj<</Author(Alice Secret)>>endobj\ntrailer<</Info 1 0 R>>\n%%EOF\n"
+	p := writeTestFile(t, t.TempDir(), "bundle.tgz", tarBytes(t, true,
+		"docs/a.docx", string(docx),
+		"docs/b.pdf", pdf,
+		"notes.txt", "plain text",
+	))
+
+	s := newTestScrubber()
+	s.Include = []string{"tgz"} // 只针对顶层文件，不应让 docx 成员被跳过
+	if err := s.Check(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatalf("未开启 --with-pdf 时 PDF 成员应原样保留而不是让归档失败: %v", err)
+	}
+
+	members := readTar(t, p)
+	out := filepath.Join(t.TempDir(), "a.docx")
+	if err := os.WriteFile(out, members["docs/a.docx"], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readZip(t, out)
+	if _, ok := parts["docProps/core.xml"]; ok {
+		t.Error("docx 成员未被处理：仍有 docProps/core.xml")
+	}
+	if string(members["docs/b.pdf"]) != pdf {
+		t.Error("PDF 成员应原样复制")
+	}
+	if string(members["notes.txt"]) != "plain text" {
+		t.Error("不支持的成员应原样复制")
+	}
+}
+
+func TestTarMemberExt(t *testing.T) {
+	s := newTestScrubber().memberScrubber()
+	s.Exclude = []string{"docx"}
+	dir := t.TempDir()
+	for _, c := range []struct{ name, data, want string }{
+		{"a.docx", string(zipBytes(t, testDocx("")...)), ".docx"}, // Exclude 只针对顶层文件
+		{"b.pdf", "%PDF-1.4\n", ""},                               // 未开启 WithPDF
+		{"c.txt", "plain", ""},
+	} {
+		p := writeTestFile(t, dir, c.name, []byte(c.data))
+		if got := s.memberExt(p); got != c.want {
+			t.Errorf("memberExt(%s) = %q, 期望 %q", c.name, got, c.want)
+		}
+	}
+	s.WithPDF = true
+	if pdfBuilt {
+		p := writeTestFile(t, dir, "d.pdf", []byte("%PDF-1.4\n"))
+		if got := s.memberExt(p); got != ".pdf" {
+			t.Errorf("开启 WithPDF 后 memberExt = %q, 期望 .pdf", got)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package scrub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// —— --verify：处理后重新读取输出，按类型检查元数据确实已被删除 ——
+// 这里不复用处理时的中间结果，而是从磁盘上的输出文件独立解析，
+// 用来发现“处理成功但元数据仍在”的情况（如写入失败后残留原文件）。
+
+// verify 检查 out 中不再含有该类型应被删除的元数据
+func (s *Scrubber) verify(out, ext string) error {
+	h, ok := handlerFor(ext)
+	if !ok {
+		return errUnsupported(ext)
+	}
+	if v, ok := h.(Verifier); ok {
+		return v.Verify(s, out, ext)
+	}
+	return nil
+}
+
+// verifyImage 按输出格式检查图片；HEIC 的输出同样是 JPEG
+func (s *Scrubber) verifyImage(out, ext string) error {
+	switch ext {
+	case ".jpg", ".jpeg", ".heic", ".heif":
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		return s.verifyJPEGData(data)
+	case ".tif", ".tiff":
+		if s.StripMode != "gps" {
+			return nil
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		return verifyNoGPS(data, ext)
+	case ".png":
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		return verifyPNG(data)
+	case ".webp":
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		return verifyWebP(data)
+	case ".bmp":
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		return verifyBMP(data)
+	}
+	// 其余模式下 TIFF/GIF 由编码器保证只写出像素相关数据，无需额外检查
+	return nil
+}
+
+// verifyRTF 检查输出中不再有 \info 等属性组；与处理时一样跳过控制字与 \bin 后的二进制，
+// 图片数据中恰好出现的 {\info 字节不算
+func verifyRTF(out string) error {
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' {
+			i = rtfSkipControl(data, i) - 1
+			continue
+		}
+		if data[i] == '{' && rtfIsDropGroup(data[i:]) {
+			return errors.New("仍包含 RTF 属性组")
+		}
+	}
+	return nil
+}
+
+// verifyOpenXML 检查应删除的条目与替换为空的属性部件；DerefContentTypes 时还要求关系与内容类型声明中没有悬空的引用
+func (s *Scrubber) verifyOpenXML(out, _ string) error {
+	if err := verifyZip(out, s.dropMacEntries(s.keepOpenXMLEntry)); err != nil {
+		return err
+	}
+	if s.KeepMinimalProps {
+		if err := verifyMinimalProps(out); err != nil {
+			return err
+		}
+	}
+	if s.DerefContentTypes {
+		return verifyOPCRefs(out)
+	}
+	return nil
+}
+
+// verifyZip 检查归档中没有本应被删除的条目
+func verifyZip(path string, keep func(name string) bool) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	if zr.Comment != "" {
+		return errors.New("归档注释未清除")
+	}
+	for _, zf := range zr.File {
+		if !keep(zf.Name) {
+			return fmt.Errorf("仍包含元数据条目: %s", zf.Name)
+		}
+		for _, id := range zipExtraIDs(zf.Extra) {
+			if name, ok := zipExtraNames[id]; ok {
+				return fmt.Errorf("条目 %s 仍包含扩展字段（%s）", zf.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyEPUB 检查 OPF 中没有会被删除的元数据元素
+func verifyEPUB(path string) error {
+	opf, err := epubOPFPath(path)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("打开 zip 失败: %w", err)
+	}
+	defer zr.Close()
+	f, err := zr.Open(opf)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	drop := newEPUBMetaDrop()
+	d := xml.NewDecoder(f)
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解析 OPF 失败: %w", err)
+		}
+		if el, ok := tok.(xml.StartElement); ok && drop(el) {
+			return fmt.Errorf("OPF 中仍包含 %s", qname(el.Name))
+		}
+	}
+}
+
+// verifyJPEGData 按 StripMode 检查 JPEG 数据
+func (s *Scrubber) verifyJPEGData(b []byte) error {
+	switch s.jpegMode() {
+	case "gps":
+		return verifyNoGPS(b, ".jpg")
+	case "lossless":
+		return verifyJPEGLossless(b, s.StripICC)
+	}
+	return verifyJPEG(b, s.StripMode == "selective")
+}
+
+// verifyJPEG 检查没有 XMP；full 模式下也不应再有 EXIF 与 IPTC/Photoshop 段，selective 模式下 EXIF 中不应再有 GPS IFD
+func verifyJPEG(b []byte, selective bool) error {
+	segs, _, err := splitJPEG(b)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		if seg.marker == markerAPP13 && !selective {
+			return errors.New("仍包含 IPTC/Photoshop（APP13）段")
+		}
+		if seg.marker != markerAPP1 {
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(seg.data, xmpHeader):
+			return errors.New("仍包含 XMP 段")
+		case bytes.HasPrefix(seg.data, exifHeader):
+			if !selective {
+				return errors.New("仍包含 EXIF 段")
+			}
+			t, ifd0, err := newTIFFBlock(seg.data[len(exifHeader):])
+			if err != nil {
+				return err
+			}
+			if t.find(ifd0, tagGPSIFD) >= 0 {
+				return errors.New("EXIF 中仍包含 GPS 信息")
+			}
+		}
+	}
+	return nil
+}
+
+// verifyPNG 检查没有文本与 EXIF 块
+func verifyPNG(b []byte) error {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return errors.New("不是合法的 PNG 文件")
+	}
+	for p := len(pngSignature); p+8 <= len(b); {
+		n := int(binary.BigEndian.Uint32(b[p : p+4]))
+		switch typ := string(b[p+4 : p+8]); typ {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+			return fmt.Errorf("仍包含 %s 块", typ)
+		}
+		p += 12 + n
+	}
+	return nil
+}
+
+// verifyWebP 检查没有 EXIF/XMP 块
+func verifyWebP(b []byte) error {
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return errors.New("不是合法的 WebP 文件")
+	}
+	for p := 12; p+8 <= len(b); {
+		size := int(binary.LittleEndian.Uint32(b[p+4 : p+8]))
+		switch fourCC := string(b[p : p+4]); fourCC {
+		case "EXIF", "XMP ":
+			return fmt.Errorf("仍包含 %q 块", fourCC)
+		}
+		p += 8 + size + size&1
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package scrub
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// —— MP4/MOV：删除 moov 中的 udta/meta 与 XMP uuid 盒子 ——
+// 手机拍摄的视频在 moov/udta（QuickTime 的 ©xyz 位置、©mak/©mod 设备）与 moov/meta
+// （keys/ilst 中的 com.apple.quicktime.location.ISO6709 等）里记录 GPS 与设备信息，XMP 放在 uuid 盒子中。
+// 只解析 ISO BMFF 盒子结构，不触及 mdat 中的音视频数据：moov 读入内存重写，其余顶层盒子流式复制。
+// 删除盒子会使其后的数据前移，因此按删除的字节数修正 stco/co64 中的块偏移；
+// 分片 MP4（含 moof）的偏移分散在各片段与索引中，改为把待删盒子原位替换为等长、内容清零的 free 盒子。
+
+var videoSet = map[string]bool{
+	".mp4": true, ".mov": true,
+}
+
+func init() {
+	registerSet(videoSet, handlerFuncs{
+		kind: "video",
+		scrub: func(s *Scrubber, p, dst, _ string) error {
+			if !s.WithVideo {
+				return errors.New("检测到视频，请使用 --with-video 以启用 MP4/MOV 脱敏")
+			}
+			return s.scrubVideo(p, dst)
+		},
+		inspect: func(_ *Scrubber, p, _ string) ([]Finding, error) { return inspectVideo(p) },
+		verify:  func(_ *Scrubber, out, _ string) error { return verifyVideo(out) },
+		info: HandlerInfo{
+			Method:  "删除盒子并修正块偏移，不重新编码",
+			Removes: "udta/meta 中的 GPS、设备信息与 XMP",
+			Flag:    "--with-video",
+		},
+	})
+}
+
+// xmpUUID 是 XMP 在 ISO BMFF 中使用的 uuid 盒子扩展类型
+var xmpUUID = []byte{0xBE, 0x7A, 0xCF, 0xCB, 0x97, 0xA9, 0x42, 0xE8, 0x9C, 0x71, 0x99, 0x94, 0x91, 0xE3, 0xAF, 0xAC}
+
+// maxMoovSize 限制读入内存的 moov 大小（正常视频的 moov 只有几 MB）
+const maxMoovSize = 256 << 20
+
+// mp4Containers 为需要向下查找的容器：moov/trak 下可能有 udta/meta，stbl 下是 stco/co64
+var mp4Containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+}
+
+type mp4Box struct {
+	typ  string
+	off  int64 // 盒子起点：顶层为文件偏移，moov 内为相对 moov 的偏移
+	hdr  int64 // 头长度：8，使用 64 位长度时为 16
+	size int64 // 含头的总长度
+}
+
+func (b mp4Box) end() int64 { return b.off + b.size }
+
+// readMP4Box 读取 off 处的盒子头；size 为 0 表示延续到 end
+func readMP4Box(r io.ReaderAt, off, end int64) (mp4Box, error) {
+	var h [16]byte
+	if _, err := r.ReadAt(h[:8], off); err != nil {
+		return mp4Box{}, err
+	}
+	b := mp4Box{typ: string(h[4:8]), off: off, hdr: 8, size: int64(binary.BigEndian.Uint32(h[:4]))}
+	switch b.size {
+	case 1:
+		if _, err := r.ReadAt(h[8:16], off+8); err != nil {
+			return mp4Box{}, err
+		}
+		b.hdr = 16
+		b.size = int64(binary.BigEndian.Uint64(h[8:16]))
+	case 0:
+		b.size = end - off
+	}
+	if b.size < b.hdr || b.size > end-off {
+		return mp4Box{}, fmt.Errorf("盒子 %q 长度非法", b.typ)
+	}
+	return b, nil
+}
+
+// mp4Boxes 返回 [start, end) 内连续排列的盒子；末尾不足一个盒子头的字节视为填充
+func mp4Boxes(r io.ReaderAt, start, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for off := start; end-off >= 8; {
+		b, err := readMP4Box(r, off, end)
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, b)
+		off = b.end()
+	}
+	return boxes, nil
+}
+
+// mp4Drop 判断盒子是否删除；parent 为所在容器类型，顶层为空串
+func mp4Drop(r io.ReaderAt, b mp4Box, parent string) bool {
+	switch b.typ {
+	case "udta", "meta":
+		return parent == "moov" || parent == "trak"
+	case "uuid":
+		u := make([]byte, len(xmpUUID))
+		if b.size < b.hdr+int64(len(u)) {
+			return false
+		}
+		_, err := r.ReadAt(u, b.off+b.hdr)
+		return err == nil && bytes.Equal(u, xmpUUID)
+	}
+	return false
+}
+
+// mp4Span 是一个待删除的盒子；path 形如 moov/trak/udta，用于 dry-run 与校验的提示
+type mp4Span struct {
+	mp4Box
+	path string
+}
+
+// mp4Layout 是解析后的文件结构
+type mp4Layout struct {
+	top        []mp4Box
+	moov       mp4Box    // 顶层 moov（文件偏移）
+	moovData   []byte    // moov 的完整内容
+	fragmented bool      // 含 moof，不能移动数据
+	topDrops   []mp4Span // 顶层待删盒子（文件偏移）
+	moovDrops  []mp4Span // moov 内待删盒子（相对 moov 的偏移）
+	tail       int64     // 最后一个顶层盒子之后的字节起点
+}
+
+// scanMP4 解析顶层盒子并读入 moov，收集所有待删除的盒子
+func scanMP4(r io.ReaderAt, size int64) (*mp4Layout, error) {
+	top, err := mp4Boxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	l := &mp4Layout{top: top, tail: size}
+	if len(top) > 0 {
+		l.tail = top[len(top)-1].end()
+	}
+	found := false
+	for _, b := range top {
+		switch {
+		case b.typ == "moov":
+			l.moov, found = b, true
+		case b.typ == "moof":
+			l.fragmented = true
+		case mp4Drop(r, b, ""):
+			l.topDrops = append(l.topDrops, mp4Span{b, b.typ})
+		}
+	}
+	if !found {
+		return nil, errors.New("未找到 moov 盒子，不是合法的 MP4/MOV 文件")
+	}
+	if l.moov.size > maxMoovSize {
+		return nil, fmt.Errorf("moov 过大（%d 字节）", l.moov.size)
+	}
+	l.moovData = make([]byte, l.moov.size)
+	if _, err := r.ReadAt(l.moovData, l.moov.off); err != nil {
+		return nil, err
+	}
+	root := mp4Box{typ: "moov", hdr: l.moov.hdr, size: l.moov.size}
+	if err := l.collect(bytes.NewReader(l.moovData), root, "moov"); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// collect 递归收集容器 b 内待删除的盒子
+func (l *mp4Layout) collect(r io.ReaderAt, b mp4Box, path string) error {
+	kids, err := mp4Boxes(r, b.off+b.hdr, b.end())
+	if err != nil {
+		return err
+	}
+	for _, k := range kids {
+		switch {
+		case mp4Drop(r, k, b.typ):
+			l.moovDrops = append(l.moovDrops, mp4Span{k, path + "/" + k.typ})
+		case mp4Containers[k.typ]:
+			if err := l.collect(r, k, path+"/"+k.typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shift 返回原文件偏移 o 处的数据在输出中的偏移；分片文件只填充不移动
+func (l *mp4Layout) shift(o int64) int64 {
+	if l.fragmented {
+		return o
+	}
+	n := int64(0)
+	for _, d := range l.topDrops {
+		if d.off < o {
+			n += d.size
+		}
+	}
+	for _, d := range l.moovDrops {
+		if l.moov.off+d.off < o {
+			n += d.size
+		}
+	}
+	return o - n
+}
+
+func (s *Scrubber) scrubVideo(path, dst string) error {
+	return s.writeThenReplace(path, dst, func(w io.Writer) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		return writeVideo(in, w)
+	})
+}
+
+// writeVideo 将去除元数据后的视频写入 out
+func writeVideo(in *os.File, out io.Writer) error {
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	l, err := scanMP4(in, info.Size())
+	if err != nil {
+		return err
+	}
+	var moov bytes.Buffer
+	if err := l.emit(&moov, mp4Box{typ: "moov", hdr: l.moov.hdr, size: l.moov.size}); err != nil {
+		return err
+	}
+	dropped := map[int64]mp4Box{}
+	for _, d := range l.topDrops {
+		dropped[d.off] = d.mp4Box
+	}
+
+	w := bufio.NewWriter(out)
+	for _, b := range l.top {
+		if b.typ == "moov" {
+			_, err = w.Write(moov.Bytes())
+		} else if d, ok := dropped[b.off]; ok {
+			if l.fragmented {
+				err = writeFreeBox(w, d)
+			}
+		} else {
+			_, err = io.Copy(w, io.NewSectionReader(in, b.off, b.size))
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		_, err = io.Copy(w, io.NewSectionReader(in, l.tail, info.Size()-l.tail))
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// emit 写出 moov 内的盒子 b：容器重算长度，stco/co64 修正块偏移，其余原样复制
+func (l *mp4Layout) emit(w *bytes.Buffer, b mp4Box) error {
+	data := l.moovData[b.off:b.end()]
+	switch {
+	case mp4Containers[b.typ]:
+		kids, err := mp4Boxes(bytes.NewReader(l.moovData), b.off+b.hdr, b.end())
+		if err != nil {
+			return err
+		}
+		var body bytes.Buffer
+		last := b.off + b.hdr
+		for _, k := range kids {
+			last = k.end()
+			if l.dropped(k) {
+				if l.fragmented {
+					writeFreeBox(&body, k)
+				}
+				continue
+			}
+			if err := l.emit(&body, k); err != nil {
+				return err
+			}
+		}
+		body.Write(l.moovData[last:b.end()])
+		writeBoxHeader(w, b.typ, b.hdr, b.hdr+int64(body.Len()))
+		w.Write(body.Bytes())
+	case b.typ == "stco" || b.typ == "co64":
+		out := bytes.Clone(data)
+		if err := fixChunkOffsets(out[b.hdr:], b.typ == "co64", l.shift); err != nil {
+			return err
+		}
+		w.Write(out)
+	default:
+		w.Write(data)
+	}
+	return nil
+}
+
+func (l *mp4Layout) dropped(b mp4Box) bool {
+	for _, d := range l.moovDrops {
+		if d.off == b.off {
+			return true
+		}
+	}
+	return false
+}
+
+// fixChunkOffsets 改写 stco/co64 的内容（版本与标志、条目数、偏移表）
+func fixChunkOffsets(p []byte, wide bool, shift func(int64) int64) error {
+	if len(p) < 8 {
+		return errors.New("块偏移表长度非法")
+	}
+	n := int(binary.BigEndian.Uint32(p[4:8]))
+	width := 4
+	if wide {
+		width = 8
+	}
+	if n > (len(p)-8)/width {
+		return errors.New("块偏移表条目数非法")
+	}
+	for i := 0; i < n; i++ {
+		e := p[8+i*width:]
+		if wide {
+			binary.BigEndian.PutUint64(e, uint64(shift(int64(binary.BigEndian.Uint64(e)))))
+		} else {
+			binary.BigEndian.PutUint32(e, uint32(shift(int64(binary.BigEndian.Uint32(e)))))
+		}
+	}
+	return nil
+}
+
+// writeBoxHeader 按原有的头长度写出盒子头
+func writeBoxHeader(w io.Writer, typ string, hdr, size int64) error {
+	h := make([]byte, hdr)
+	if hdr == 16 {
+		binary.BigEndian.PutUint32(h, 1)
+		binary.BigEndian.PutUint64(h[8:], uint64(size))
+	} else {
+		binary.BigEndian.PutUint32(h, uint32(size))
+	}
+	copy(h[4:8], typ)
+	_, err := w.Write(h)
+	return err
+}
+
+// writeFreeBox 写出与 b 等长、内容清零的 free 盒子
+func writeFreeBox(w io.Writer, b mp4Box) error {
+	if err := writeBoxHeader(w, "free", b.hdr, b.size); err != nil {
+		return err
+	}
+	_, err := io.CopyN(w, zeroReader{}, b.size-b.hdr)
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// —— dry-run 与校验 ——
+
+// inspectVideo 列出将被删除的盒子；udta 再列出其中的 QuickTime 条目（如 ©xyz 位置）
+func inspectVideo(path string) ([]Finding, error) {
+	l, err := openMP4(path)
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	for range l.topDrops {
+		fs = append(fs, Finding{Item: "XMP（uuid）"})
+	}
+	r := bytes.NewReader(l.moovData)
+	for _, d := range l.moovDrops {
+		if d.typ == "uuid" {
+			fs = append(fs, Finding{Item: d.path + "（XMP）"})
+			continue
+		}
+		fs = append(fs, Finding{Item: d.path})
+		if d.typ != "udta" {
+			continue
+		}
+		kids, _ := mp4Boxes(r, d.off+d.hdr, d.end())
+		for _, k := range kids {
+			fs = append(fs, Finding{Item: d.path + "/" + boxName(k.typ), Value: qtText(l.moovData[k.off+k.hdr : k.end()])})
+		}
+	}
+	return fs, nil
+}
+
+// boxName 将 QuickTime 条目类型首字节 0xA9 显示为 ©
+func boxName(typ string) string {
+	if strings.HasPrefix(typ, "\xa9") {
+		return "©" + typ[1:]
+	}
+	return typ
+}
+
+// qtText 读取 QuickTime 国际化文本条目（16 位长度 + 16 位语言 + 文本），不是该格式时返回空串
+func qtText(p []byte) string {
+	if len(p) < 4 {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(p))
+	if n == 0 || 4+n > len(p) {
+		return ""
+	}
+	return strings.TrimSpace(string(p[4 : 4+n]))
+}
+
+// verifyVideo 检查输出中不再有 udta/meta/XMP 盒子
+func verifyVideo(path string) error {
+	l, err := openMP4(path)
+	if err != nil {
+		return err
+	}
+	if len(l.topDrops) > 0 {
+		return errors.New("仍包含 XMP 盒子")
+	}
+	if len(l.moovDrops) > 0 {
+		return fmt.Errorf("仍包含 %s 盒子", l.moovDrops[0].path)
+	}
+	return nil
+}
+
+func openMP4(path string) (*mp4Layout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return scanMP4(f, info.Size())
+}
@@ -0,0 +1,103 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// isoBox 写出 ISO BMFF 盒子：4 字节长度、类型与内容
+func isoBox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	return append(append(binary.BigEndian.AppendUint32(nil, uint32(8+len(body))), typ...), body...)
+}
+
+// chunkOffsetBox 写出只有一个条目的 stco（wide 时为 co64）
+func chunkOffsetBox(wide bool, off int64) []byte {
+	p := binary.BigEndian.AppendUint32(nil, 0) // 版本与标志
+	p = binary.BigEndian.AppendUint32(p, 1)
+	if wide {
+		return isoBox("co64", binary.BigEndian.AppendUint64(p, uint64(off)))
+	}
+	return isoBox("stco", binary.BigEndian.AppendUint32(p, uint32(off)))
+}
+
+// testMP4 返回 ftyp、moov、mdat 依次排列的 MP4：两条轨道分别用 stco 与 co64 指向 mdat 中的两段样本，
+// moov/udta 中有 ©xyz 位置，轨道中还有 udta 与 XMP uuid
+func testMP4(samples ...string) []byte {
+	ftyp := isoBox("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	build := func(offs []int64) []byte {
+		trak := func(i int) []byte {
+			return isoBox("trak",
+				isoBox("tkhd", make([]byte, 84)),
+				isoBox("mdia", isoBox("minf", isoBox("stbl", chunkOffsetBox(i == 1, offs[i])))),
+				isoBox("udta", isoBox("\xa9mak", []byte("\x00\x05\x00\x00Apple"))),
+			)
+		}
+		return isoBox("moov",
+			isoBox("mvhd", make([]byte, 100)),
+			trak(0), trak(1),
+			isoBox("udta", isoBox("\xa9xyz", []byte("\x00\x12\x15\xc7+37.7749-122.4194/"))),
+			isoBox("uuid", xmpUUID, []byte(`<x:xmpmeta>Alice</x:xmpmeta>`)),
+		)
+	}
+	moov := build([]int64{0, 0})
+	base := int64(len(ftyp) + len(moov) + 8)
+	offs := []int64{base, base + int64(len(samples[0]))}
+	mdat := isoBox("mdat", []byte(samples[0]+samples[1]))
+	return bytes.Join([][]byte{ftyp, build(offs), mdat}, nil)
+}
+
+// mp4ChunkOffsets 返回各轨道 stco/co64 中的第一个偏移
+func mp4ChunkOffsets(t *testing.T, b []byte) []int64 {
+	t.Helper()
+	var offs []int64
+	for _, typ := range []string{"stco", "co64"} {
+		i := bytes.Index(b, []byte(typ+"\x00\x00\x00\x00\x00\x00\x00\x01"))
+		if i < 0 {
+			t.Fatalf("找不到 %s", typ)
+		}
+		e := b[i+12:]
+		if typ == "co64" {
+			offs = append(offs, int64(binary.BigEndian.Uint64(e)))
+		} else {
+			offs = append(offs, int64(binary.BigEndian.Uint32(e)))
+		}
+	}
+	return offs
+}
+
+func TestVideoLocationRemovedOffsetsFixed(t *testing.T) {
+	samples := []string{"SAMPLE-ONE", "SAMPLE-TWO!"}
+	in := testMP4(samples...)
+	for i, off := range mp4ChunkOffsets(t, in) {
+		if string(in[off:off+int64(len(samples[i]))]) != samples[i] {
+			t.Fatal("样本的块偏移构造有误")
+		}
+	}
+	p := writeTestFile(t, t.TempDir(), "clip.mp4", in)
+	s := newTestScrubber()
+	s.WithVideo = true
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"\xa9xyz", "+37.7749", "\xa9mak", "Apple", "xmpmeta", "udta"} {
+		if bytes.Contains(out, []byte(leak)) {
+			t.Errorf("输出仍含有 %q", leak)
+		}
+	}
+	if len(out) >= len(in) {
+		t.Fatalf("输出 %d 字节，删除盒子后应小于输入的 %d 字节", len(out), len(in))
+	}
+	for i, off := range mp4ChunkOffsets(t, out) {
+		if off < 0 || off+int64(len(samples[i])) > int64(len(out)) || string(out[off:off+int64(len(samples[i]))]) != samples[i] {
+			t.Errorf("第 %d 条轨道的块偏移 %d 未指向样本数据", i+1, off)
+		}
+	}
+}
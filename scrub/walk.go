@@ -0,0 +1,327 @@
+package scrub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// —— 目录遍历与符号链接策略 ——
+// 默认跳过符号链接：原地替换时 rename 会把链接本身换成普通文件，
+// 链接目标还可能在输入目录之外。开启 FollowSymlinks 后：
+//   - 指向目录的链接按链接路径展开遍历（真实目录去重，防止环）；
+//   - 指向文件的链接改为处理其真实路径，链接本身保持不变；
+//   - 目标在输入根目录之外时拒绝，除非同时开启 AllowExternalLinks。
+//
+// ExcludeDirs 匹配的目录整个跳过（不含输入根目录本身）：不含 / 的模式与目录名比较，
+// 如 node_modules、.git、*.bak；含 / 的模式与相对输入根目录的路径比较，如 docs/old*。
+// 输出、隔离与备份目录位于输入目录之内时同样跳过，避免把上一次（或本次）的输出再处理一遍。
+//
+// WalkWorkers 大于 1 时改用 walkParallel：多个协程同时读取不同的子目录，适合网络文件系统上
+// 单次 ReadDir/Stat 延迟高、条目数以百万计的目录。符号链接与排除规则不变，但文件的发现顺序不再固定。
+
+type walker struct {
+	s       *Scrubber
+	top     string          // 调用方给出的根目录，ExcludeDirs 按相对它的路径匹配
+	root    string          // 解析符号链接后的根目录
+	ownDirs []string        // 解析后的输出、隔离与备份目录（已设置的）
+	visited map[string]bool // 已进入的真实目录
+	seen    map[string]bool // 已交给 fn 的真实文件，避免同一文件经不同路径被并发处理
+	mu      sync.Mutex      // 并发遍历时保护 visited 与 seen
+}
+
+// walkFiles 与 filepath.WalkDir 用法相同，但按上述策略处理符号链接
+func (s *Scrubber) walkFiles(root string, fn fs.WalkDirFunc) error {
+	w, err := s.newWalker(root)
+	if err != nil {
+		return err
+	}
+	return w.walk(root, root, fn)
+}
+
+func (s *Scrubber) newWalker(root string) (*walker, error) {
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	w := &walker{s: s, top: root, root: real, visited: map[string]bool{real: true}, seen: map[string]bool{}}
+	for _, d := range []string{s.OutputDir, s.QuarantineDir, s.BackupDir} {
+		if d != "" {
+			w.ownDirs = append(w.ownDirs, realAbs(d))
+		}
+	}
+	// 输出或隔离目录就是输入根目录时无法跳过：输出会逐个覆盖原文件且不做备份，隔离会把文件复制到自身。
+	// 备份目录与输入根目录相同时备份照常写在原文件旁边，不受影响
+	top := realAbs(root)
+	for _, d := range []struct{ name, dir string }{{"output-dir", s.OutputDir}, {"quarantine-dir", s.QuarantineDir}} {
+		if d.dir != "" && realAbs(d.dir) == top {
+			return nil, fmt.Errorf("%s 不能是输入目录本身: %s", d.name, d.dir)
+		}
+	}
+	return w, nil
+}
+
+// walk 遍历真实目录 dir，回调时把路径换算到逻辑路径 logical 之下
+func (w *walker) walk(dir, logical string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && p != dir {
+			rel, _ := filepath.Rel(dir, p)
+			p = filepath.Join(logical, rel)
+		} else if err == nil {
+			p = logical
+		}
+		if err == nil && d.IsDir() && w.skipDir(p) {
+			return filepath.SkipDir
+		}
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			if err == nil && !d.IsDir() && w.s.FollowSymlinks && !w.first(p) {
+				return nil
+			}
+			return fn(p, d, err)
+		}
+
+		if !w.s.FollowSymlinks {
+			w.s.logger().Debugf("跳过符号链接: %s", p)
+			return nil
+		}
+		target, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			w.s.logger().Warnf("跳过无法解析的符号链接 %s: %v", p, err)
+			return nil
+		}
+		if !w.s.AllowExternalLinks && !within(w.root, target) {
+			w.s.logger().Warnf("跳过指向输入目录之外的符号链接: %s -> %s", p, target)
+			return nil
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			return fn(p, d, err)
+		}
+		if !info.IsDir() {
+			if !w.first(target) {
+				return nil
+			}
+			return fn(target, fs.FileInfoToDirEntry(info), nil)
+		}
+		if w.visited[target] || w.skipDir(p) {
+			return nil // 已遍历过（含链接成环）或被排除
+		}
+		w.visited[target] = true
+		err = w.walk(target, p, fn)
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+		return err
+	})
+}
+
+// skipDir 判断目录 p 是否应跳过；输入根目录本身从不跳过
+func (w *walker) skipDir(p string) bool {
+	rel, err := filepath.Rel(w.top, p)
+	if err != nil || rel == "." {
+		return false
+	}
+	if len(w.ownDirs) > 0 {
+		real := realAbs(p)
+		for _, d := range w.ownDirs {
+			if real == d {
+				w.s.logger().Debugf("跳过输出目录: %s", p)
+				return true
+			}
+		}
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pat := range w.s.ExcludeDirs {
+		pat = strings.TrimSuffix(filepath.ToSlash(strings.TrimSpace(pat)), "/")
+		name := path.Base(rel)
+		if strings.Contains(pat, "/") {
+			name = rel
+		}
+		if ok, _ := path.Match(pat, name); ok {
+			w.s.logger().Debugf("跳过排除的目录: %s", p)
+			return true
+		}
+	}
+	return false
+}
+
+// realAbs 返回解析符号链接后的绝对路径；路径不存在（如尚未创建的输出目录）时解析最近的已存在上级
+func realAbs(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	// 否则输入根目录经过符号链接时，遍历中途才创建的输出目录会因路径形式不同而匹配不上
+	rest := ""
+	for dir := abs; ; {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// first 返回真实文件是否第一次出现
+func (w *walker) first(p string) bool {
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		real = p
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seen[real] {
+		return false
+	}
+	w.seen[real] = true
+	return true
+}
+
+// within 判断 p 是否位于 root 之内（两者均为解析后的路径）
+func within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// dirTask 为并发遍历中待读取的目录：dir 为真实路径，logical 为回调时使用的路径
+type dirTask struct{ dir, logical string }
+
+// walkParallel 以 n 个协程并发读取 root 下的目录，对每个文件（以及读取失败的目录，err 非空）调用 fn；
+// fn 会被并发调用，返回错误或 ctx 取消时停止遍历并返回该错误。与 walk 不同，不对目录本身调用 fn
+func (w *walker) walkParallel(ctx context.Context, root string, n int, fn fs.WalkDirFunc) error {
+	var (
+		mu      sync.Mutex
+		cond    = sync.NewCond(&mu)
+		queue   = []dirTask{{w.root, root}}
+		pending = 1 // 排队中与读取中的目录数
+		first   error
+		wg      sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if first == nil {
+			first = err
+		}
+		queue = nil
+		cond.Broadcast()
+		mu.Unlock()
+	}
+	push := func(t dirTask) {
+		mu.Lock()
+		if first == nil {
+			queue = append(queue, t)
+			pending++
+			cond.Signal()
+		}
+		mu.Unlock()
+	}
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				for len(queue) == 0 && pending > 0 && first == nil {
+					cond.Wait()
+				}
+				if len(queue) == 0 {
+					mu.Unlock()
+					return
+				}
+				// 后进先出：优先深入子目录，队列长度随深度而不是宽度增长
+				t := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				mu.Unlock()
+
+				if err := ctx.Err(); err != nil {
+					fail(err)
+				} else if err := w.readDir(t, fn, push); err != nil {
+					fail(err)
+				}
+
+				mu.Lock()
+				if pending--; pending == 0 {
+					cond.Broadcast()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
+
+// readDir 读取一个目录：文件交给 fn，子目录（含按策略跟随的目录链接）交给 push
+func (w *walker) readDir(t dirTask, fn fs.WalkDirFunc, push func(dirTask)) error {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return fn(t.logical, nil, err)
+	}
+	for _, d := range entries {
+		p := filepath.Join(t.logical, d.Name())
+		switch {
+		case d.IsDir():
+			if !w.skipDir(p) {
+				push(dirTask{filepath.Join(t.dir, d.Name()), p})
+			}
+			continue
+		case d.Type()&fs.ModeSymlink == 0:
+			if w.s.FollowSymlinks && !w.first(p) {
+				continue
+			}
+			if err := fn(p, d, nil); err != nil {
+				return err
+			}
+			continue
+		case !w.s.FollowSymlinks:
+			w.s.logger().Debugf("跳过符号链接: %s", p)
+			continue
+		}
+
+		target, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			w.s.logger().Warnf("跳过无法解析的符号链接 %s: %v", p, err)
+			continue
+		}
+		if !w.s.AllowExternalLinks && !within(w.root, target) {
+			w.s.logger().Warnf("跳过指向输入目录之外的符号链接: %s -> %s", p, target)
+			continue
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			if err := fn(p, d, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if !info.IsDir() {
+			if w.first(target) {
+				if err := fn(target, fs.FileInfoToDirEntry(info), nil); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if w.skipDir(p) {
+			continue
+		}
+		w.mu.Lock()
+		seen := w.visited[target] // 已遍历过（含链接成环）
+		w.visited[target] = true
+		w.mu.Unlock()
+		if !seen {
+			push(dirTask{target, p})
+		}
+	}
+	return nil
+}
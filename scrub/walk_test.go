@@ -0,0 +1,89 @@
+package scrub
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOutputDirInsideInputSkipped(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		out  string   // 相对输入目录的输出目录
+		want []string // 期望处理的文件
+	}{
+		// 上一次运行留下的输出：本次不应再被当作输入
+		{"已存在", "out", []string{"a.docx", "sub/b.docx"}},
+		// 遍历开始时尚不存在、处理过程中才创建的输出目录同样跳过
+		{"尚不存在", "new/out", []string{"a.docx", "out/old.docx", "sub/b.docx"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			in := t.TempDir()
+			data := zipBytes(t, testDocx(`<w:p/>`)...)
+			writeTestFile(t, in, "a.docx", data)
+			writeTestFile(t, in, "sub/b.docx", data)
+			writeTestFile(t, in, "out/old.docx", data)
+			out := filepath.Join(in, c.out)
+
+			s := newTestScrubber()
+			s.OutputDir = out
+			rep, err := s.ScrubDir(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range rep.Results {
+				rel, _ := filepath.Rel(in, r.Path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(c.want, ",") {
+				t.Errorf("处理的文件 %v, 期望 %v", got, c.want)
+			}
+			for _, name := range c.want {
+				if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+					t.Errorf("输出目录中缺少 %s: %v", name, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(out, c.out)); err == nil {
+				t.Error("输出目录被当作输入处理，输出中又出现了它自身")
+			}
+		})
+	}
+}
+
+func TestOutputDirEqualToInputRejected(t *testing.T) {
+	in := t.TempDir()
+	data := zipBytes(t, testDocx(`<w:p/>`)...)
+	p := writeTestFile(t, in, "a.docx", data)
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(in, link); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		set  func(s *Scrubber, dir string)
+	}{
+		{"output-dir", func(s *Scrubber, dir string) { s.OutputDir = dir }},
+		{"quarantine-dir", func(s *Scrubber, dir string) { s.QuarantineDir = dir }},
+	} {
+		// 同一目录的不同写法（末尾分隔符、符号链接）同样拒绝
+		for _, dir := range []string{in, in + string(filepath.Separator), link} {
+			s := newTestScrubber()
+			c.set(s, dir)
+			rep, err := s.ScrubDir(in)
+			if err == nil || !strings.Contains(err.Error(), c.name) {
+				t.Errorf("%s=%s: err = %v, 期望拒绝", c.name, dir, err)
+			}
+			if len(rep.Results) != 0 {
+				t.Errorf("%s=%s: 拒绝时不应处理任何文件，实际处理了 %d 个", c.name, dir, len(rep.Results))
+			}
+		}
+	}
+	if got, _ := os.ReadFile(p); string(got) != string(data) {
+		t.Error("拒绝处理时原文件不应改动")
+	}
+}
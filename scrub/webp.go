@@ -0,0 +1,82 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// —— WebP：直接在 RIFF 容器层删除 EXIF/XMP 块，不重新编码 ——
+// x/image 只提供 WebP 解码器，重新编码做不到；而元数据本就放在独立的块里，
+// 跳过这些块即可无损去除，VP8/VP8L/ALPH/ANIM 等图像数据原样保留。
+
+// VP8X 扩展头中的标志位
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+	webpFlagICC  = 0x20
+)
+
+// webpRIFF 校验 WebP 文件头，返回 RIFF 头声明的范围 b[:8+长度]。
+// 声明的长度超出文件时视为文件被截断；RIFF 之后的多余字节（可能是附加的任意数据）不属于图像，不在范围内
+func webpRIFF(b []byte) ([]byte, error) {
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return nil, errors.New("不是合法的 WebP 文件")
+	}
+	size := int64(binary.LittleEndian.Uint32(b[4:8]))
+	if size < 4 || 8+size > int64(len(b)) {
+		return nil, fmt.Errorf("WebP RIFF 长度 %d 超出文件大小 %d（文件被截断）", size, len(b))
+	}
+	return b[:8+size], nil
+}
+
+// stripWebP 删除 RIFF 中的 "EXIF" 与 "XMP " 块（stripICC 时还有 "ICCP"），并同步清除 VP8X 中对应的标志位；
+// RIFF 之后的多余字节不写出
+func stripWebP(b []byte, stripICC bool) ([]byte, error) {
+	b, err := webpRIFF(b)
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(b)))
+	out.Write(b[:12]) // RIFF 总长度在最后回填
+
+	for p := 12; p < len(b); {
+		if p+8 > len(b) {
+			return nil, errors.New("WebP 块头被截断")
+		}
+		fourCC := string(b[p : p+4])
+		size := int(binary.LittleEndian.Uint32(b[p+4 : p+8]))
+		if p+8+size > len(b) {
+			return nil, fmt.Errorf("WebP 块 %q 长度越界", fourCC)
+		}
+		// 奇数长度的块后有 1 字节填充；容忍部分编码器省略 RIFF 末尾的填充
+		end := min(p+8+size+size&1, len(b))
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+			// 丢弃
+		case "ICCP":
+			if !stripICC {
+				out.Write(b[p:end])
+			}
+		case "VP8X":
+			chunk := append([]byte(nil), b[p:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+				if stripICC {
+					chunk[8] &^= webpFlagICC
+				}
+			}
+			out.Write(chunk)
+		default:
+			out.Write(b[p:end])
+		}
+		p = end
+	}
+
+	res := out.Bytes()
+	binary.LittleEndian.PutUint32(res[4:8], uint32(len(res)-8))
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// webpBytes 把 fourCC、数据交替排列的块写成 WebP（RIFF 长度按块计算，奇数长度补 1 字节）
+func webpBytes(chunks ...string) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WEBP")
+	for i := 0; i+1 < len(chunks); i += 2 {
+		b = append(b, chunks[i]...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(chunks[i+1])))
+		b = append(b, chunks[i+1]...)
+		if len(chunks[i+1])%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	binary.LittleEndian.PutUint32(b[4:8], uint32(len(b)-8))
+	return b
+}
+
+func TestStripWebPStopsAtRIFFSize(t *testing.T) {
+	img := webpBytes(
+		"VP8X", string([]byte{webpFlagEXIF, 0, 0, 0, 1, 0, 0, 1, 0, 0}),
+		"EXIF", "Exif\x00\x00Alice",
+		"VP8L", "pixels",
+	)
+	in := append(bytes.Clone(img), "TRAILING-SECRET"...)
+
+	out, err := stripWebP(in, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out, []byte("TRAILING-SECRET")) || bytes.Contains(out, []byte("Alice")) {
+		t.Error("输出仍含有 RIFF 之后的多余字节或 EXIF")
+	}
+	if got := int(binary.LittleEndian.Uint32(out[4:8])); got != len(out)-8 {
+		t.Errorf("RIFF 长度 %d, 期望 %d", got, len(out)-8)
+	}
+	if out[20]&webpFlagEXIF != 0 {
+		t.Error("VP8X 仍带有 EXIF 标志")
+	}
+	if !bytes.HasSuffix(out, []byte("VP8L\x06\x00\x00\x00pixels")) {
+		t.Error("图像数据块应原样保留")
+	}
+}
+
+func TestStripWebPRejectsTruncated(t *testing.T) {
+	img := webpBytes("VP8L", "pixels")
+	if _, err := stripWebP(img[:len(img)-3], false); err == nil {
+		t.Error("RIFF 长度超出文件时应报错")
+	}
+	if _, err := (&Scrubber{}).inspectWebP(img[:len(img)-3]); err == nil {
+		t.Error("inspectWebP 同样应拒绝被截断的文件")
+	}
+}
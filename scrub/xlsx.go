@@ -0,0 +1,107 @@
+package scrub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// —— Excel 批注作者与计算链（--deep-xlsx）——
+// 删除 docProps 只去掉了文档属性，批注仍带着真实用户名：
+//   - xl/comments*.xml 的 <authors> 列出所有批注作者，Excel 还会把“作者名:”作为批注正文的第一段；
+//   - 新式“线程批注”的作者在 xl/persons/person.xml 中，含显示名与账号（userId/providerId）。
+// 作者统一替换为 Author，批注内容与单元格数据不变。xl/calcChain.xml 只是公式计算顺序的缓存，
+// 删除后 Excel 打开时会重建；指向它的关系与内容类型声明由 refsEdit 一并去掉，避免 Excel 提示修复。
+
+func xlsxEdit(name string) func(r io.Reader, w io.Writer) error {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, "xl/comments") && strings.HasSuffix(lower, ".xml"):
+		return anonymizeXLComments
+	case strings.HasPrefix(lower, "xl/persons/") && strings.HasSuffix(lower, ".xml"):
+		return xmlEditor(anonymizeXLPerson)
+	}
+	return nil
+}
+
+// anonymizeXLComments 替换 <author> 中的作者名，以及批注正文开头的“作者名:”
+func anonymizeXLComments(r io.Reader, w io.Writer) error {
+	var names []string // <authors> 位于 <commentList> 之前，流式处理即可
+	return editXML(r, w, nil, nil, func(parent xml.Name, text string) string {
+		switch parent.Local {
+		case "author":
+			if n := strings.TrimSpace(text); n != "" {
+				names = append(names, n)
+			}
+			return anonAuthor
+		case "t":
+			for _, n := range names {
+				if strings.HasPrefix(text, n+":") {
+					return anonAuthor + text[len(n):]
+				}
+			}
+		}
+		return text
+	})
+}
+
+// anonymizeXLPerson 处理线程批注的作者：显示名与账号
+func anonymizeXLPerson(el *xml.StartElement) {
+	if el.Name.Local != "person" {
+		return
+	}
+	for i, a := range el.Attr {
+		switch a.Name.Local {
+		case "displayName", "userId":
+			el.Attr[i].Value = anonAuthor
+		case "providerId":
+			el.Attr[i].Value = "None"
+		}
+	}
+}
+
+// xlsxAuthors 收集批注作者与线程批注人员的显示名（去重，按出现顺序）
+func xlsxAuthors(path string) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	seen := map[string]bool{}
+	var authors []string
+	add := func(n string) {
+		if n = strings.TrimSpace(n); n != "" && !seen[n] {
+			seen[n] = true
+			authors = append(authors, n)
+		}
+	}
+	for _, zf := range zr.File {
+		lower := strings.ToLower(zf.Name)
+		isComments := strings.HasPrefix(lower, "xl/comments") && strings.HasSuffix(lower, ".xml")
+		isPersons := strings.HasPrefix(lower, "xl/persons/") && strings.HasSuffix(lower, ".xml")
+		if !isComments && !isPersons {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = editXML(r, io.Discard, nil, func(el *xml.StartElement) {
+			if el.Name.Local == "person" {
+				add(xmlAttr(*el, "displayName"))
+			}
+		}, func(parent xml.Name, text string) string {
+			if parent.Local == "author" {
+				add(text)
+			}
+			return text
+		})
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", zf.Name, err)
+		}
+	}
+	return authors, nil
+}
@@ -0,0 +1,58 @@
+package scrub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXLSXCommentAuthorAnonymized(t *testing.T) {
+	const ns = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+	data := zipBytes(t,
+		"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+			`<Default Extension="xml" ContentType="application/xml"/>`+
+			`<Override PartName="/xl/calcChain.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.calcChain+xml"/></Types>`,
+		"_rels/.rels", testRootRels,
+		"docProps/core.xml", testCore,
+		"xl/workbook.xml", `<workbook `+ns+`><sheets><sheet name="Sheet1" sheetId="1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+			`<Relationship Id="rId9" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/calcChain" Target="calcChain.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml", `<worksheet `+ns+`><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Cell value</t></is></c></row></sheetData></worksheet>`,
+		"xl/comments1.xml", `<comments `+ns+`><authors><author>Bob Reviewer</author></authors><commentList>`+
+			`<comment ref="A1" authorId="0"><text><r><t>Bob Reviewer:</t></r><r><t xml:space="preserve">`+"\n"+`please check</t></r></text></comment>`+
+			`</commentList></comments>`,
+		"xl/persons/person.xml", `<personList xmlns="http://schemas.microsoft.com/office/spreadsheetml/2018/threadedcomments">`+
+			`<person displayName="Bob Reviewer" id="{1}" userId="bob@corp.example" providerId="AD"/></personList>`,
+		"xl/calcChain.xml", `<calcChain `+ns+`><c r="A1" i="1"/></calcChain>`,
+	)
+	p := writeTestFile(t, t.TempDir(), "book.xlsx", data)
+
+	s := newTestScrubber()
+	s.DeepXLSX = true
+	s.Verify = true
+	if err := s.ScrubFile(p); err != nil {
+		t.Fatal(err)
+	}
+	_, parts := readZip(t, p)
+	for name, content := range parts {
+		for _, leak := range []string{"Bob", "bob@corp.example", "Alice Secret", "calcChain.xml"} {
+			if strings.Contains(content, leak) {
+				t.Errorf("%s 仍含有 %q", name, leak)
+			}
+		}
+	}
+	if _, ok := parts["xl/calcChain.xml"]; ok {
+		t.Error("xl/calcChain.xml 未被删除")
+	}
+	comments := parts["xl/comments1.xml"]
+	for _, want := range []string{"<author>" + anonAuthor + "</author>", "<t>" + anonAuthor + ":</t>", "please check"} {
+		if !strings.Contains(comments, want) {
+			t.Errorf("批注中缺少 %q", want)
+		}
+	}
+	if !strings.Contains(parts["xl/persons/person.xml"], `displayName="`+anonAuthor+`"`) {
+		t.Error("线程批注人员的显示名应匿名化")
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], "Cell value") {
+		t.Error("单元格数据应保持不变")
+	}
+}
@@ -0,0 +1,209 @@
+package scrub
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// —— 流式 XML 改写 ——
+// encoding/xml 的 Encoder 会改写命名空间前缀，Office 对此很敏感，
+// 因此这里用 RawToken 逐个读取（保留原始前缀），再手工序列化回去。
+// 空元素仍输出为 <a/>，避免文件体积无谓增大。
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+// xmlEdit 在输出前修改起始标签（通常是改写或删除属性）
+type xmlEdit func(el *xml.StartElement)
+
+// xmlDrop 返回 true 时整个元素（含子元素与文本）不输出
+type xmlDrop func(el xml.StartElement) bool
+
+// xmlText 在输出前改写文本节点；parent 为所在元素的名字（Space 为原始前缀）
+type xmlText func(parent xml.Name, text string) string
+
+// rewriteXML 从 r 读取 XML，对每个起始标签调用 fn 后写入 w
+func rewriteXML(r io.Reader, w io.Writer, fn xmlEdit) error {
+	return filterXML(r, w, nil, fn)
+}
+
+// filterXML 与 rewriteXML 相同，另外删除 drop 返回 true 的元素
+func filterXML(r io.Reader, w io.Writer, drop xmlDrop, fn xmlEdit) error {
+	return editXML(r, w, drop, fn, nil)
+}
+
+// editXML 与 filterXML 相同，另外用 text 改写文本节点
+func editXML(r io.Reader, w io.Writer, drop xmlDrop, fn xmlEdit, text xmlText) error {
+	d := xml.NewDecoder(r)
+	bw := bufio.NewWriter(w)
+	pending := false    // 上一个起始标签尚未输出 ">"，用于合并为自闭合标签
+	skip := 0           // 正在删除的元素嵌套深度
+	var open []xml.Name // 已输出、尚未闭合的元素
+
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("解析 XML 失败: %w", err)
+		}
+		if skip > 0 {
+			switch tok.(type) {
+			case xml.StartElement:
+				skip++
+			case xml.EndElement:
+				skip--
+			}
+			continue
+		}
+		if t, ok := tok.(xml.StartElement); ok && drop != nil && drop(t) {
+			skip = 1
+			continue
+		}
+		if _, ok := tok.(xml.EndElement); ok && len(open) > 0 {
+			open = open[:len(open)-1]
+		}
+		if _, ok := tok.(xml.EndElement); ok && pending {
+			bw.WriteString("/>")
+			pending = false
+			continue
+		}
+		if pending {
+			bw.WriteByte('>')
+			pending = false
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if fn != nil {
+				fn(&t)
+			}
+			bw.WriteByte('<')
+			bw.WriteString(qname(t.Name))
+			for _, a := range t.Attr {
+				bw.WriteByte(' ')
+				bw.WriteString(qname(a.Name))
+				bw.WriteString(`="`)
+				attrEscaper.WriteString(bw, a.Value)
+				bw.WriteByte('"')
+			}
+			open = append(open, t.Name)
+			pending = true
+		case xml.EndElement:
+			bw.WriteString("</")
+			bw.WriteString(qname(t.Name))
+			bw.WriteByte('>')
+		case xml.CharData:
+			s := string(t)
+			if text != nil && len(open) > 0 {
+				s = text(open[len(open)-1], s)
+			}
+			textEscaper.WriteString(bw, s)
+		case xml.Comment:
+			bw.WriteString("<!--")
+			bw.Write(t)
+			bw.WriteString("-->")
+		case xml.ProcInst:
+			bw.WriteString("<?")
+			bw.WriteString(t.Target)
+			if len(t.Inst) > 0 {
+				bw.WriteByte(' ')
+				bw.Write(t.Inst)
+			}
+			bw.WriteString("?>")
+		case xml.Directive:
+			declareEntities(d, t)
+			bw.WriteString("<!")
+			bw.Write(t)
+			bw.WriteByte('>')
+		}
+	}
+	if pending {
+		bw.WriteByte('>')
+	}
+	return bw.Flush()
+}
+
+// —— DTD 内部子集中的实体 ——
+// Illustrator 把命名空间 URI 声明为内部子集中的实体（<!ENTITY ns_ai "http://ns.adobe.com/AdobeIllustrator/10.0/">），
+// 再以 xmlns:i="&ns_ai;" 引用。严格模式的 Decoder 只认识预定义实体，遇到这类引用直接报错，
+// 因此读到 DOCTYPE 时把其中以字面值定义的一般实体登记到 Decoder.Entity；参数实体与外部实体不展开。
+
+// declareEntities 把 DOCTYPE 指令 dir 中的一般实体登记到 d.Entity（同名实体以先出现的为准）
+func declareEntities(d *xml.Decoder, dir xml.Directive) {
+	s := string(dir)
+	if !strings.HasPrefix(s, "DOCTYPE") {
+		return
+	}
+	const space = " \t\r\n"
+	for {
+		i := strings.Index(s, "<!ENTITY")
+		if i < 0 {
+			return
+		}
+		s = strings.TrimLeft(s[i+len("<!ENTITY"):], space)
+		if strings.HasPrefix(s, "%") {
+			continue
+		}
+		end := strings.IndexAny(s, space)
+		if end <= 0 {
+			return
+		}
+		name := s[:end]
+		s = strings.TrimLeft(s[end:], space)
+		if s == "" || (s[0] != '"' && s[0] != '\'') {
+			continue // SYSTEM/PUBLIC 外部实体
+		}
+		j := strings.IndexByte(s[1:], s[0])
+		if j < 0 {
+			return
+		}
+		if d.Entity == nil {
+			d.Entity = map[string]string{}
+		}
+		if _, ok := d.Entity[name]; !ok {
+			d.Entity[name] = s[1 : 1+j]
+		}
+		s = s[2+j:]
+	}
+}
+
+// qname 还原带前缀的名字（RawToken 中 Space 即原始前缀）
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// xmlEditor 把 xmlEdit 包装为 rewriteZip 可用的条目改写函数
+func xmlEditor(fn xmlEdit) func(r io.Reader, w io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return rewriteXML(r, w, fn)
+	}
+}
+
+// xmlDropper 把 xmlDrop 包装为 rewriteZip 可用的条目改写函数
+func xmlDropper(drop xmlDrop) func(r io.Reader, w io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return filterXML(r, w, drop, nil)
+	}
+}
+
+// xmlAttr 返回本地名为 local 的属性值（忽略前缀），不存在时返回空串
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package scrub
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// —— 条目名检查（zip-slip 防护）——
+// zip 与 tar 的条目名由归档作者任意填写：../../etc/x、/etc/x、C:\x 这样的名字在解压时会写到目标目录之外，
+// 含 NUL 的名字在不同工具中会被截断成不同的路径。本工具处理时不按条目名落盘（tar 成员以序号命名），
+// 但原样写进输出会把隐患带给下游的解压工具，因此默认拒绝处理这类归档，AllowUnsafeNames 时照常处理、原样保留。
+// 反斜杠在 Windows 上会被当作分隔符，检查时一并按分隔符看待。
+
+// ErrUnsafeEntryName 表示归档中有绝对路径、含 .. 或 NUL 的条目名；AllowUnsafeNames 时不检查
+var ErrUnsafeEntryName = errors.New("归档中有不安全的条目名")
+
+// unsafeEntryName 返回条目名不安全的原因，安全时返回空串
+func unsafeEntryName(name string) string {
+	if strings.ContainsRune(name, 0) {
+		return "含有 NUL 字符"
+	}
+	n := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(n, "/") || len(n) >= 2 && n[1] == ':' {
+		return "是绝对路径"
+	}
+	for _, seg := range strings.Split(n, "/") {
+		if seg == ".." {
+			return "含有 .."
+		}
+	}
+	return ""
+}
+
+// checkEntryName 在 name 不安全且未开启 AllowUnsafeNames 时返回错误
+func (s *Scrubber) checkEntryName(name string) error {
+	if s.AllowUnsafeNames {
+		return nil
+	}
+	if reason := unsafeEntryName(name); reason != "" {
+		return fmt.Errorf("%w（%s）: %q", ErrUnsafeEntryName, reason, name)
+	}
+	return nil
+}
+
+// —— 条目名规范化（--normalize-filenames）——
+// 部分 Windows 导出工具以反斜杠作为分隔符写出条目名（word\media\image1.png），按 zip 规范应为 /。
+// 这样的条目 Office 打不开，也会逃过按前缀判断的删除规则（docProps\core.xml 不以 docProps/ 开头）。
+// NormalizeZipNames 时把反斜杠换成 /，去掉多余的 ./ 与重复的分隔符，保留、改写与删除都按规范化后的名字判断。
+// 大小写保持不变：包内的关系、清单与内容类型声明按原样引用部件名，改动大小写反而会造成悬空引用。
+
+// zipEntryName 检查条目名并返回写入输出时使用的名字
+func (s *Scrubber) zipEntryName(name string) (string, error) {
+	if err := s.checkEntryName(name); err != nil {
+		return "", err
+	}
+	if !s.NormalizeZipNames {
+		return name, nil
+	}
+	n := strings.ReplaceAll(name, `\`, "/")
+	dir := strings.HasSuffix(n, "/")
+	n = path.Clean(n)
+	if n == "." {
+		return "", fmt.Errorf("条目名为空: %q", name)
+	}
+	if dir {
+		n += "/"
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package scrub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+var unsafeNames = []string{"../x", "/abs", `C:\x`, "a/../../x", `..\x`, "a\x00b"}
+
+func TestUnsafeEntryName(t *testing.T) {
+	for _, name := range unsafeNames {
+		if unsafeEntryName(name) == "" {
+			t.Errorf("%q 应视为不安全", name)
+		}
+	}
+	for _, name := range []string{"word/document.xml", "a/..b/c", "..foo", "dir/", "C"} {
+		if r := unsafeEntryName(name); r != "" {
+			t.Errorf("%q 应视为安全，实际: %s", name, r)
+		}
+	}
+}
+
+func TestZipUnsafeNames(t *testing.T) {
+	for _, name := range unsafeNames {
+		t.Run(name, func(t *testing.T) {
+			data := zipBytes(t, testDocx(`<w:p/>`, name, "payload")...)
+			p := writeTestFile(t, t.TempDir(), "a.docx", data)
+
+			s := newTestScrubber()
+			if err := s.ScrubFile(p); !errors.Is(err, ErrUnsafeEntryName) {
+				t.Fatalf("err = %v, 期望 ErrUnsafeEntryName", err)
+			}
+			if got, _ := os.ReadFile(p); !bytes.Equal(got, data) {
+				t.Fatal("拒绝处理时原文件不应改动")
+			}
+
+			s.AllowUnsafeNames = true
+			if err := s.ScrubFile(p); err != nil {
+				t.Fatalf("AllowUnsafeNames 时应照常处理: %v", err)
+			}
+			_, parts := readZip(t, p)
+			if parts[name] != "payload" {
+				t.Errorf("条目 %q 应原样保留", name)
+			}
+			if _, ok := parts["docProps/core.xml"]; ok {
+				t.Error("docProps/core.xml 未被删除")
+			}
+		})
+	}
+}
+
+func TestTarUnsafeNames(t *testing.T) {
+	for _, name := range unsafeNames {
+		if strings.ContainsRune(name, 0) {
+			continue // archive/tar 无法写出含 NUL 的成员名
+		}
+		t.Run(name, func(t *testing.T) {
+			data := tarBytes(t, false, "ok.txt", "fine", name, "payload")
+			p := writeTestFile(t, t.TempDir(), "a.tar", data)
+
+			s := newTestScrubber()
+			if err := s.ScrubFile(p); !errors.Is(err, ErrUnsafeEntryName) {
+				t.Fatalf("err = %v, 期望 ErrUnsafeEntryName", err)
+			}
+			if got, _ := os.ReadFile(p); !bytes.Equal(got, data) {
+				t.Fatal("拒绝处理时原文件不应改动")
+			}
+
+			s.AllowUnsafeNames = true
+			if err := s.ScrubFile(p); err != nil {
+				t.Fatalf("AllowUnsafeNames 时应照常处理: %v", err)
+			}
+			if got := readTar(t, p); string(got[name]) != "payload" {
+				t.Errorf("成员 %q 应原样保留", name)
+			}
+		})
+	}
+}